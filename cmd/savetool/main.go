@@ -0,0 +1,126 @@
+// Command savetool lists, exports and imports saves.
+//
+// Usage:
+//
+//	savetool [flags] list
+//	savetool [flags] export SAVE FILE
+//	savetool [flags] import FILE
+//
+// SAVE is either the ID or the name of a save. Exported saves are written as
+// compressed archives that may be imported on another machine.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/internal/mods"
+	"github.com/qbradq/after/internal/savearchive"
+)
+
+func main() {
+	flag.StringVar(&game.SaveRoot, "saves", game.SaveRoot, "directory saves are stored in")
+	force := flag.Bool("force", false, "import saves even if mod content differs")
+	modsDir := flag.String("mods", "", "additional directory to discover mods in")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] list | export SAVE FILE | import FILE\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *modsDir != "" {
+		if err := mods.Discover(*modsDir); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if err := run(flag.Args(), *force); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command given by args.
+func run(args []string, force bool) error {
+	if len(args) < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := game.LoadSaveInfo(); err != nil {
+		return err
+	}
+	switch args[0] {
+	case "list":
+		var saves []*game.SaveInfo
+		for _, si := range game.Saves {
+			saves = append(saves, si)
+		}
+		sort.Slice(saves, func(i, j int) bool {
+			return saves[i].Name < saves[j].Name
+		})
+		for _, si := range saves {
+			fmt.Printf("%s\t%s\t%s\n", si.ID, si.Name, si.PlayTime.Round(time.Second))
+		}
+	case "export":
+		if len(args) != 3 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		si, err := findSave(args[1])
+		if err != nil {
+			return err
+		}
+		f, err := os.Create(args[2])
+		if err != nil {
+			return err
+		}
+		if err := savearchive.Export(si.ID, f); err != nil {
+			f.Close()
+			os.Remove(args[2])
+			return err
+		}
+		return f.Close()
+	case "import":
+		if len(args) != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		f, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		si, err := savearchive.Import(f, force)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("imported %s as %s\n", si.Name, si.ID)
+	default:
+		return fmt.Errorf("unknown command %s", args[0])
+	}
+	return nil
+}
+
+// findSave returns the save with the given ID or name.
+func findSave(s string) (*game.SaveInfo, error) {
+	if si, found := game.Saves[s]; found {
+		return si, nil
+	}
+	var ret *game.SaveInfo
+	for _, si := range game.Saves {
+		if si.Name != s {
+			continue
+		}
+		if ret != nil {
+			return nil, fmt.Errorf("more than one save named %s, use the save ID", s)
+		}
+		ret = si
+	}
+	if ret == nil {
+		return nil, fmt.Errorf("save %s not found", s)
+	}
+	return ret, nil
+}
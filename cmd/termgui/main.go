@@ -1,15 +1,23 @@
 package main
 
 import (
+	"flag"
 	"os"
 	"os/signal"
 
 	"github.com/qbradq/after/internal/client/termgui"
+	"github.com/qbradq/after/internal/game"
 	tcelldriver "github.com/qbradq/after/lib/tcell-driver"
 	"github.com/qbradq/after/lib/termui"
 )
 
 func main() {
+	flag.StringVar(&game.SaveRoot, "saves", game.SaveRoot, "directory saves are stored in")
+	flag.DurationVar(&game.AutosaveGameInterval, "autosave-game", game.AutosaveGameInterval, "in-game time between autosaves, 0 to disable")
+	flag.DurationVar(&game.AutosaveWallInterval, "autosave-wall", game.AutosaveWallInterval, "real time between autosaves, 0 to disable")
+	flag.IntVar(&game.BackupCount, "backups", game.BackupCount, "number of rotating backups kept for each save")
+	flag.Int64Var(&termgui.WorldSeed, "seed", 0, "world generation seed for new games, 0 for random")
+	flag.Parse()
 	s := tcelldriver.New()
 	s.Init()
 	defer s.Fini()
@@ -43,6 +43,20 @@ func regPUFn(name string, fn puFn) {
 	puFns[name] = fn
 }
 
+// hearFn is the function signature all "hear" functions take.
+type hearFn func(*AIModel, *game.Actor, *game.CityMap, util.Point, int)
+
+// Global registry of "hear" functions.
+var hearFns = map[string]hearFn{}
+
+// regHearFn registers a "hear" function by name.
+func regHearFn(name string, fn hearFn) {
+	if _, found := hearFns[name]; found {
+		panic(fmt.Errorf("duplicate hear function %s", name))
+	}
+	hearFns[name] = fn
+}
+
 // AIModel implements the thinking AI of CPU-controlled actors.
 type AIModel struct {
 	POI      util.Point    // Point of interest
@@ -50,6 +64,7 @@ type AIModel struct {
 	tid      string        // Template ID
 	act      string        // Act makes the actor take its next action and returns the delay until that actor's next Act() call.
 	periodic string        // Responsible for all periodic updates
+	hear     string        // Responsible for reacting to noises
 	cd       time.Duration // General-purpose cool-down counter
 }
 
@@ -57,6 +72,9 @@ type AIModel struct {
 // defined set of behaviors.
 type aiModelConstructor func() *AIModel
 
+// Current version of the AIModel binary record, see game.ReadVersion.
+const aiModelVersion uint32 = 0
+
 // Global registry of AI model constructors
 var ctors = map[string]aiModelConstructor{}
 
@@ -82,12 +100,29 @@ func NewAIModel(name string) game.AIModel {
 
 // NewAIModelFromReader constructs a new AIModel object from the information in
 // the reader.
-func NewAIModelFromReader(r io.Reader) game.AIModel {
-	_ = util.GetUint32(r)                          // version
-	ai := NewAIModel(util.GetString(r)).(*AIModel) // Template ID
-	ai.POI = util.GetPoint(r)                      // Point of interest
-	ai.act = util.GetString(r)                     // Act handler
-	var b = []byte{0}                              // Path to PoI
+func NewAIModelFromReader(r io.Reader) (game.AIModel, error) {
+	if _, err := game.ReadVersion(r, "AI model", aiModelVersion); err != nil {
+		return nil, err
+	}
+	tid := util.GetString(r) // Template ID
+	if _, found := ctors[tid]; !found {
+		if !game.Repair {
+			return nil, fmt.Errorf("unknown AI model %s", tid)
+		}
+		game.LogRepair("replaced unknown AI model %s with Nil", tid)
+		tid = "Nil"
+	}
+	ai := NewAIModel(tid).(*AIModel)
+	ai.POI = util.GetPoint(r) // Point of interest
+	act := util.GetString(r)  // Act handler
+	if _, found := actFns[act]; found {
+		ai.act = act
+	} else if !game.Repair {
+		return nil, fmt.Errorf("unknown AI act function %s", act)
+	} else {
+		game.LogRepair("replaced unknown AI act function %s with %s", act, ai.act)
+	}
+	var b = []byte{0} // Path to PoI
 	r.Read(b)
 	ai.Path = make(game.Path, b[0])
 	b = make([]byte, b[0])
@@ -95,12 +130,12 @@ func NewAIModelFromReader(r io.Reader) game.AIModel {
 	for i, d := range b {
 		ai.Path[i] = util.Direction(d)
 	}
-	return ai
+	return ai, nil
 }
 
 // Write writes out state information. See NewAIModelFromReader().
 func (ai *AIModel) Write(w io.Writer) {
-	util.PutUint32(w, 0)              // Version
+	util.PutUint32(w, aiModelVersion) // Version
 	util.PutString(w, ai.tid)         // Template ID
 	util.PutPoint(w, ai.POI)          // Point of interest
 	util.PutString(w, ai.act)         // Current act handler
@@ -122,9 +157,18 @@ func (ai *AIModel) Act(a *game.Actor, m *game.CityMap) time.Duration {
 
 // PeriodicUpdate is responsible for calling the "periodic" function.
 func (ai *AIModel) PeriodicUpdate(a *game.Actor, m *game.CityMap, d time.Duration) {
+	a.UpdateEffects(m.Now, d)
 	puFns[ai.periodic](ai, a, m, d)
 }
 
+// Heard is responsible for calling the "hear" function.
+func (ai *AIModel) Heard(a *game.Actor, m *game.CityMap, p util.Point, v int) {
+	if a.Dead {
+		return
+	}
+	hearFns[ai.hear](ai, a, m, p, v)
+}
+
 func (ai *AIModel) setPOI(p util.Point, a *game.Actor, m *game.CityMap) {
 	ai.POI = p
 	ai.Path = ai.Path[:0]
@@ -132,8 +176,8 @@ func (ai *AIModel) setPOI(p util.Point, a *game.Actor, m *game.CityMap) {
 }
 
 func (ai *AIModel) targetPlayer(a *game.Actor, m *game.CityMap) bool {
-	// If we are too far away from the player to see them we bail
-	if a.Position.Distance(m.Player.Position) > a.SightRange {
+	// If we are too far away from the player to see them in this light we bail
+	if a.Position.Distance(m.Player.Position) > m.SightRange(a, m.Player.Position) {
 		return false
 	}
 	// If we can't see the player we can't target them
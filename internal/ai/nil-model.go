@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
 )
 
 // Nil implements an AI model that does nothing.
@@ -12,12 +13,16 @@ func init() {
 		return &AIModel{
 			act:      "nil",
 			periodic: "nil",
+			hear:     "nil",
 		}
 	})
 	regActFn("nil", func(ai *AIModel, a *game.Actor, m *game.CityMap) time.Duration {
 		// Never act
 		return time.Hour
 	})
+	regHearFn("nil", func(ai *AIModel, a *game.Actor, m *game.CityMap, p util.Point, v int) {
+		// Ignore all noises
+	})
 	regPUFn("nil", func(ai *AIModel, a *game.Actor, m *game.CityMap, d time.Duration) {
 		// Standard regeneration
 		days := float64(d) / float64(time.Hour*24)
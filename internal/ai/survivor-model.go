@@ -0,0 +1,265 @@
+package ai
+
+import (
+	"time"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
+)
+
+// Survivor behavior parameters.
+const (
+	svScavengeRange int           = 8                // Distance a survivor looks for loose items
+	svScavengeDelay time.Duration = time.Minute      // Time between looking for loose items
+	svFleeTime      time.Duration = time.Second * 30 // Time a survivor keeps running after losing sight of a threat
+	svFleeHealth    float64       = 0.5              // Head or body health below which a survivor flees instead of fighting
+	svOutnumbered   int           = 3                // Number of threats in sight that makes a survivor flee instead of fighting
+)
+
+// Survivor configures an AIModel to act as a human survivor that wanders,
+// scavenges loose items, fights enemies it can take and flees from everything
+// else including loud noises. Raider configures a survivor that goes looking
+// for the source of noises instead.
+func init() {
+	reg("Survivor", func() *AIModel {
+		return &AIModel{
+			act:      "svActWander",
+			periodic: "nil",
+			hear:     "svHear",
+		}
+	})
+	reg("Raider", func() *AIModel {
+		return &AIModel{
+			act:      "svActWander",
+			periodic: "nil",
+			hear:     "rdHear",
+		}
+	})
+	regHearFn("svHear", func(ai *AIModel, a *game.Actor, m *game.CityMap, p util.Point, v int) {
+		// Loud noises close by mean trouble, get away from them
+		if ai.act == "svActFight" || v < game.NoiseCombat/2 {
+			return
+		}
+		ai.POI = p
+		ai.Path = ai.Path[:0]
+		ai.cd = svFleeTime
+		ai.act = "svActFlee"
+	})
+	regHearFn("rdHear", func(ai *AIModel, a *game.Actor, m *game.CityMap, p util.Point, v int) {
+		// Noise means someone to rob, go take a look
+		if ai.act == "svActFight" || ai.act == "svActFlee" {
+			return
+		}
+		ai.setPOI(p, a, m)
+		ai.act = "svActScavenge"
+	})
+	regActFn("svActWander", func(ai *AIModel, a *game.Actor, m *game.CityMap) time.Duration {
+		if ai.react(a, m) {
+			return ai.Act(a, m)
+		}
+		// Look for something worth picking up every so often
+		if ai.cd <= 0 {
+			ai.cd = svScavengeDelay
+			if ai.findLoot(a, m) {
+				ai.act = "svActScavenge"
+				return ai.Act(a, m)
+			}
+		}
+		// Amble about
+		d := actTime(a)
+		if util.Random(0, 4) == 0 && ai.stepFan(a, m, util.Direction(util.Random(0, 8))) {
+			d = walkTime(a)
+		}
+		ai.cd -= d
+		return d
+	})
+	regActFn("svActScavenge", func(ai *AIModel, a *game.Actor, m *game.CityMap) time.Duration {
+		if ai.react(a, m) {
+			return ai.Act(a, m)
+		}
+		// Pick up everything loose once there
+		if a.Position == ai.POI {
+			for _, i := range m.ItemsAt(a.Position) {
+				if i.Fixed || i.Container {
+					continue
+				}
+				if m.RemoveItem(i) {
+					a.AddItemToInventory(i)
+				}
+			}
+			ai.act = "svActWander"
+			return actTime(a)
+		}
+		if ai.followPath(a, m) {
+			return walkTime(a)
+		}
+		// No way there, give up
+		ai.act = "svActWander"
+		return actTime(a)
+	})
+	regActFn("svActFight", func(ai *AIModel, a *game.Actor, m *game.CityMap) time.Duration {
+		t, n := ai.threat(a, m)
+		if t == nil {
+			ai.act = "svActWander"
+			return actTime(a)
+		}
+		if shouldFlee(a, n) {
+			ai.POI = t.Position
+			ai.cd = svFleeTime
+			ai.act = "svActFlee"
+			return ai.Act(a, m)
+		}
+		// Close enough to attack, do that
+		if a.Position.Distance(t.Position) < 2 {
+			min, max := a.DamageMinMax()
+			t.Damage(a.AttackDamageType(), min, max, m.Now, a)
+			a.WearWeapon()
+			m.MakeNoise(t.Position, game.NoiseCombat)
+			return actTime(a)
+		}
+		// Close in
+		if t.Position != ai.POI || len(ai.Path) == 0 {
+			ai.setPOI(t.Position, a, m)
+		}
+		if ai.followPath(a, m) {
+			return walkTime(a)
+		}
+		return actTime(a)
+	})
+	regActFn("svActFlee", func(ai *AIModel, a *game.Actor, m *game.CityMap) time.Duration {
+		t, _ := ai.threat(a, m)
+		if t != nil {
+			ai.POI = t.Position
+			ai.cd = svFleeTime
+		}
+		if ai.cd <= 0 {
+			ai.cd = 0
+			ai.act = "svActWander"
+			return actTime(a)
+		}
+		d := actTime(a)
+		if ai.stepFan(a, m, ai.POI.DirectionTo(a.Position)) {
+			d = walkTime(a)
+		} else if t != nil && a.Position.Distance(t.Position) < 2 {
+			// Cornered
+			ai.act = "svActFight"
+			return ai.Act(a, m)
+		}
+		ai.cd -= d
+		return d
+	})
+}
+
+// walkTime returns the time it takes the actor to take a step.
+func walkTime(a *game.Actor) time.Duration {
+	return time.Duration(float64(time.Second) * a.WalkSpeed())
+}
+
+// actTime returns the time it takes the actor to take an action.
+func actTime(a *game.Actor) time.Duration {
+	return time.Duration(float64(time.Second) * a.ActSpeed())
+}
+
+// shouldFlee returns true if the actor is too hurt or too outnumbered by the
+// n threats in sight to fight.
+func shouldFlee(a *game.Actor, n int) bool {
+	return n >= svOutnumbered ||
+		a.BodyParts[game.BodyPartBody].Health < svFleeHealth ||
+		a.BodyParts[game.BodyPartHead].Health < svFleeHealth
+}
+
+// threat returns the nearest actor in sight that is hostile to the actor or nil
+// if there are none, along with the number of hostile actors in sight.
+func (ai *AIModel) threat(a *game.Actor, m *game.CityMap) (*game.Actor, int) {
+	var ret *game.Actor
+	n := 0
+	r := a.CurrentSightRange()
+	consider := func(o *game.Actor, visible func() bool) {
+		if o.Dead || a.Position.Distance(o.Position) > r || !m.Hostile(a, o) ||
+			a.Position.Distance(o.Position) > m.SightRange(a, o.Position) || !visible() {
+			return
+		}
+		n++
+		if ret == nil || a.Position.Distance(o.Position) < a.Position.Distance(ret.Position) {
+			ret = o
+		}
+	}
+	for _, o := range m.ActorsWithin(util.NewRectFromRadius(a.Position, r).Overlap(game.LevelBounds(game.LevelOf(a.Position)))) {
+		consider(o, func() bool { return m.HasLineOfSight(a.Position, o.Position) })
+	}
+	consider(&m.Player.Actor, func() bool { return m.CanSeePlayerFrom(a.Position) })
+	return ret, n
+}
+
+// react switches to fighting or fleeing if there is a threat in sight and
+// returns true if it did.
+func (ai *AIModel) react(a *game.Actor, m *game.CityMap) bool {
+	t, n := ai.threat(a, m)
+	if t == nil {
+		return false
+	}
+	if shouldFlee(a, n) {
+		ai.POI = t.Position
+		ai.cd = svFleeTime
+		ai.act = "svActFlee"
+	} else {
+		ai.Path = ai.Path[:0]
+		ai.act = "svActFight"
+	}
+	return true
+}
+
+// findLoot sets the point of interest to the nearest loose item in sight and
+// returns true if there is a path to it.
+func (ai *AIModel) findLoot(a *game.Actor, m *game.CityMap) bool {
+	var best *game.Item
+	for _, i := range m.ItemsWithin(util.NewRectFromRadius(a.Position, svScavengeRange).Overlap(game.LevelBounds(game.LevelOf(a.Position)))) {
+		if i.Fixed || i.Container {
+			continue
+		}
+		if best != nil && a.Position.Distance(i.Position) >= a.Position.Distance(best.Position) {
+			continue
+		}
+		if !m.HasLineOfSight(a.Position, i.Position) {
+			continue
+		}
+		best = i
+	}
+	if best == nil {
+		return false
+	}
+	ai.setPOI(best.Position, a, m)
+	return best.Position == a.Position || len(ai.Path) > 0
+}
+
+// followPath steps the actor along the path to the point of interest,
+// re-pathing around obstacles as needed. Returns false if the actor could not
+// step.
+func (ai *AIModel) followPath(a *game.Actor, m *game.CityMap) bool {
+	for i := 0; i < 2; i++ {
+		if len(ai.Path) > 0 {
+			if ws, cs := m.StepActor(a, true, ai.Path[0]); ws || cs {
+				ai.Path = ai.Path[1:]
+				return true
+			}
+		}
+		// Our path is blocked or exhausted, try to path around it
+		ai.setPOI(ai.POI, a, m)
+	}
+	return false
+}
+
+// stepFan tries to step the actor in direction d, falling back to the
+// directions to either side. Returns true if the actor stepped.
+func (ai *AIModel) stepFan(a *game.Actor, m *game.CityMap, d util.Direction) bool {
+	offsets := []util.Direction{0, 1, 7, 2, 6}
+	if util.RandomBool() {
+		offsets = []util.Direction{0, 7, 1, 6, 2}
+	}
+	for _, o := range offsets {
+		if ws, cs := m.StepActor(a, true, (d + o).Bound()); ws || cs {
+			return true
+		}
+	}
+	return false
+}
@@ -14,11 +14,36 @@ func init() {
 		return &AIModel{
 			act:      "zmActIdle",
 			periodic: "nil",
+			hear:     "zmHear",
 		}
 	})
+	regHearFn("zmHear", func(ai *AIModel, a *game.Actor, m *game.CityMap, p util.Point, v int) {
+		// The player in sight is more interesting than any noise
+		if ai.act == "zmActApproach" && ai.POI == m.Player.Position &&
+			a.Position.Distance(m.Player.Position) <= m.SightRange(a, m.Player.Position) &&
+			m.CanSeePlayerFrom(a.Position) {
+			return
+		}
+		// Noises right next to an approaching zombie are most likely its own
+		// blows, keep on toward the original point of interest
+		if ai.act == "zmActApproach" && a.Position.Distance(p) <= 1 {
+			return
+		}
+		// Shamble straight toward the noise, path finding begins when the
+		// player is sighted
+		ai.POI = p
+		ai.Path = ai.Path[:0]
+		ai.cd = time.Minute
+		ai.act = "zmActApproach"
+	})
 	regActFn("zmActIdle", func(ai *AIModel, a *game.Actor, m *game.CityMap) time.Duration {
 		// Wait for the player to step into view
 		if !ai.targetPlayer(a, m) {
+			// Catch the player's scent
+			if m.ScentDirection(a.Position) != util.DirectionInvalid {
+				ai.cd = time.Minute
+				ai.act = "zmActTrack"
+			}
 			return time.Duration(float64(time.Second) * a.ActSpeed())
 		}
 		// Begin approaching the player
@@ -39,6 +64,11 @@ func init() {
 			if ws, cs := m.StepActor(a, true, d); ws || cs {
 				return time.Duration(float64(time.Second) * a.WalkSpeed())
 			}
+			// Doors and windows in the way are broken down rather than
+			// walked around
+			if zmSmash(a, m, d) {
+				return time.Duration(float64(time.Second) * a.ActSpeed())
+			}
 			o1s := 1
 			o2s := -1
 			if util.RandomBool() {
@@ -65,8 +95,14 @@ func init() {
 			// stand there looking dumb
 			return time.Duration(float64(time.Second) * a.ActSpeed())
 		}
-		// Already at the POI or out of path steps, just wait there
+		// Already at the POI or out of path steps, follow the player's scent
+		// or just wait there
 		if len(ai.Path) == 0 || a.Position.Distance(ai.POI) < 1 {
+			if m.ScentDirection(a.Position) != util.DirectionInvalid {
+				ai.cd = time.Minute
+				ai.act = "zmActTrack"
+				return ai.Act(a, m)
+			}
 			ai.cd -= time.Second
 			if ai.cd <= 0 {
 				ai.cd = 0
@@ -84,7 +120,10 @@ func init() {
 		ai.Path = ai.Path[:0]
 		game.NewPath(a.Position, ai.POI, m, &ai.Path)
 		if len(ai.Path) == 0 {
-			// No path right now, just wait
+			// No path right now, try to break through or just wait
+			if zmSmash(a, m, a.Position.DirectionTo(ai.POI)) {
+				return time.Duration(float64(time.Second) * a.ActSpeed())
+			}
 			return time.Second
 		}
 		if ws, cs := m.StepActor(a, true, ai.Path[0]); ws || cs {
@@ -97,9 +136,45 @@ func init() {
 		}
 		return time.Duration(float64(time.Second) * a.WalkSpeed())
 	})
+	regActFn("zmActTrack", func(ai *AIModel, a *game.Actor, m *game.CityMap) time.Duration {
+		// Close enough to attack, do that
+		if a.Position.Distance(m.Player.Position) < 2 {
+			return actFns["zmActAttack"](ai, a, m)
+		}
+		// The player in sight is better than any trail
+		if ai.targetPlayer(a, m) {
+			ai.cd = time.Minute
+			ai.act = "zmActApproach"
+			return ai.Act(a, m)
+		}
+		// Follow the scent toward where the player went
+		if d := m.ScentDirection(a.Position); d != util.DirectionInvalid {
+			if ws, cs := m.StepActor(a, true, d); ws || cs {
+				return time.Duration(float64(time.Second) * a.WalkSpeed())
+			}
+			if zmSmash(a, m, d) {
+				return time.Duration(float64(time.Second) * a.ActSpeed())
+			}
+		}
+		// Lost the trail or blocked, sniff around for a while
+		ai.cd -= time.Second
+		if ai.cd <= 0 {
+			ai.cd = 0
+			ai.act = "zmActIdle"
+		}
+		return time.Duration(float64(time.Second) * a.ActSpeed())
+	})
 	regActFn("zmActAttack", func(ai *AIModel, a *game.Actor, m *game.CityMap) time.Duration {
 		min, max := a.DamageMinMax()
-		m.Player.Damage(min, max, m.Now, a)
+		m.Player.Damage(a.AttackDamageType(), min, max, m.Now, a)
+		m.MakeNoise(m.Player.Position, game.NoiseCombat)
 		return time.Duration(float64(time.Second) * a.ActSpeed())
 	})
 }
+
+// zmSmash has the zombie smash whatever blocks its way in direction d if it is
+// strong enough to damage it. Returns true if the zombie struck.
+func zmSmash(a *game.Actor, m *game.CityMap, d util.Direction) bool {
+	p := a.Position.Step(d)
+	return m.CanSmash(a, p) && m.Smash(a, p)
+}
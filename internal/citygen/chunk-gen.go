@@ -10,7 +10,16 @@ import (
 
 func init() {
 	game.GetChunkGen = func(s, v string) game.ChunkGen {
-		return ChunkGenGroups[s].Variants[v]
+		g := ChunkGenGroups[s]
+		if g == nil {
+			return nil
+		}
+		cg := g.Variants[v]
+		if cg == nil {
+			// Avoid returning a typed nil interface
+			return nil
+		}
+		return cg
 	}
 }
 
@@ -68,6 +77,7 @@ type ChunkGen struct {
 	Fg      termui.Color                 // Foreground color
 	Bg      termui.Color                 // Background color
 	Map     []string                     // Map of characters that define how to procedurally generate each tile, the map is selected at random
+	Levels  map[int][]string             // Maps of the levels above and below ground keyed by level, levels without a map are left empty
 	Tiles   map[string]game.GenStatement // Mapping of map characters to value generator statements
 }
 
@@ -85,8 +95,16 @@ func (g *ChunkGen) AssignStaticInfo(c *game.Chunk) {
 	c.MinimapRune = string(g.Minimap[c.ChunkGenOffset.Y][c.ChunkGenOffset.X])
 }
 
-// Generate handles all of the procedural generation for the chunk.
+// Generate handles all of the procedural generation for the chunk. Chunks of
+// other levels use the map for their level if there is one.
 func (g *ChunkGen) Generate(c *game.Chunk, m *game.CityMap) {
+	gm := g.Map
+	if c.Level != 0 {
+		if gm = g.Levels[c.Level]; gm == nil {
+			c.FillEmptyLevel()
+			return
+		}
+	}
 	var sp util.Point
 	var dp util.Point
 	cb := util.NewRectWH(game.ChunkWidth, game.ChunkHeight)
@@ -94,7 +112,7 @@ func (g *ChunkGen) Generate(c *game.Chunk, m *game.CityMap) {
 	for sp.Y = c.ChunkGenOffset.Y * game.ChunkHeight; sp.Y < (c.ChunkGenOffset.Y+1)*game.ChunkHeight; sp.Y++ {
 		dp.X = 0
 		for sp.X = c.ChunkGenOffset.X * game.ChunkWidth; sp.X < (c.ChunkGenOffset.X+1)*game.ChunkWidth; sp.X++ {
-			r := string(g.Map[sp.Y][sp.X])
+			r := string(gm[sp.Y][sp.X])
 			rp := cb.RotatePointRelative(dp, c.Facing)
 			g.Tiles[r].Tile.Evaluate(c, rp, m)
 			dp.X++
@@ -106,7 +124,7 @@ func (g *ChunkGen) Generate(c *game.Chunk, m *game.CityMap) {
 	for sp.Y = c.ChunkGenOffset.Y * game.ChunkHeight; sp.Y < (c.ChunkGenOffset.Y+1)*game.ChunkHeight; sp.Y++ {
 		dp.X = 0
 		for sp.X = c.ChunkGenOffset.X * game.ChunkWidth; sp.X < (c.ChunkGenOffset.X+1)*game.ChunkWidth; sp.X++ {
-			r := string(g.Map[sp.Y][sp.X])
+			r := string(gm[sp.Y][sp.X])
 			gen := g.Tiles[r].Vehicle
 			if gen == nil {
 				dp.X++
@@ -123,7 +141,7 @@ func (g *ChunkGen) Generate(c *game.Chunk, m *game.CityMap) {
 	for sp.Y = c.ChunkGenOffset.Y * game.ChunkHeight; sp.Y < (c.ChunkGenOffset.Y+1)*game.ChunkHeight; sp.Y++ {
 		dp.X = 0
 		for sp.X = c.ChunkGenOffset.X * game.ChunkWidth; sp.X < (c.ChunkGenOffset.X+1)*game.ChunkWidth; sp.X++ {
-			r := string(g.Map[sp.Y][sp.X])
+			r := string(gm[sp.Y][sp.X])
 			rp := cb.RotatePointRelative(dp, c.Facing)
 			for _, gen := range g.Tiles[r].Items {
 				gen.Evaluate(c, rp, m)
@@ -137,7 +155,7 @@ func (g *ChunkGen) Generate(c *game.Chunk, m *game.CityMap) {
 	for sp.Y = c.ChunkGenOffset.Y * game.ChunkHeight; sp.Y < (c.ChunkGenOffset.Y+1)*game.ChunkHeight; sp.Y++ {
 		dp.X = 0
 		for sp.X = c.ChunkGenOffset.X * game.ChunkWidth; sp.X < (c.ChunkGenOffset.X+1)*game.ChunkWidth; sp.X++ {
-			r := string(g.Map[sp.Y][sp.X])
+			r := string(gm[sp.Y][sp.X])
 			gen := g.Tiles[r].Actor
 			if gen == nil {
 				dp.X++
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
 )
 
 // CityGen is a factory function for *game.CityMap that handles procedural
@@ -23,9 +24,17 @@ func reg(name string, g CityGen) {
 }
 
 // Generate generates a new CityMap for use with the named city generator and
-// scenario.
-func Generate(cityGen, scenario string) *game.CityMap {
-	m := CityGens[cityGen]()
-	Scenarios[scenario].Execute(m)
-	return m
+// scenario. The same seed always generates the same city.
+func Generate(cityGen, scenario string, seed int64) (*game.CityMap, error) {
+	var m *game.CityMap
+	var err error
+	util.WithSeed(seed, func() {
+		m = CityGens[cityGen]()
+		m.Seed = seed
+		err = Scenarios[scenario].Execute(m)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
 }
@@ -293,6 +293,8 @@ func interstate() *game.CityMap {
 	p = iip
 	p.X--
 	place(m, ChunkGenGroups["CombatTest"].Get(), p, util.FacingSouth, true)
+	p.X--
+	place(m, ChunkGenGroups["NPCTest"].Get(), p, util.FacingSouth, true)
 	// for p.Y = 0; p.Y < 8; p.Y++ {
 	// 	for p.X = 0; p.X < 8; p.X++ {
 	// 		f := util.Facing(util.Random(0, 4))
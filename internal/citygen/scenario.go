@@ -22,7 +22,7 @@ type Scenario struct {
 
 // Execute sets up the city map and player according to the parameters of the
 // scenario.
-func (s *Scenario) Execute(m *game.CityMap) {
+func (s *Scenario) Execute(m *game.CityMap) error {
 	// Equipment injection
 	game.ActorDefs["Player"].Equipment = s.Equipment
 	game.ActorDefs["Player"].CacheEquipmentStatements()
@@ -45,21 +45,25 @@ func (s *Scenario) Execute(m *game.CityMap) {
 	if s.SafeZoneRadius > 0 {
 		// Load all the chunks we need to modify
 		r := util.NewRectFromRadius(c.Position, s.SafeZoneRadius)
-		m.EnsureLoaded(r)
+		if err := m.EnsureLoaded(r); err != nil {
+			return err
+		}
 		// Clean out all actors from the safe zone
 		for _, a := range m.ActorsWithin(r.Multiply(game.ChunkWidth)) {
 			m.RemoveActor(a)
 		}
 	}
 	// Load the chunk and scan for a valid location for the player
-	m.LoadChunk(c, m.Now)
+	if err := m.LoadChunk(c, m.Now); err != nil {
+		return err
+	}
 	for i := 0; i < 512; i++ {
 		p := util.RandomPoint(c.Bounds)
 		ws, cs := c.CanStep(&m.Player.Actor, p, m)
 		if ws || cs {
 			m.Player.Position = p
-			return
+			return nil
 		}
 	}
-	panic(errors.New("exhausted player placement attempts"))
+	return errors.New("exhausted player placement attempts")
 }
@@ -60,3 +60,15 @@ func (m *confirmDialog) Draw(s termui.TerminalDriver) {
 	m.tb.Title = m.Title
 	m.tb.Draw(s)
 }
+
+// retryDialog presents the prompt describing a failure and returns true if the
+// player wants to try again.
+func retryDialog(s termui.TerminalDriver, title, prompt string) bool {
+	cd := newConfirmDialog()
+	cd.Title = title
+	cd.Prompt = prompt
+	retry := false
+	cd.Confirmed = func() { retry = true }
+	termui.RunMode(s, cd)
+	return retry
+}
@@ -0,0 +1,111 @@
+package termgui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// craftingDialog implements a dialog to select a recipe to craft.
+type craftingDialog struct {
+	Selected func(*game.Recipe) // Callback function when the player selects a recipe to craft
+	cm       *game.CityMap      // City map the player is in
+	recipes  []*game.Recipe     // Recipes in display order
+	list     *termui.List       // List of recipes
+	tb       *termui.TextBox    // Details of the recipe under the cursor
+}
+
+// newCraftingDialog creates a new craftingDialog ready for use.
+func newCraftingDialog(cm *game.CityMap) *craftingDialog {
+	var ret *craftingDialog
+	ret = &craftingDialog{
+		cm: cm,
+		list: &termui.List{
+			Boxed: true,
+			Title: "Craft",
+			Selected: func(s termui.TerminalDriver, i int) error {
+				if i >= len(ret.recipes) {
+					return termui.ErrorQuit
+				}
+				ret.Selected(ret.recipes[i])
+				return termui.ErrorQuit
+			},
+		},
+		tb: &termui.TextBox{
+			Boxed: true,
+			Title: "Requires",
+		},
+	}
+	for _, r := range game.RecipeDefs {
+		ret.recipes = append(ret.recipes, r)
+	}
+	slices.SortFunc(ret.recipes, func(a, b *game.Recipe) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, r := range ret.recipes {
+		ret.list.Items = append(ret.list.Items, r.Name)
+	}
+	return ret
+}
+
+// HandleEvent implements the termui.Mode interface.
+func (m *craftingDialog) HandleEvent(s termui.TerminalDriver, e any) error {
+	if err := m.list.HandleEvent(s, e); err != nil {
+		return err
+	}
+	switch e.(type) {
+	case *termui.EventQuit:
+		return termui.ErrorQuit
+	}
+	return nil
+}
+
+// details returns the description of the recipe's requirements.
+func (m *craftingDialog) details(r *game.Recipe) string {
+	var sb strings.Builder
+	list := func(title string, items map[string]int) {
+		fmt.Fprintf(&sb, "%s:\n", title)
+		keys := make([]string, 0, len(items))
+		for k := range items {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, tid := range keys {
+			fmt.Fprintf(&sb, "  %s x%d\n", game.ItemDefs[tid].Name, items[tid])
+		}
+	}
+	list("Ingredients", r.Inputs)
+	for _, tid := range r.Tools {
+		fmt.Fprintf(&sb, "Tool: %s\n", game.ItemDefs[tid].Name)
+	}
+	for _, tid := range r.Fixtures {
+		fmt.Fprintf(&sb, "Nearby: %s\n", game.ItemDefs[tid].Name)
+	}
+	fmt.Fprintf(&sb, "Time: %s\n", time.Duration(r.Time))
+	list("Makes", r.Outputs)
+	if missing := r.Missing(m.cm); len(missing) > 0 {
+		fmt.Fprintf(&sb, "\nYou need %s.", strings.Join(missing, ", "))
+	}
+	return sb.String()
+}
+
+// Draw implements the termui.Mode interface.
+func (m *craftingDialog) Draw(s termui.TerminalDriver) {
+	sb := util.NewRectWH(s.Size())
+	b := sb.CenterRect(72, 22)
+	m.list.Bounds = util.NewRectXYWH(b.TL.X, b.TL.Y, 30, b.Height())
+	m.tb.Bounds = util.NewRectXYWH(b.TL.X+30, b.TL.Y, b.Width()-30, b.Height())
+	if len(m.recipes) < 1 {
+		m.list.Items = []string{"You know no recipes."}
+		m.list.Draw(s)
+		return
+	}
+	m.list.Draw(s)
+	m.tb.SetText(m.details(m.recipes[m.list.CursorPos]))
+	m.tb.Draw(s)
+}
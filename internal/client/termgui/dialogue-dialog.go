@@ -0,0 +1,103 @@
+package termgui
+
+import (
+	"strings"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// dialogueDialog implements a dialog to hold a conversation with an actor.
+type dialogueDialog struct {
+	Trade   func(*game.Trade)      // Callback function when the player starts trading with the actor
+	cm      *game.CityMap          // City map the conversation takes place in
+	a       *game.Actor            // Actor the player is talking to
+	node    *game.DialogueNode     // Current node of the conversation
+	options []*game.DialogueOption // Options available at the current node
+	tb      *termui.TextBox        // What the actor says
+	list    *termui.List           // Responses available to the player
+}
+
+// newDialogueDialog creates a new dialogueDialog ready for use starting at
+// node n.
+func newDialogueDialog(cm *game.CityMap, a *game.Actor, n *game.DialogueNode) *dialogueDialog {
+	var ret *dialogueDialog
+	ret = &dialogueDialog{
+		cm: cm,
+		a:  a,
+		tb: &termui.TextBox{
+			Boxed: true,
+			Title: strings.ToUpper(a.Name[:1]) + a.Name[1:],
+		},
+		list: &termui.List{
+			Boxed: true,
+			Title: "Respond",
+			Selected: func(s termui.TerminalDriver, i int) error {
+				if i >= len(ret.options) {
+					return termui.ErrorQuit
+				}
+				o := ret.options[i]
+				n := ret.cm.ChooseDialogueOption(ret.a, o)
+				if o.Trade {
+					// Trading happens over top of the conversation, which
+					// carries on once the trade dialog closes
+					if t, reason := ret.cm.StartTrade(ret.a); t == nil {
+						game.Log.Log(termui.ColorYellow, "%s", reason)
+					} else if ret.Trade != nil {
+						ret.Trade(t)
+					}
+					if n == nil {
+						n = ret.node
+					}
+				}
+				if n == nil {
+					return termui.ErrorQuit
+				}
+				ret.setNode(n)
+				return nil
+			},
+		},
+	}
+	ret.setNode(n)
+	return ret
+}
+
+// setNode moves the conversation to node n.
+func (m *dialogueDialog) setNode(n *game.DialogueNode) {
+	m.node = n
+	m.options = m.cm.DialogueOptions(n, m.a)
+	m.list.Items = m.list.Items[:0]
+	for _, o := range m.options {
+		m.list.Items = append(m.list.Items, o.Text)
+	}
+	if len(m.options) < 1 {
+		m.list.Items = append(m.list.Items, "[End conversation]")
+	}
+	m.list.CursorPos = 0
+}
+
+// HandleEvent implements the termui.Mode interface.
+func (m *dialogueDialog) HandleEvent(s termui.TerminalDriver, e any) error {
+	if err := m.list.HandleEvent(s, e); err != nil {
+		return err
+	}
+	switch e.(type) {
+	case *termui.EventQuit:
+		return termui.ErrorQuit
+	}
+	return nil
+}
+
+// Draw implements the termui.Mode interface.
+func (m *dialogueDialog) Draw(s termui.TerminalDriver) {
+	sb := util.NewRectWH(s.Size())
+	m.tb.Bounds = util.NewRectWH(60, 3)
+	th := m.tb.SetText(m.node.Say(m.a)) + 2
+	lh := len(m.list.Items) + 2
+	b := sb.CenterRect(60, th+lh)
+	m.tb.Bounds = util.NewRectXYWH(b.TL.X, b.TL.Y, b.Width(), th)
+	m.list.Bounds = util.NewRectXYWH(b.TL.X, b.TL.Y+th, b.Width(), lh)
+	m.tb.Draw(s)
+	m.list.Draw(s)
+}
@@ -33,10 +33,15 @@ func newEscapeMenu(m *gameMode) *escapeMenu {
 				case 0:
 					return termui.ErrorQuit
 				case 1:
-					m.CityMap.FullSave()
+					if err := m.CityMap.FullSave(); err != nil {
+						m.logMode.Log(termui.ColorRed, "Save failed: %v", err)
+					}
 					return termui.ErrorQuit
 				case 2:
-					m.CityMap.FullSave()
+					if err := m.CityMap.FullSave(); err != nil {
+						m.logMode.Log(termui.ColorRed, "Save failed: %v", err)
+						return termui.ErrorQuit
+					}
 					m.quit = true
 					return termui.ErrorQuit
 				case 3:
@@ -61,12 +66,15 @@ func newEscapeMenu(m *gameMode) *escapeMenu {
 					termui.RunMode(td, &minimap{
 						CityMap:     m.CityMap,
 						Bounds:      util.NewRectWH(td.Size()),
-						Center:      util.NewPoint(m.CityMap.Player.Position.X/game.ChunkWidth, m.CityMap.Player.Position.Y/game.ChunkHeight),
+						Center:      game.OnLevel(m.CityMap.Player.Position, 0).Divide(game.ChunkWidth),
 						CursorStyle: 2,
 						DrawInfo:    true,
 						Selected: func(p util.Point) {
 							c := m.CityMap.GetChunkFromMapPoint(p)
-							m.CityMap.LoadChunk(c, m.CityMap.Now)
+							if err := m.CityMap.LoadChunk(c, m.CityMap.Now); err != nil {
+								m.logMode.Log(termui.ColorRed, "Unable to load chunk: %v", err)
+								return
+							}
 							p = p.Multiply(game.ChunkWidth)
 							for i := 0; i < 512; i++ {
 								dp := p
@@ -76,7 +84,9 @@ func newEscapeMenu(m *gameMode) *escapeMenu {
 								if ws || cs {
 									m.CityMap.Player.Position = dp
 									m.logMode.Log(termui.ColorLime, "Teleported to %dx%d.", dp.X, dp.Y)
-									m.CityMap.Update(dp, 0, nil)
+									if err := m.CityMap.Update(dp, 0, nil); err != nil {
+										m.logMode.Log(termui.ColorRed, "Unable to update: %v", err)
+									}
 									return
 								}
 							}
@@ -114,7 +124,9 @@ func newEscapeMenu(m *gameMode) *escapeMenu {
 					}
 					ret.m.CityMap.FlagBitmapsForVehicle(v, v.Bounds)
 					ret.m.logMode.Log(termui.ColorFuchsia, "New vehicle bounds: %v", v.Bounds)
-					ret.m.CityMap.Update(ret.m.CityMap.Player.Position, 0, nil)
+					if err := ret.m.CityMap.Update(ret.m.CityMap.Player.Position, 0, nil); err != nil {
+						ret.m.logMode.Log(termui.ColorRed, "Unable to update: %v", err)
+					}
 					return termui.ErrorQuit
 				case 3:
 					m.debug = !m.debug
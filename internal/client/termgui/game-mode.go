@@ -2,6 +2,7 @@ package termgui
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/qbradq/after/internal/events"
@@ -46,6 +47,9 @@ func newGameMode(m *game.CityMap) *gameMode {
 	gm.escapeMenu = newEscapeMenu(gm)
 	game.Log = gm.logMode
 	game.Log.Log(termui.ColorTeal, "Welcome to the aftermath!")
+	if a := m.Player.Activity; a != nil {
+		game.Log.Log(termui.ColorPurple, "You were %s. Press A to continue.", a.Name)
+	}
 	return gm
 }
 
@@ -99,7 +103,7 @@ func (m *gameMode) handleEventInternal(s termui.TerminalDriver, e any) error {
 			termui.RunMode(s, &minimap{
 				CityMap:     m.CityMap,
 				Bounds:      util.NewRectWH(s.Size()),
-				Center:      util.NewPoint(m.CityMap.Player.Position.X/game.ChunkWidth, m.CityMap.Player.Position.Y/game.ChunkHeight),
+				Center:      game.OnLevel(m.CityMap.Player.Position, 0).Divide(game.ChunkWidth),
 				CursorStyle: 2,
 				DrawInfo:    true,
 			})
@@ -136,7 +140,7 @@ func (m *gameMode) handleEventInternal(s termui.TerminalDriver, e any) error {
 				}
 				a := m.CityMap.ActorAt(p)
 				if a != nil {
-					m.CityMap.Player.Attack(a, m.CityMap.Now)
+					m.CityMap.PlayerAttack(a)
 					m.CityMap.PlayerTookTurn(time.Second, func() { m.Draw(s) })
 				}
 				return nil
@@ -145,6 +149,78 @@ func (m *gameMode) handleEventInternal(s termui.TerminalDriver, e any) error {
 			m.mapMode.CursorPos = m.CityMap.Player.Position
 			m.mapMode.CursorRange = 1
 			return nil
+		case 's': // Smash
+			m.inTarget = true
+			m.mapMode.Callback = func(p util.Point, b bool) error {
+				m.inTarget = false
+				if !b {
+					return nil
+				}
+				if m.CityMap.PlayerSmash(p) {
+					m.CityMap.PlayerTookTurn(time.Duration(float64(time.Second)*m.CityMap.Player.ActSpeed()), func() { m.Draw(s) })
+				}
+				return nil
+			}
+			m.mapMode.Center = m.CityMap.Player.Position
+			m.mapMode.CursorPos = m.CityMap.Player.Position
+			m.mapMode.CursorRange = 1
+			m.logMode.Log(termui.ColorPurple, "Smash what?")
+			return nil
+		case 'f': // Fire or throw ranged weapon
+			w := m.CityMap.Player.Weapon
+			if w == nil || !w.Ranged {
+				m.logMode.Log(termui.ColorYellow, "You are not wielding a ranged weapon.")
+				return nil
+			}
+			m.inTarget = true
+			m.mapMode.Callback = func(p util.Point, b bool) error {
+				m.inTarget = false
+				if !b {
+					return nil
+				}
+				if m.CityMap.PlayerFire(p) {
+					m.CityMap.PlayerTookTurn(time.Duration(float64(time.Second)*m.CityMap.Player.ActSpeed()), func() { m.Draw(s) })
+				}
+				return nil
+			}
+			m.mapMode.Center = m.CityMap.Player.Position
+			m.mapMode.CursorPos = m.CityMap.Player.Position
+			m.mapMode.CursorRange = w.Reach()
+			m.logMode.Log(termui.ColorPurple, "Fire at what?")
+			return nil
+		case 'L': // Reload
+			m.CityMap.Reload(func() { m.Draw(s) })
+			s.FlushEvents()
+			return nil
+		case 't': // Talk
+			m.inTarget = true
+			m.mapMode.Callback = func(p util.Point, b bool) error {
+				m.inTarget = false
+				if !b {
+					return nil
+				}
+				a := m.CityMap.ActorAt(p)
+				if a == nil {
+					m.logMode.Log(termui.ColorYellow, "There is nobody there.")
+					return nil
+				}
+				n, reason := m.CityMap.StartDialogue(a)
+				if n == nil {
+					m.logMode.Log(termui.ColorYellow, "%s", reason)
+					return nil
+				}
+				dd := newDialogueDialog(m.CityMap, a, n)
+				dd.Trade = func(t *game.Trade) {
+					m.modeStack = append(m.modeStack, newTradeDialog(m.CityMap, t))
+				}
+				m.modeStack = append(m.modeStack, dd)
+				return nil
+			}
+			m.mapMode.Center = m.CityMap.Player.Position
+			m.mapMode.CursorPos = m.CityMap.Player.Position
+			m.mapMode.CursorRange = 2
+			m.logMode.Log(termui.ColorPurple, "Talk to whom?")
+			return nil
 		case 'd': // Drop item from inventory
 			m.inTarget = true
 			m.mapMode.Callback = func(p util.Point, confirmed bool) error {
@@ -185,6 +261,14 @@ func (m *gameMode) handleEventInternal(s termui.TerminalDriver, e any) error {
 			m.mapMode.CursorRange = 1
 			m.logMode.Log(termui.ColorPurple, "Get where?")
 			return nil
+		case '<': // Go up stairs or ladder
+			m.CityMap.PlayerTakeStairs(true)
+			s.FlushEvents()
+			return nil
+		case '>': // Go down stairs or ladder
+			m.CityMap.PlayerTakeStairs(false)
+			s.FlushEvents()
+			return nil
 		case 'c': // Climb
 			m.inTarget = true
 			m.mapMode.Callback = func(p util.Point, confirmed bool) error {
@@ -208,15 +292,42 @@ func (m *gameMode) handleEventInternal(s termui.TerminalDriver, e any) error {
 				m.CityMap.Player.Position)
 			m.modeStack = append(m.modeStack, inv)
 			return nil
+		case 'C': // Craft
+			cd := newCraftingDialog(m.CityMap)
+			cd.Selected = func(r *game.Recipe) {
+				m.CityMap.Craft(r, func() { m.Draw(s) })
+			}
+			m.modeStack = append(m.modeStack, cd)
+			return nil
+		case 'M': // Mend
+			rd := newRepairDialog(m.CityMap)
+			rd.Selected = func(i *game.Item) {
+				m.CityMap.Repair(i, func() { m.Draw(s) })
+			}
+			m.modeStack = append(m.modeStack, rd)
+			return nil
 		case 'r': // Rest / Wait
 			td := newTimeDialog(m.CityMap)
 			td.Title = "Rest How Long?"
 			td.Selected = func(d time.Duration) {
-				m.CityMap.PlayerTookTurn(d, func() { m.Draw(s) })
+				m.CityMap.PlayerRest(d, func() { m.Draw(s) })
 			}
 			m.modeStack = append(m.modeStack, td)
 			m.logMode.Log(termui.ColorPurple, "Rest how long?")
 			return nil
+		case 'z': // Sleep
+			td := newTimeDialog(m.CityMap)
+			td.Title = "Sleep How Long?"
+			td.Selected = func(d time.Duration) {
+				m.CityMap.PlayerSleep(d, func() { m.Draw(s) })
+			}
+			m.modeStack = append(m.modeStack, td)
+			m.logMode.Log(termui.ColorPurple, "Sleep how long?")
+			return nil
+		case 'A': // Resume activity
+			m.CityMap.ResumeActivity(func() { m.Draw(s) })
+			s.FlushEvents()
+			return nil
 		case 'R': // Run / Walk toggle
 			if m.CityMap.Player.Running {
 				m.logMode.Log(termui.ColorFuchsia, "You slow to a walk.")
@@ -309,7 +420,7 @@ func (m *gameMode) handleBump(dir util.Direction, s termui.TerminalDriver) error
 	// Try attacking first
 	a := m.CityMap.ActorAt(np)
 	if a != nil {
-		m.CityMap.Player.Attack(a, m.CityMap.Now)
+		m.CityMap.PlayerAttack(a)
 		m.CityMap.PlayerTookTurn(time.Duration(float64(time.Second)*m.CityMap.Player.ActSpeed()), func() { m.Draw(s) })
 		s.FlushEvents()
 		return nil
@@ -350,8 +461,20 @@ func (m *gameMode) handleBump(dir util.Direction, s termui.TerminalDriver) error
 		// just quit.
 		return nil
 	}
-	// Try to use fixed items
+	// Try to trade with fixtures
 	items := m.CityMap.ItemsAt(np)
+	for _, i := range items {
+		if !i.Fixed || !i.Stock {
+			continue
+		}
+		if t, reason := m.CityMap.StartFixtureTrade(i); t == nil {
+			m.logMode.Log(termui.ColorYellow, "%s", reason)
+		} else {
+			m.modeStack = append(m.modeStack, newTradeDialog(m.CityMap, t))
+		}
+		return nil
+	}
+	// Try to use fixed items
 	for _, i := range items {
 		if !i.Fixed || i.Events == nil {
 			continue
@@ -418,13 +541,42 @@ func (m *gameMode) Draw(s termui.TerminalDriver) {
 	mmb := util.NewRectXYWH(sw-23, 0, 23, 23)
 	termui.DrawBox(s, mmb, termui.CurrentTheme.Normal)
 	m.minimap.Bounds = mmb.Shrink(1)
-	m.minimap.Center = util.NewPoint(m.CityMap.Player.Position.X/game.ChunkWidth, m.CityMap.Player.Position.Y/game.ChunkHeight)
+	m.minimap.Center = game.OnLevel(m.CityMap.Player.Position, 0).Divide(game.ChunkWidth)
 	m.minimap.Draw(s)
 	// Status display
 	m.status.Position = util.NewPoint(sw-39, 0)
 	m.status.Draw(s)
+	// Activity progress
+	if a := m.CityMap.Player.Activity; a != nil && a.Running() {
+		m.drawActivity(s, a, m.mapMode.Bounds)
+	}
 	// Render the mode stack
 	for _, m := range m.modeStack {
 		m.Draw(s)
 	}
 }
+
+// drawActivity draws the progress bar of the running activity at the top of
+// the map bounds.
+func (m *gameMode) drawActivity(s termui.TerminalDriver, a *game.Activity, mb util.Rect) {
+	b := util.NewRectXYWH(mb.TL.X+(mb.Width()-32)/2, mb.TL.Y+1, 32, 4)
+	termui.DrawFill(s, b, termui.Glyph{Rune: ' ', Style: termui.CurrentTheme.Normal})
+	termui.DrawBox(s, b, termui.CurrentTheme.Normal)
+	db := b.Shrink(1)
+	termui.DrawStringCenter(s, db, strings.ToUpper(a.Name[:1])+a.Name[1:], termui.CurrentTheme.Normal.Foreground(termui.ColorPurple))
+	db.TL.Y++
+	db.BR.Y = db.TL.Y
+	termui.DrawFill(s, db, termui.Glyph{
+		Rune:  '-',
+		Style: termui.StyleDefault.Foreground(termui.ColorNavy),
+	})
+	n := int(a.Completion() * float64(db.Width()))
+	if n < 1 {
+		return
+	}
+	db.BR.X = db.TL.X + n - 1
+	termui.DrawFill(s, db, termui.Glyph{
+		Rune:  '=',
+		Style: termui.StyleDefault.Foreground(termui.ColorAqua),
+	})
+}
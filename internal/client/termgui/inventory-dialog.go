@@ -14,12 +14,13 @@ type inventoryDialogLine struct {
 
 // inventoryDialogPanel contains the common inventory panel methods.
 type inventoryDialogPanel struct {
-	top      int                   // Top line index
-	selected int                   // Index of the selected line
-	source   any                   // Source of the inventory
-	title    string                // Title for the panel
-	lines    []inventoryDialogLine // All of the lines of the panel
-	size     util.Point            // Size of the panel
+	top      int                     // Top line index
+	selected int                     // Index of the selected line
+	source   any                     // Source of the inventory
+	title    string                  // Title for the panel
+	lines    []inventoryDialogLine   // All of the lines of the panel
+	size     util.Point              // Size of the panel
+	suffix   func(*game.Item) string // If not nil, returns extra text to draw right-aligned after each item
 }
 
 // newInventoryDialogPanel creates a new inventoryDialogPanel ready for use.
@@ -301,6 +302,9 @@ func (m *inventoryDialogPanel) Draw(s termui.TerminalDriver, tl util.Point, si i
 				Style: ns,
 			})
 			termui.DrawStringLeft(s, db, i.item.UIDisplayName(), ns)
+			if m.suffix != nil {
+				termui.DrawStringRight(s, db, m.suffix(i.item), ns)
+			}
 		}
 		b.TL.Y++
 	}
@@ -378,6 +382,10 @@ func (m *inventoryDialog) HandleEvent(s termui.TerminalDriver, e any) error {
 			} else {
 				c = left.getSelectedItem()
 			}
+			if c != nil && c.Stock {
+				game.Log.Log(termui.ColorYellow, "You will have to trade for that.")
+				break
+			}
 			if c != nil && c.Container {
 				if m.OnRight {
 					m.right = append(m.right, newInventoryDialogPanel(c, m.m))
@@ -425,10 +433,10 @@ func (m *inventoryDialog) HandleEvent(s termui.TerminalDriver, e any) error {
 				s = left
 			}
 			i = s.getSelectedItem()
-			if i == nil || (!i.Weapon && !i.Wearable) {
+			if i == nil || (!i.Wieldable() && !i.Wearable) {
 				break
 			}
-			if i.Weapon {
+			if i.Wieldable() {
 				// Weapon handling
 				if i == m.m.Player.Weapon {
 					// Unwield request
@@ -1,6 +1,9 @@
 package termgui
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/qbradq/after/internal/game"
 	"github.com/qbradq/after/internal/mods"
 	"github.com/qbradq/after/lib/termui"
@@ -9,7 +12,8 @@ import (
 
 // LoadMenu implements a menu to select existing saves.
 type LoadMenu struct {
-	list termui.List // List of saves
+	list      termui.List      // List of saves
+	saveInfos []*game.SaveInfo // Save information for each list item
 }
 
 // newLoadMenu creates a new load menu for use.
@@ -18,27 +22,44 @@ func newLoadMenu(s termui.TerminalDriver) *LoadMenu {
 	var items []string
 	var saveInfos []*game.SaveInfo
 	for _, si := range game.Saves {
-		items = append(items, si.Name)
+		if si.SaveVersion > game.SaveVersion {
+			items = append(items, si.Name+" (newer version)")
+		} else {
+			items = append(items, si.Name)
+		}
 		saveInfos = append(saveInfos, si)
 	}
 	lm = &LoadMenu{
+		saveInfos: saveInfos,
 		list: termui.List{
 			Boxed: true,
-			Title: "Load Save",
+			Title: "Load Save (r to Restore Backup)",
 			Items: items,
 			Selected: func(td termui.TerminalDriver, i int) error {
 				si := saveInfos[i]
-				if err := mods.LoadMods(si.Mods); err != nil {
-					panic(err)
+				if si.SaveVersion > game.SaveVersion {
+					// Can not load saves from newer versions of the engine
+					return nil
 				}
-				if err := game.LoadSave(si.ID); err != nil {
-					panic(err)
+				for err := mods.LoadMods(si.Mods); err != nil; err = mods.LoadMods(si.Mods) {
+					if !retryDialog(td, "Load Failed", fmt.Sprintf("Unable to load the mods of %s: %v. Try again?", si.Name, err)) {
+						return nil
+					}
+				}
+				repair := false
+				gm, err := startGame(s, si.ID, repair)
+				for err != nil {
+					game.CloseSave()
+					prompt := fmt.Sprintf("Unable to load %s: %v. Try to repair the save?", si.Name, err)
+					if repair {
+						prompt = fmt.Sprintf("Repair of %s failed: %v. Try again?", si.Name, err)
+					}
+					if !retryDialog(td, "Load Failed", prompt) {
+						return nil
+					}
+					repair = true
+					gm, err = startGame(s, si.ID, repair)
 				}
-				m := game.NewCityMap()
-				m.LoadCityPlan()
-				m.LoadDynamicData()
-				gm := newGameMode(m)
-				m.Update(m.Player.Position, 0, func() { gm.Draw(s) })
 				termui.RunMode(s, gm)
 				game.CloseSave()
 				return termui.ErrorQuit
@@ -55,6 +76,10 @@ func (m *LoadMenu) HandleEvent(s termui.TerminalDriver, e any) error {
 		if ev.Key == '\033' {
 			return termui.ErrorQuit
 		}
+		if ev.Key == 'r' && m.list.CursorPos >= 0 && m.list.CursorPos < len(m.saveInfos) {
+			m.restoreBackup(s, m.saveInfos[m.list.CursorPos])
+			return nil
+		}
 	case *termui.EventQuit:
 		return termui.ErrorQuit
 	}
@@ -67,3 +92,76 @@ func (m *LoadMenu) Draw(s termui.TerminalDriver) {
 	m.list.Bounds = util.NewRectWH(w, h).CenterRect(42, len(m.list.Items)+2)
 	m.list.Draw(s)
 }
+
+// loadGame opens the save and loads the city and the chunks around the player.
+// If repair is true the save is opened in repair mode, see game.Repair.
+func loadGame(id string, repair bool) (*game.CityMap, error) {
+	// Repairs made while loading are logged once the game mode is up
+	game.Log = nil
+	if err := game.LoadSave(id, repair); err != nil {
+		return nil, err
+	}
+	m := game.NewCityMap()
+	if err := m.LoadCityPlan(); err != nil {
+		return nil, err
+	}
+	if err := m.LoadDynamicData(); err != nil {
+		return nil, err
+	}
+	if err := m.EnsureLoadedAround(m.Player.Position); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// startGame loads the game and brings the world around the player up to date,
+// returning the game mode ready to run.
+func startGame(s termui.TerminalDriver, id string, repair bool) (*gameMode, error) {
+	m, err := loadGame(id, repair)
+	if err != nil {
+		return nil, err
+	}
+	repairs := game.Repairs
+	gm := newGameMode(m)
+	for _, r := range repairs {
+		game.Log.Log(termui.ColorYellow, "Repair: %s", r)
+	}
+	if err := m.Update(m.Player.Position, 0, func() { gm.Draw(s) }); err != nil {
+		return nil, err
+	}
+	return gm, nil
+}
+
+// restoreBackup presents the list of backups of the save and restores the one
+// selected after confirmation.
+func (m *LoadMenu) restoreBackup(s termui.TerminalDriver, si *game.SaveInfo) {
+	backups, err := game.Backups(si.ID)
+	if err != nil || len(backups) < 1 {
+		return
+	}
+	var items []string
+	for _, b := range backups {
+		items = append(items, b.Time.Local().Format(time.DateTime))
+	}
+	list := &termui.List{
+		Boxed: true,
+		Title: "Restore " + si.Name,
+		Items: items,
+		Selected: func(td termui.TerminalDriver, i int) error {
+			cd := newConfirmDialog()
+			cd.Title = "Restore Backup"
+			cd.Prompt = fmt.Sprintf("Restore %s to the backup taken %s? Progress since then will be lost.",
+				si.Name, items[i])
+			cd.Confirmed = func() {
+				if err := game.RestoreBackup(si.ID, backups[i]); err != nil {
+					util.Log("error restoring backup %s: %v", backups[i].Path, err)
+				}
+			}
+			termui.RunMode(td, cd)
+			return termui.ErrorQuit
+		},
+	}
+	w, h := s.Size()
+	list.Bounds = util.NewRectWH(w, h).CenterRect(42, len(items)+2)
+	termui.RunMode(s, list)
+}
@@ -1,6 +1,7 @@
 package termgui
 
 import (
+	"fmt"
 	"time"
 
 	_ "github.com/qbradq/after/internal/ai"
@@ -18,6 +19,10 @@ type MainMenu struct {
 
 var debugMods = []string{"Base"}
 
+// WorldSeed is the world generation seed used for new games. A value of zero
+// selects a new random seed for each game.
+var WorldSeed int64
+
 // NewMainMenu returns a new MainMenu object.
 func NewMainMenu(s termui.TerminalDriver) *MainMenu {
 	return &MainMenu{
@@ -32,20 +37,21 @@ func NewMainMenu(s termui.TerminalDriver) *MainMenu {
 			Selected: func(s termui.TerminalDriver, n int) error {
 				switch n {
 				case 0:
-					if err := mods.LoadMods(debugMods); err != nil {
-						panic(err)
+					for err := mods.LoadMods(debugMods); err != nil; err = mods.LoadMods(debugMods) {
+						if !retryDialog(s, "New Game Failed", fmt.Sprintf("Unable to load mods: %v. Try again?", err)) {
+							return nil
+						}
 					}
 					sl := newScenarioList()
 					sl.Selected = func(sn string) {
-						if err := game.NewSave("debug-"+time.Now().Format(time.DateTime), debugMods); err != nil {
-							panic(err)
+						gm, err := newGame(s, sn)
+						for err != nil {
+							game.CloseSave()
+							if !retryDialog(s, "New Game Failed", fmt.Sprintf("Unable to start a new game: %v. Try again?", err)) {
+								return
+							}
+							gm, err = newGame(s, sn)
 						}
-						m := citygen.Generate("Interstate Town", sn)
-						m.SaveCityPlan()
-						gm := newGameMode(m)
-						m.Update(m.Player.Position, 0, func() { gm.Draw(s) })
-						m.FullSave()
-						game.SaveTileRefs()
 						termui.RunMode(s, gm)
 						game.CloseSave()
 					}
@@ -62,6 +68,36 @@ func NewMainMenu(s termui.TerminalDriver) *MainMenu {
 	}
 }
 
+// newGame creates a new save, generates the city for the named scenario and
+// returns the game mode ready to run.
+func newGame(s termui.TerminalDriver, scenario string) (*gameMode, error) {
+	seed := WorldSeed
+	if seed == 0 {
+		seed = util.NewSeed()
+	}
+	if err := game.NewSave("debug-"+time.Now().Format(time.DateTime), debugMods, seed); err != nil {
+		return nil, err
+	}
+	m, err := citygen.Generate("Interstate Town", scenario, seed)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.SaveCityPlan(); err != nil {
+		return nil, err
+	}
+	gm := newGameMode(m)
+	if err := m.Update(m.Player.Position, 0, func() { gm.Draw(s) }); err != nil {
+		return nil, err
+	}
+	if err := m.FullSave(); err != nil {
+		return nil, err
+	}
+	if err := game.SaveTileRefs(); err != nil {
+		return nil, err
+	}
+	return gm, nil
+}
+
 // HandleEvent implements the termui.Mode interface.
 func (m *MainMenu) HandleEvent(s termui.TerminalDriver, e any) error {
 	switch ev := e.(type) {
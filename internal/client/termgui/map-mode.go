@@ -24,20 +24,21 @@ type mapMode struct {
 }
 
 func (m *mapMode) topLeft() util.Point {
-	// Calculate top-left corner
+	// Calculate top-left corner within the level being viewed
+	lb := game.LevelBounds(game.LevelOf(m.Center))
 	ret := util.NewPoint(m.Center.X-m.Bounds.Width()/2,
 		m.Center.Y-m.Bounds.Height()/2)
-	if ret.X < 0 {
-		ret.X = 0
+	if ret.X < lb.TL.X {
+		ret.X = lb.TL.X
 	}
-	if ret.X >= m.CityMap.Bounds.Width()*game.ChunkWidth-m.Bounds.Width() {
-		ret.X = (m.CityMap.Bounds.Width()*game.ChunkWidth - m.Bounds.Width()) - 1
+	if ret.X >= lb.BR.X+1-m.Bounds.Width() {
+		ret.X = (lb.BR.X + 1 - m.Bounds.Width()) - 1
 	}
-	if ret.Y < 0 {
-		ret.Y = 0
+	if ret.Y < lb.TL.Y {
+		ret.Y = lb.TL.Y
 	}
-	if ret.Y >= m.CityMap.Bounds.Height()*game.ChunkHeight-m.Bounds.Height() {
-		ret.Y = (m.CityMap.Bounds.Height()*game.ChunkHeight - m.Bounds.Height()) - 1
+	if ret.Y >= lb.BR.Y+1-m.Bounds.Height() {
+		ret.Y = (lb.BR.Y + 1 - m.Bounds.Height()) - 1
 	}
 	return ret
 }
@@ -67,6 +68,10 @@ func (m *mapMode) HandleEvent(s termui.TerminalDriver, e any) error {
 			m.CursorPos.Y++
 		case 'k':
 			m.CursorPos.Y--
+		case '<': // View the level above
+			m.viewLevel(1)
+		case '>': // View the level below
+			m.viewLevel(-1)
 		case ' ':
 			fallthrough
 		case '\n':
@@ -87,18 +92,31 @@ func (m *mapMode) HandleEvent(s termui.TerminalDriver, e any) error {
 		m.CursorPos =
 			util.NewRectFromRadius(m.Center, m.CursorRange).Bound(m.CursorPos)
 	}
-	m.CursorPos = m.CityMap.TileBounds.Bound(m.CursorPos)
+	m.CursorPos = game.LevelBounds(game.LevelOf(m.Center)).Bound(m.CursorPos)
 	mtl := m.topLeft()
 	mb := util.NewRectXYWH(mtl.X, mtl.Y, m.Bounds.Width(), m.Bounds.Height())
 	m.CursorPos = mb.Bound(m.CursorPos)
 	return nil
 }
 
+// viewLevel moves the view and cursor d levels up, or down if negative. This
+// is only possible when the cursor range is not limited.
+func (m *mapMode) viewLevel(d int) {
+	z := game.LevelOf(m.Center) + d
+	if m.CursorRange > 0 || z < game.MinLevel || z > game.MaxLevel {
+		return
+	}
+	m.Center = game.OnLevel(m.Center, z)
+	m.CursorPos = game.OnLevel(m.CursorPos, z)
+}
+
 // Draw implements the termui.Mode interface.
 func (m *mapMode) Draw(s termui.TerminalDriver) {
 	mtl := m.topLeft()
 	mb := util.NewRectXYWH(mtl.X, mtl.Y, m.Bounds.Width(), m.Bounds.Height())
-	m.CityMap.EnsureLoaded(mb.Divide(game.ChunkWidth))
+	if err := m.CityMap.EnsureLoaded(mb.Divide(game.ChunkWidth)); err != nil {
+		game.Log.Log(termui.ColorRed, "Error loading chunks: %v", err)
+	}
 	m.drawMap(s, mtl, mb)
 	if m.DrawPaths {
 		m.drawPaths(s, mtl, mb)
@@ -150,6 +168,15 @@ func (m *mapMode) drawPaths(s termui.TerminalDriver, mtl util.Point, mb util.Rec
 	}
 }
 
+// rememberedColor returns the color to draw the remembered but not visible
+// position at visibility set index idx in, dimmer if the position is dark.
+func rememberedColor(cm *game.CityMap, idx uint32) termui.Color {
+	if cm.Light.Contains(idx) {
+		return termui.ColorGray
+	}
+	return termui.ColorNavy
+}
+
 func (m *mapMode) drawMap(s termui.TerminalDriver, mtl util.Point, mb util.Rect) {
 	m.CityMap.MakeVisibilitySets(mb)
 	var p util.Point
@@ -170,7 +197,7 @@ func (m *mapMode) drawMap(s termui.TerminalDriver, mtl util.Point, mb util.Rect)
 			} else if m.CityMap.Remembered.Contains(idx) {
 				t := m.CityMap.GetTile(p)
 				ns := termui.StyleDefault.
-					Foreground(termui.ColorGray)
+					Foreground(rememberedColor(m.CityMap, idx))
 				s.SetCell(sp, termui.Glyph{
 					Rune:  rune(t.Rune[0]),
 					Style: ns,
@@ -202,7 +229,7 @@ func (m *mapMode) drawMap(s termui.TerminalDriver, mtl util.Point, mb util.Rect)
 		} else if m.CityMap.Remembered.Contains(idx) {
 			sp := util.NewPoint((p.X-mtl.X)+m.Bounds.TL.X, (p.Y-mtl.Y)+m.Bounds.TL.Y)
 			ns := termui.StyleDefault.
-				Foreground(termui.ColorGray)
+				Foreground(rememberedColor(m.CityMap, idx))
 			s.SetCell(sp, termui.Glyph{
 				Rune:  rune(i.Rune[0]),
 				Style: ns,
@@ -252,7 +279,7 @@ func (m *mapMode) drawMap(s termui.TerminalDriver, mtl util.Point, mb util.Rect)
 					s.SetCell(sp, l.Glyph)
 				} else if m.CityMap.Remembered.Contains(idx) {
 					g := l.Glyph
-					g.Style = g.Style.Foreground(termui.ColorGray).Background(termui.ColorBlack)
+					g.Style = g.Style.Foreground(rememberedColor(m.CityMap, idx)).Background(termui.ColorBlack)
 					s.SetCell(sp, g)
 				}
 			}
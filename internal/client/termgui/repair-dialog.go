@@ -0,0 +1,102 @@
+package termgui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// repairDialog implements a dialog to select a worn item to repair.
+type repairDialog struct {
+	Selected func(*game.Item) // Callback function when the player selects an item to repair
+	cm       *game.CityMap    // City map the player is in
+	items    []*game.Item     // Repairable items in display order
+	list     *termui.List     // List of items
+	tb       *termui.TextBox  // Details of the repair of the item under the cursor
+}
+
+// newRepairDialog creates a new repairDialog ready for use.
+func newRepairDialog(cm *game.CityMap) *repairDialog {
+	var ret *repairDialog
+	ret = &repairDialog{
+		cm:    cm,
+		items: cm.RepairableItems(),
+		list: &termui.List{
+			Boxed: true,
+			Title: "Repair",
+			Selected: func(s termui.TerminalDriver, i int) error {
+				if i >= len(ret.items) {
+					return termui.ErrorQuit
+				}
+				ret.Selected(ret.items[i])
+				return termui.ErrorQuit
+			},
+		},
+		tb: &termui.TextBox{
+			Boxed: true,
+			Title: "Requires",
+		},
+	}
+	for _, i := range ret.items {
+		ret.list.Items = append(ret.list.Items, i.DisplayName())
+	}
+	return ret
+}
+
+// HandleEvent implements the termui.Mode interface.
+func (m *repairDialog) HandleEvent(s termui.TerminalDriver, e any) error {
+	if err := m.list.HandleEvent(s, e); err != nil {
+		return err
+	}
+	switch e.(type) {
+	case *termui.EventQuit:
+		return termui.ErrorQuit
+	}
+	return nil
+}
+
+// details returns the description of the requirements to repair the item.
+func (m *repairDialog) details(i *game.Item) string {
+	var sb strings.Builder
+	k := game.RepairKitDefs[i.RepairKit]
+	fmt.Fprintf(&sb, "Condition: %d%%\n", int((1-i.Wear)*100))
+	fmt.Fprintf(&sb, "Skill: %s\n", k.Name)
+	fmt.Fprintf(&sb, "Materials:\n")
+	keys := make([]string, 0, len(k.Inputs))
+	for tid := range k.Inputs {
+		keys = append(keys, tid)
+	}
+	slices.Sort(keys)
+	for _, tid := range keys {
+		fmt.Fprintf(&sb, "  %s x%d\n", game.ItemDefs[tid].Name, k.Inputs[tid])
+	}
+	for _, tid := range k.Tools {
+		fmt.Fprintf(&sb, "Tool: %s\n", game.ItemDefs[tid].Name)
+	}
+	fmt.Fprintf(&sb, "Time: %s\n", time.Duration(k.Time))
+	if missing := k.Missing(m.cm); len(missing) > 0 {
+		fmt.Fprintf(&sb, "\nYou need %s.", strings.Join(missing, ", "))
+	}
+	return sb.String()
+}
+
+// Draw implements the termui.Mode interface.
+func (m *repairDialog) Draw(s termui.TerminalDriver) {
+	sb := util.NewRectWH(s.Size())
+	b := sb.CenterRect(72, 22)
+	m.list.Bounds = util.NewRectXYWH(b.TL.X, b.TL.Y, 30, b.Height())
+	m.tb.Bounds = util.NewRectXYWH(b.TL.X+30, b.TL.Y, b.Width()-30, b.Height())
+	if len(m.items) < 1 {
+		m.list.Items = []string{"Nothing needs repair."}
+		m.list.Draw(s)
+		return
+	}
+	m.list.Draw(s)
+	m.tb.SetText(m.details(m.items[m.list.CursorPos]))
+	m.tb.Draw(s)
+}
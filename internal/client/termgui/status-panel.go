@@ -1,7 +1,9 @@
 package termgui
 
 import (
+	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/qbradq/after/internal/game"
@@ -32,6 +34,15 @@ func (m *statusPanel) DrawPlayerStatus(s termui.TerminalDriver, db util.Rect) {
 	// Overall status display
 	ss := "Normal"
 	sss := termui.CurrentTheme.Normal.Foreground(termui.ColorSilver)
+	if effects := m.CityMap.Player.Effects; len(effects) > 0 {
+		// Display the most recent status effect
+		e := effects[len(effects)-1]
+		ss = strings.ToUpper(e.Name[:1]) + e.Name[1:]
+		if len(effects) > 1 {
+			ss += " +" + strconv.Itoa(len(effects)-1)
+		}
+		sss = sss.Foreground(e.Fg)
+	}
 	if m.CityMap.Player.BodyParts[game.BodyPartArms].Broken ||
 		m.CityMap.Player.BodyParts[game.BodyPartHand].Broken {
 		ss = "Mangled"
@@ -55,7 +66,9 @@ func (m *statusPanel) DrawPlayerStatus(s termui.TerminalDriver, db util.Rect) {
 		ss = "Running"
 		sss = sss.Foreground(termui.ColorRed)
 	}
-	termui.DrawStringCenter(s, db, ss, sss)
+	termui.DrawStringLeft(s, db, ss, sss)
+	// Level display
+	termui.DrawStringRight(s, db, game.LevelName(game.LevelOf(m.CityMap.Player.Position)), termui.CurrentTheme.Normal)
 
 }
 
@@ -279,7 +292,17 @@ func (m *statusPanel) Draw(s termui.TerminalDriver) {
 	db.TL.Y += 19
 	termui.DrawBox(s, db, termui.CurrentTheme.Normal)
 	db = db.Shrink(1)
-	termui.DrawStringLeft(s, db, m.CityMap.Now.Format("Mon Jan 02 2006"), termui.CurrentTheme.Normal)
-	db.TL.Y++
+	termui.DrawStringLeft(s, db, m.CityMap.Now.Format("Jan 02"), termui.CurrentTheme.Normal)
 	termui.DrawStringRight(s, db, m.CityMap.Now.Format(time.TimeOnly), termui.CurrentTheme.Normal)
+	db.TL.Y++
+	// Weather display
+	t := m.CityMap.OutdoorTemperature()
+	ts := termui.CurrentTheme.Normal
+	if t <= 32 {
+		ts = ts.Foreground(termui.ColorAqua)
+	} else if t >= 90 {
+		ts = ts.Foreground(termui.ColorRed)
+	}
+	termui.DrawStringLeft(s, db, m.CityMap.WeatherDescription(), termui.CurrentTheme.Normal)
+	termui.DrawStringRight(s, db, strconv.Itoa(int(math.Round(t)))+"F", ts)
 }
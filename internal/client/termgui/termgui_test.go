@@ -0,0 +1,87 @@
+package termgui
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qbradq/after/internal/citygen"
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/internal/mods"
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+	virtualdriver "github.com/qbradq/after/lib/virtual-driver"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden screens")
+
+// screenW and screenH are the dimensions of the virtual screen.
+const (
+	screenW = 120
+	screenH = 40
+)
+
+func TestMain(m *testing.M) {
+	if err := mods.Discover("../../../mods"); err != nil {
+		panic(err)
+	}
+	game.AutosaveGameInterval = 0
+	game.AutosaveWallInterval = 0
+	os.Exit(m.Run())
+}
+
+// checkGolden compares the screen to the named golden screen in testdata.
+func checkGolden(t *testing.T, name string, d *virtualdriver.Driver) {
+	t.Helper()
+	p := filepath.Join("testdata", name+".golden")
+	got := d.StyledText()
+	if *update {
+		if err := os.WriteFile(p, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Fatalf("screen does not match %s, got:\n%s", p, d.Text())
+	}
+}
+
+func TestMainMenu(t *testing.T) {
+	d := virtualdriver.New(screenW, screenH)
+	termui.RunMode(d, NewMainMenu(d))
+	checkGolden(t, "main-menu", d)
+}
+
+func TestGameModeTurn(t *testing.T) {
+	if err := mods.LoadMods(debugMods); err != nil {
+		t.Fatal(err)
+	}
+	if err := game.NewScratchSave(); err != nil {
+		t.Fatal(err)
+	}
+	defer game.CloseSave()
+	m, err := citygen.Generate("Interstate Town", "CombatTest", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The start date follows the wall clock, pin it so the screen is stable
+	m.Now = time.Date(2030, time.May, 1, 8, 0, 0, 0, time.UTC)
+	d := virtualdriver.New(screenW, screenH)
+	util.WithSeed(3, func() {
+		gm := newGameMode(m)
+		err = m.Update(m.Player.Position, 0, nil)
+		// Wait one turn, then walk east through the flushes after each turn
+		d.Key(".ll")
+		termui.RunMode(d, gm)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "game-mode-turn", d)
+}
@@ -0,0 +1,182 @@
+package termgui
+
+import (
+	"fmt"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// tradeDialog implements a two-panel dialog for bartering the player's
+// inventory against a trader's stock.
+type tradeDialog struct {
+	OnRight bool                  // If true, the cursor is in the right-hand pane
+	m       *game.CityMap         // CityMap we are getting the player from
+	t       *game.Trade           // Trade in progress
+	left    *inventoryDialogPanel // Player's inventory
+	right   *inventoryDialogPanel // Trader's stock
+}
+
+// newTradeDialog creates a new tradeDialog ready to use.
+func newTradeDialog(m *game.CityMap, t *game.Trade) *tradeDialog {
+	ret := &tradeDialog{
+		m: m,
+		t: t,
+		left: &inventoryDialogPanel{
+			size:  util.NewPoint(35, 21),
+			title: "Offer",
+		},
+		right: &inventoryDialogPanel{
+			size:  util.NewPoint(35, 21),
+			title: "Ask",
+		},
+	}
+	ret.left.suffix = func(i *game.Item) string {
+		if n := t.Offer[i]; n > 0 {
+			return fmt.Sprintf("*%d $%.2f", n, t.OfferPrice(i, n))
+		}
+		return fmt.Sprintf("$%.2f", t.OfferPrice(i, 1))
+	}
+	ret.right.suffix = func(i *game.Item) string {
+		if n := t.Ask[i]; n > 0 {
+			return fmt.Sprintf("*%d $%.2f", n, t.AskPrice(i, n))
+		}
+		return fmt.Sprintf("$%.2f", t.AskPrice(i, 1))
+	}
+	ret.refresh()
+	return ret
+}
+
+// linesForItems returns a slice of dialog lines for the items.
+func linesForItems(items []*game.Item) []inventoryDialogLine {
+	ret := []inventoryDialogLine{}
+	for _, i := range items {
+		ret = append(ret, inventoryDialogLine{
+			item: i,
+			text: i.UIDisplayName(),
+		})
+	}
+	return ret
+}
+
+// refresh rebuilds both panels from the player's inventory and the stock.
+func (m *tradeDialog) refresh() {
+	m.left.lines = linesForItems(m.m.Player.Inventory)
+	m.right.lines = linesForItems(m.t.Stock.Inventory)
+	m.left.setSelected(min(max(m.left.selected, 0), len(m.left.lines)-1), false, true)
+	m.right.setSelected(min(max(m.right.selected, 0), len(m.right.lines)-1), false, true)
+}
+
+// adjust changes the number of the selected item in the trade. If all is true
+// the whole stack is toggled in or out of the trade instead.
+func (m *tradeDialog) adjust(d int, all bool) {
+	p, n, set := m.left, m.t.Offer, m.t.SetOffer
+	if m.OnRight {
+		p, n, set = m.right, m.t.Ask, m.t.SetAsk
+	}
+	i := p.getSelectedItem()
+	if i == nil {
+		return
+	}
+	if !all {
+		set(i, n[i]+d)
+	} else if n[i] > 0 {
+		set(i, 0)
+	} else {
+		set(i, i.StackAmount())
+	}
+}
+
+// HandleEvent implements the termui.Mode interface.
+func (m *tradeDialog) HandleEvent(s termui.TerminalDriver, e any) error {
+	switch evt := e.(type) {
+	case *termui.EventKey:
+		switch evt.Key {
+		case 'h': // Cursor left
+			if !m.OnRight {
+				break
+			}
+			m.OnRight = false
+			m.left.setSelected((m.right.selected-m.right.top)+m.left.top, false, true)
+		case 'l': // Cursor right
+			if m.OnRight {
+				break
+			}
+			m.OnRight = true
+			m.right.setSelected((m.left.selected-m.left.top)+m.right.top, false, true)
+		case 'j': // Cursor down
+			if m.OnRight {
+				m.right.setSelected(m.right.selected+1, false, false)
+			} else {
+				m.left.setSelected(m.left.selected+1, false, false)
+			}
+		case 'k': // Cursor up
+			if m.OnRight {
+				m.right.setSelected(m.right.selected-1, true, false)
+			} else {
+				m.left.setSelected(m.left.selected-1, true, false)
+			}
+		case ' ':
+			fallthrough
+		case '\n': // Toggle the whole stack
+			m.adjust(0, true)
+		case '+':
+			m.adjust(1, false)
+		case '-':
+			m.adjust(-1, false)
+		case 't': // Complete the trade
+			if r := m.m.CompleteTrade(m.t); r != "" {
+				game.Log.Log(termui.ColorYellow, "%s", r)
+				break
+			}
+			m.refresh()
+		case '\033':
+			return termui.ErrorQuit
+		}
+	case *termui.EventQuit:
+		return termui.ErrorQuit
+	}
+	return nil
+}
+
+// Draw implements the termui.Mode interface.
+func (m *tradeDialog) Draw(s termui.TerminalDriver) {
+	sb := util.NewRectWH(s.Size())
+	b := sb.CenterRect(70, 24)
+	termui.DrawFill(s, b, termui.Glyph{
+		Rune:  ' ',
+		Style: termui.CurrentTheme.Normal,
+	})
+	// Help frame
+	db := b
+	db.TL.Y += 21
+	termui.DrawBox(s, db, termui.CurrentTheme.Normal)
+	termui.DrawStringCenter(s, db,
+		"[hjkl] Navigate [SPACE] Toggle [+/-] Amount [t] Trade [ESC] Leave",
+		termui.CurrentTheme.Normal.Foreground(termui.ColorLime),
+	)
+	db.TL.Y++
+	c := termui.ColorRed
+	if m.t.Balanced() {
+		c = termui.ColorLime
+	}
+	termui.DrawStringCenter(s, db,
+		fmt.Sprintf("Offering $%.2f for $%.2f", m.t.OfferValue(), m.t.AskValue()),
+		termui.CurrentTheme.Normal.Foreground(c),
+	)
+	// Left-hand display
+	idx := -1
+	if !m.OnRight {
+		idx = m.left.selected
+	}
+	m.left.Draw(s, b.TL, idx, m.m, !m.OnRight)
+	// Right-hand display
+	db = b
+	db.TL.X += 35
+	idx = -1
+	if m.OnRight {
+		idx = m.right.selected
+	}
+	m.right.Draw(s, db.TL, idx, m.m, m.OnRight)
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/termui"
 	"github.com/qbradq/after/lib/util"
 )
 
@@ -12,6 +13,7 @@ func init() {
 	rue("CloseDoor", closeDoor)
 	rue("Eat", eat)
 	rue("Drink", drink)
+	rue("Medicate", medicate)
 }
 
 func openDoor(i *game.Item, src *game.Actor, m *game.CityMap) error {
@@ -38,6 +40,7 @@ func openDoor(i *game.Item, src *game.Actor, m *game.CityMap) error {
 		},
 	}
 	ff.Execute(i.Position)
+	m.MakeNoise(i.Position, game.NoiseDoor)
 	return nil
 }
 
@@ -66,23 +69,75 @@ func closeDoor(i *game.Item, src *game.Actor, m *game.CityMap) error {
 		},
 	}
 	ff.Execute(i.Position)
+	m.MakeNoise(i.Position, game.NoiseDoor)
 	return nil
 }
 
 func eat(i *game.Item, src *game.Actor, m *game.CityMap) error {
-	m.Player.Hunger += i.FArg
-	if m.Player.Hunger > 1 {
-		m.Player.Hunger = 1
+	return consume(i, m, "eat")
+}
+
+func drink(i *game.Item, src *game.Actor, m *game.CityMap) error {
+	return consume(i, m, "drink")
+}
+
+func medicate(i *game.Item, src *game.Actor, m *game.CityMap) error {
+	game.Log.Log(termui.ColorWhite, "You use the %s.", i.Name)
+	if err := applyEffects(i, src, m); err != nil {
+		return err
+	}
+	if i.Amount > 1 {
+		i.Amount--
+	} else {
+		i.Destroyed = true
 	}
-	i.Destroyed = true
 	return nil
 }
 
-func drink(i *game.Item, src *game.Actor, m *game.CityMap) error {
-	m.Player.Thirst += i.FArg
-	if m.Player.Thirst > 1 {
-		m.Player.Thirst = 1
+// applyEffects removes the status effects the item cures from the actor then
+// applies the status effects of the item.
+func applyEffects(i *game.Item, a *game.Actor, m *game.CityMap) error {
+	for _, id := range i.Cures {
+		if a.RemoveEffect(id) && a.IsPlayer {
+			game.Log.Log(termui.ColorLime, "You are no longer suffering from %s.", game.StatusEffectDefs[id].Name)
+		}
+	}
+	for _, id := range i.Effects {
+		if err := a.ApplyEffect(id, m.Now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// consume has the player consume one item from the stack, applying its
+// nutrition, status effects and the effects of spoilage.
+func consume(i *game.Item, m *game.CityMap, verb string) error {
+	p := m.Player
+	i.Spoil(m.Now)
+	f := 1.0
+	if i.Rotten() {
+		// Rotten food is less nourishing and might make the player sick
+		f = 0.5
+		game.Log.Log(termui.ColorOlive, "You %s the %s. It tastes foul.", verb, i.Name)
+		if util.RandomF(0, 1) < i.Sickness {
+			game.Log.Log(termui.ColorRed, "You feel violently ill.")
+			if err := p.ApplyEffect("FoodPoisoning", m.Now); err != nil {
+				return err
+			}
+		}
+	} else {
+		game.Log.Log(termui.ColorWhite, "You %s the %s.", verb, i.Name)
+	}
+	p.Hunger = min(max(p.Hunger+i.Calories*f, 0), 1)
+	p.Thirst = min(max(p.Thirst+i.Hydration*f, 0), 1)
+	if err := applyEffects(i, &p.Actor, m); err != nil {
+		return err
+	}
+	if i.Amount > 1 {
+		i.Amount--
+	} else {
+		i.Destroyed = true
 	}
-	i.Destroyed = true
 	return nil
 }
@@ -9,7 +9,8 @@ import (
 
 func init() {
 	rpue("ResurrectCorpse", resurrectCorpse)
-
+	rpue("Spoil", spoil)
+	rpue("Restock", restock)
 }
 
 func resurrectCorpse(i *game.Item, m *game.CityMap, d time.Duration) error {
@@ -36,3 +37,13 @@ func resurrectCorpse(i *game.Item, m *game.CityMap, d time.Duration) error {
 	}
 	return nil
 }
+
+func spoil(i *game.Item, m *game.CityMap, d time.Duration) error {
+	i.Spoil(m.Now)
+	return nil
+}
+
+func restock(i *game.Item, m *game.CityMap, d time.Duration) error {
+	i.Restock(m.Now)
+	return nil
+}
@@ -35,6 +35,7 @@ func openVehicleDoor(v *game.Vehicle, l *game.VehicleLocation, i *game.Item, pp
 				if p.TemplateID == i.TemplateID {
 					l.Remove(p)
 					np := game.NewItem("Open"+p.TemplateID, m.Now, false)
+					np.Wear = p.Wear
 					l.Add(np)
 					m.FlagBitmapsForVehicle(v, v.Bounds)
 				}
@@ -70,6 +71,7 @@ func closeVehicleDoor(v *game.Vehicle, l *game.VehicleLocation, i *game.Item, pp
 					s, _ := strings.CutPrefix(i.TemplateID, "Open")
 					np := game.NewItem(s, m.Now, false)
 					np.Position = p.Position
+					np.Wear = p.Wear
 					l.Add(np)
 					m.FlagBitmapsForVehicle(v, v.Bounds)
 				}
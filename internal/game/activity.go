@@ -0,0 +1,260 @@
+package game
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// activityStep is the amount of time simulated between interruption checks
+// while the player performs an activity.
+const activityStep = time.Second * 30
+
+// activityLongStep is the amount of time simulated between interruption checks
+// while nothing within the update radius could interrupt the activity. Steps
+// this long use the long-term update.
+const activityLongStep = time.Minute * 10
+
+// Hunger and thirst values that interrupt activities with the InterruptHunger
+// and InterruptThirst flags when crossed.
+var (
+	HungerInterrupt = 0.1
+	ThirstInterrupt = 0.1
+)
+
+// ActivityInterrupt is a set of conditions that interrupt an activity.
+type ActivityInterrupt uint8
+
+const (
+	InterruptHostile ActivityInterrupt = 1 << iota // A hostile actor comes into view
+	InterruptDamage                                // The player is hurt by another actor
+	InterruptHunger                                // Hunger falls below HungerInterrupt
+	InterruptThirst                                // Thirst falls below ThirstInterrupt
+	InterruptAll     = InterruptHostile | InterruptDamage | InterruptHunger | InterruptThirst
+)
+
+// ActivityKind defines the behavior of a kind of activity. All functions are
+// optional.
+type ActivityKind struct {
+	Interrupts ActivityInterrupt                    // Default interrupts for new activities of this kind
+	Start      func(*CityMap, *Activity) string     // Prepares the activity to start or resume, returns a complete, punctuated sentence describing why it can not
+	Step       func(*CityMap, *Activity) string     // Called after every step, returns a complete, punctuated sentence to finish early
+	Stop       func(*CityMap, *Activity, bool) bool // Called when the activity stops with true if it ran to the end, returns false if the activity failed
+}
+
+// Global registry of activity kinds.
+var activityKinds = map[string]*ActivityKind{}
+
+// RegisterActivity registers an activity kind by name.
+func RegisterActivity(name string, k *ActivityKind) {
+	if _, found := activityKinds[name]; found {
+		panic(fmt.Errorf("duplicate activity kind %s", name))
+	}
+	activityKinds[name] = k
+}
+
+// Activity is a multi-turn action performed by the player that may be
+// interrupted and resumed.
+type Activity struct {
+	//
+	// Persistent values
+	//
+
+	Kind       string            // Name of the activity kind
+	Name       string            // Descriptive name such as "resting", completes the sentence "You stop ..."
+	SArg       string            // Generic string argument
+	FArg       float64           // Generic float argument
+	Duration   time.Duration     // Total time the activity takes
+	Progress   time.Duration     // Time spent on the activity so far
+	Interrupts ActivityInterrupt // Conditions that interrupt the activity
+
+	//
+	// Transient values
+	//
+
+	running bool // If true the activity is being performed right now
+}
+
+// NewActivity returns a new activity of the named kind with the default
+// interrupts for the kind.
+func NewActivity(kind, name string, d time.Duration) *Activity {
+	k, found := activityKinds[kind]
+	if !found {
+		panic(fmt.Errorf("reference to non-existent activity kind %s", kind))
+	}
+	return &Activity{
+		Kind:       kind,
+		Name:       name,
+		Duration:   d,
+		Interrupts: k.Interrupts,
+	}
+}
+
+// readActivity reads an activity from r. A nil activity is returned if none
+// was written.
+func readActivity(r io.Reader) (*Activity, error) {
+	if !util.GetBool(r) {
+		return nil, nil
+	}
+	a := &Activity{
+		Kind:       util.GetString(r),
+		Name:       util.GetString(r),
+		SArg:       util.GetString(r),
+		FArg:       util.GetFloat(r),
+		Duration:   time.Duration(util.GetUint64(r)),
+		Progress:   time.Duration(util.GetUint64(r)),
+		Interrupts: ActivityInterrupt(util.GetByte(r)),
+	}
+	if _, found := activityKinds[a.Kind]; !found {
+		if !Repair {
+			return nil, fmt.Errorf("reference to non-existent activity kind %s", a.Kind)
+		}
+		LogRepair("dropped unknown activity %s", a.Kind)
+		return nil, nil
+	}
+	return a, nil
+}
+
+// writeActivity writes the activity to w, which may be nil.
+func writeActivity(w io.Writer, a *Activity) {
+	if a == nil {
+		util.PutBool(w, false)
+		return
+	}
+	util.PutBool(w, true)
+	util.PutString(w, a.Kind)             // Kind
+	util.PutString(w, a.Name)             // Descriptive name
+	util.PutString(w, a.SArg)             // String argument
+	util.PutFloat(w, a.FArg)              // Float argument
+	util.PutUint64(w, uint64(a.Duration)) // Duration
+	util.PutUint64(w, uint64(a.Progress)) // Progress
+	util.PutByte(w, byte(a.Interrupts))   // Interrupts
+}
+
+// Running returns true if the activity is being performed right now.
+func (a *Activity) Running() bool { return a.running }
+
+// Completion returns the completion of the activity from zero to one.
+func (a *Activity) Completion() float64 {
+	if a.Duration <= 0 {
+		return 1
+	}
+	return min(float64(a.Progress)/float64(a.Duration), 1)
+}
+
+// activityInterrupt returns a complete, punctuated sentence describing why the
+// activity must stop or the empty string if it may continue. The activity was
+// started or resumed at start with the given hunger and thirst values.
+func (m *CityMap) activityInterrupt(a *Activity, start time.Time, hunger, thirst float64) string {
+	p := m.Player
+	if a.Interrupts&InterruptDamage != 0 && !p.LastHit.Before(start) {
+		return "You have been hurt!"
+	}
+	if a.Interrupts&InterruptHostile != 0 {
+		if h := m.VisibleHostile(); h != nil {
+			return fmt.Sprintf("You see a %s!", h.Name)
+		}
+	}
+	if a.Interrupts&InterruptHunger != 0 && hunger >= HungerInterrupt && p.Hunger < HungerInterrupt {
+		return "You are getting very hungry."
+	}
+	if a.Interrupts&InterruptThirst != 0 && thirst >= ThirstInterrupt && p.Thirst < ThirstInterrupt {
+		return "You are getting very thirsty."
+	}
+	return ""
+}
+
+// activityStepSize returns the amount of time to simulate before the next
+// interruption check. Short steps are only needed while a hostile actor within
+// the update radius could come into view or attack.
+func (m *CityMap) activityStepSize(a *Activity) time.Duration {
+	if a.Interrupts&(InterruptHostile|InterruptDamage) == 0 {
+		return activityLongStep
+	}
+	for _, o := range m.ActorsWithin(m.updateBounds) {
+		if !o.IsPlayer && !o.Dead && m.Hostile(o, &m.Player.Actor) {
+			return activityStep
+		}
+	}
+	return activityLongStep
+}
+
+// StartActivity performs the activity, replacing the player's interrupted
+// activity if any. It returns true if the activity ran to the end
+// successfully.
+func (m *CityMap) StartActivity(a *Activity, update func()) bool {
+	return m.runActivity(a, update)
+}
+
+// ResumeActivity resumes the player's interrupted activity. It returns true if
+// the activity ran to the end successfully.
+func (m *CityMap) ResumeActivity(update func()) bool {
+	if m.Player.Activity == nil {
+		Log.Log(termui.ColorYellow, "You are not doing anything.")
+		return false
+	}
+	return m.runActivity(m.Player.Activity, update)
+}
+
+// runActivity performs the activity until it completes or is interrupted.
+// Interrupted activities are kept as the player's activity so they may be
+// resumed. Returns true if the activity ran to the end successfully.
+func (m *CityMap) runActivity(a *Activity, update func()) bool {
+	p := m.Player
+	k := activityKinds[a.Kind]
+	if a.Interrupts&InterruptHostile != 0 {
+		if h := m.VisibleHostile(); h != nil {
+			Log.Log(termui.ColorRed, "You can not do that with a %s in sight.", h.Name)
+			return false
+		}
+	}
+	if k.Start != nil {
+		if s := k.Start(m, a); s != "" {
+			Log.Log(termui.ColorYellow, "%s", s)
+			return false
+		}
+	}
+	p.Activity = a
+	a.running = true
+	start := m.Now
+	hunger := p.Hunger
+	thirst := p.Thirst
+	stop := func(completed bool) bool {
+		a.running = false
+		if completed {
+			p.Activity = nil
+		}
+		if k.Stop != nil {
+			return k.Stop(m, a, completed)
+		}
+		return completed
+	}
+	for a.Progress < a.Duration {
+		d := min(m.activityStepSize(a), a.Duration-a.Progress)
+		m.PlayerTookTurn(d, nil)
+		a.Progress += d
+		if update != nil {
+			update()
+		}
+		if p.Dead {
+			p.Activity = nil
+			stop(false)
+			return false
+		}
+		if s := m.activityInterrupt(a, start, hunger, thirst); s != "" {
+			Log.Log(termui.ColorRed, "You stop %s. %s", a.Name, s)
+			stop(false)
+			return false
+		}
+		if k.Step != nil {
+			if s := k.Step(m, a); s != "" {
+				Log.Log(termui.ColorWhite, "%s", s)
+				break
+			}
+		}
+	}
+	return stop(true)
+}
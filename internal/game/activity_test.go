@@ -0,0 +1,80 @@
+package game_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
+)
+
+func TestActivityInterruptAndResume(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	log := &lastLog{}
+	game.Log = log
+	m := newQuietCity(t, 1)
+	p := m.Player
+	p.Hunger = game.HungerInterrupt + 0.01
+	p.Thirst = 1
+	start := m.Now
+	m.PlayerRest(time.Hour*4, nil)
+	a := p.Activity
+	if a == nil || a.Running() {
+		t.Fatal("interrupted rest was not kept to resume")
+	}
+	if !strings.Contains(log.line, "very hungry") {
+		t.Fatalf("rest stopped with %q", log.line)
+	}
+	if a.Progress <= 0 || a.Progress >= a.Duration || m.Now.Sub(start) != a.Progress {
+		t.Fatalf("rest stopped after %v of %v, %v passed", a.Progress, a.Duration, m.Now.Sub(start))
+	}
+	// Hunger only interrupts when it crosses the threshold
+	if !m.ResumeActivity(nil) {
+		t.Fatalf("resumed rest did not complete: %s", log.line)
+	}
+	if p.Activity != nil {
+		t.Fatal("completed rest was kept")
+	}
+	if d := m.Now.Sub(start); d != time.Hour*4 {
+		t.Fatalf("resumed rest took %v in total", d)
+	}
+	if m.ResumeActivity(nil) {
+		t.Fatal("resumed an activity after it completed")
+	}
+}
+
+func TestActivityStepSize(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	log := &lastLog{}
+	game.Log = log
+	m := newQuietCity(t, 1)
+	p := m.Player
+	for _, a := range slices.Clone(m.ActorsWithin(util.NewRectFromRadius(p.Position, game.ChunkWidth*6))) {
+		m.RemoveActor(a)
+	}
+	steps := 0
+	rest := func(d time.Duration) {
+		t.Helper()
+		steps = 0
+		p.Hunger = 1
+		p.Thirst = 1
+		if !m.StartActivity(game.NewActivity("Rest", "resting", d), func() { steps++ }) {
+			t.Fatalf("rest did not complete: %s", log.line)
+		}
+	}
+	// With nothing around to interrupt the player time passes in long steps
+	rest(time.Hour * 8)
+	if steps != 48 {
+		t.Fatalf("eight hours of rest took %d steps", steps)
+	}
+	// Hostile actors within the update radius could interrupt at any time
+	z := game.NewActor("Zombie", m.Now, false)
+	z.Position = p.Position.Add(util.NewPoint(0, game.ChunkHeight*3))
+	m.PlaceActor(z, false)
+	rest(time.Minute * 10)
+	if steps != 20 {
+		t.Fatalf("ten minutes of rest near a zombie took %d steps", steps)
+	}
+}
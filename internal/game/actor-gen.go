@@ -3,6 +3,7 @@ package game
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/qbradq/after/lib/util"
@@ -17,7 +18,14 @@ var ActorGens = map[string]ActorGen{}
 func (g *ActorGen) UnmarshalJSON(in []byte) error {
 	var src = map[string]int{}
 	json.Unmarshal(in, &src)
-	for k, n := range src {
+	// Sort the keys so the generator is deterministic for a given seed
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		n := src[k]
 		_, found := ActorDefs[k]
 		if !found {
 			panic(fmt.Errorf("ActorGen referenced non-existent actor %s", k))
@@ -19,13 +19,16 @@ type AIModel interface {
 	// Periodic update functions are expected to execute in linear time no
 	// matter how long the duration.
 	PeriodicUpdate(*Actor, *CityMap, time.Duration)
+	// Heard is called when the actor hears a noise made at the given point.
+	// The volume is the loudness remaining when the noise reached the actor.
+	Heard(*Actor, *CityMap, util.Point, int)
 	// Write writes the internal state of the model to the writer.
 	Write(io.Writer)
 }
 
 // NewAIModelFromReader reads AI model state information from r and constructs
 // a new AIModel ready for use.
-var NewAIModelFromReader func(io.Reader) AIModel
+var NewAIModelFromReader func(io.Reader) (AIModel, error)
 
 // NewAIModel should return a new AI model by template name.
 var NewAIModel func(string) AIModel
@@ -47,6 +50,7 @@ type Actor struct {
 	WornItems  [BodyPartEquipmentSlotCount]*Item // All items equipped to the body, if any
 	Inventory  []*Item                           // All items held in inventory, if any
 	Weapon     *Item                             // The item wielded as a weapon, if any
+	Effects    []*StatusEffect                   // All status effects applied to the actor
 
 	//
 	// Reconstructed values
@@ -61,7 +65,14 @@ type Actor struct {
 	SightRange int          // Distance this actor can see
 	MinDamage  float64      // Minimum damage done by normal attacks
 	MaxDamage  float64      // Maximum damage done by normal attacks
+	DamageType DamageType   // Type of damage done by normal attacks
+	Infects    string       // ID of the status effect wounds from normal attacks may cause, empty for none
+	InfectRate float64      // Chance of a wound from a normal attack causing the infection
 	IsPlayer   bool         // Only true for the player's actor
+	Horde      bool         // If true the actor roams with hordes while outside of the update radius
+	Faction    string       // ID of the faction the actor belongs to, empty for none
+	Dialogue   string       // ID of the dialogue used when the player talks to the actor, empty for none
+	Reanimates string       // Template ID of the actor the corpse rises as, empty for the same template
 	Equipment  []string     // Item statements
 
 	//
@@ -69,6 +80,7 @@ type Actor struct {
 	//
 
 	Dead      bool            // If true something has happened to this actor to cause death
+	LastHit   time.Time       // Time the actor was last damaged by another actor
 	pqIdx     int             // Priority queue index
 	minDamage float64         // Minimum damage dealt accounting for all equipment and status effects
 	maxDamage float64         // Maximum damage dealt accounting for all equipment and status effects
@@ -112,64 +124,113 @@ func NewActor(template string, now time.Time, generateEquipment bool) *Actor {
 			}
 		}
 	}
+	ret.recalculateDamage()
 	return &ret
 }
 
 // NewActorFromReader reads the actor information from r and returns a new Actor
 // with this information.
-func NewActorFromReader(r io.Reader) *Actor {
-	_ = util.GetUint32(r)                  // Version
-	tid := util.GetString(r)               // Template ID
-	a := NewActor(tid, time.Time{}, false) // Create new object
-	a.Position = util.GetPoint(r)          // Map position
-	a.AIModel = NewAIModelFromReader(r)    // AI model
-	a.NextThink = util.GetTime(r)          // Next think time
-	for i := range a.BodyParts {           // Body part status
-		p := BodyPart{
-			Which:       BodyPartCode(i),
-			Health:      util.GetFloat(r),
-			BrokenUntil: util.GetTime(r),
-		}
-		if !p.BrokenUntil.IsZero() {
-			p.Broken = true
-		}
-		a.BodyParts[i] = p
+func NewActorFromReader(r io.Reader) (*Actor, error) {
+	var a *Actor
+	ver, err := ReadVersion(r, "actor", actorVersion) // Version
+	if err != nil {
+		return nil, err
 	}
-	for i := range a.WornItems { // Equipped items
-		if util.GetBool(r) {
-			a.WornItems[i] = NewItemFromReader(r)
-		}
+	tid := util.GetString(r) // Template ID
+	if _, found := ActorDefs[tid]; found {
+		a = NewActor(tid, time.Time{}, false)
+	} else if Repair {
+		a = placeholderActor(tid)
+	} else {
+		return nil, fmt.Errorf("reference to non-existent actor template %s", tid)
 	}
-	a.Inventory = make([]*Item, util.GetUint16(r)) // Inventory
-	for i := range a.Inventory {
-		a.Inventory[i] = NewItemFromReader(r)
+	if err := actorLayout.read(a, r, ver); err != nil {
+		return nil, fmt.Errorf("actor %s %w", tid, err)
 	}
-	return a
+	a.recalculateDamage()
+	return a, nil
+}
+
+// actorLayout is the layout of the actor record following the version and
+// template ID.
+var actorLayout = recordLayout[*Actor]{
+	{0, "map position",
+		func(a *Actor, r io.Reader) error { a.Position = util.GetPoint(r); return nil },
+		func(a *Actor, w io.Writer) { util.PutPoint(w, a.Position) }, nil},
+	{0, "AI model",
+		func(a *Actor, r io.Reader) (err error) { a.AIModel, err = NewAIModelFromReader(r); return err },
+		func(a *Actor, w io.Writer) { a.AIModel.Write(w) }, nil},
+	{0, "next think time",
+		func(a *Actor, r io.Reader) error { a.NextThink = util.GetTime(r); return nil },
+		func(a *Actor, w io.Writer) { util.PutTime(w, a.NextThink) }, nil},
+	{0, "body part status",
+		func(a *Actor, r io.Reader) error {
+			for i := range a.BodyParts {
+				p := BodyPart{
+					Which:       BodyPartCode(i),
+					Health:      util.GetFloat(r),
+					BrokenUntil: util.GetTime(r),
+				}
+				if !p.BrokenUntil.IsZero() {
+					p.Broken = true
+				}
+				a.BodyParts[i] = p
+			}
+			return nil
+		},
+		func(a *Actor, w io.Writer) {
+			for _, p := range a.BodyParts {
+				util.PutFloat(w, p.Health)
+				util.PutTime(w, p.BrokenUntil)
+			}
+		}, nil},
+	{0, "equipped items",
+		func(a *Actor, r io.Reader) (err error) {
+			for i := range a.WornItems {
+				if util.GetBool(r) {
+					if a.WornItems[i], err = NewItemFromReader(r); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		func(a *Actor, w io.Writer) {
+			for _, i := range a.WornItems {
+				if i == nil {
+					util.PutBool(w, false)
+				} else {
+					util.PutBool(w, true)
+					i.Write(w)
+				}
+			}
+		}, nil},
+	{0, "inventory",
+		func(a *Actor, r io.Reader) (err error) {
+			a.Inventory = make([]*Item, util.GetUint16(r))
+			for i := range a.Inventory {
+				if a.Inventory[i], err = NewItemFromReader(r); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(a *Actor, w io.Writer) {
+			util.PutUint16(w, uint16(len(a.Inventory)))
+			for _, i := range a.Inventory {
+				i.Write(w)
+			}
+		}, nil},
+	{1, "status effects",
+		func(a *Actor, r io.Reader) (err error) { a.Effects, err = readStatusEffects(r); return err },
+		func(a *Actor, w io.Writer) { writeStatusEffects(w, a.Effects) }, nil},
 }
 
 // Write writes the actor to the writer.
 func (a *Actor) Write(w io.Writer) {
-	util.PutUint32(w, 0)            // Version
+	util.PutUint32(w, actorVersion) // Version
 	util.PutString(w, a.TemplateID) // Template ID
-	util.PutPoint(w, a.Position)    // Map position
-	a.AIModel.Write(w)              // AI model
-	util.PutTime(w, a.NextThink)    // Next think time
-	for _, p := range a.BodyParts { // Body part status
-		util.PutFloat(w, p.Health)
-		util.PutTime(w, p.BrokenUntil)
-	}
-	for _, i := range a.WornItems { // Equipped items
-		if i == nil {
-			util.PutBool(w, false)
-		} else {
-			util.PutBool(w, true)
-			i.Write(w)
-		}
-	}
-	util.PutUint16(w, uint16(len(a.Inventory))) // Inventory item count
-	for _, i := range a.Inventory {             // Inventory items
-		i.Write(w)
-	}
+	actorLayout.write(a, w)
 }
 
 // recalculateDamage recalculates the minDamage and maxDamage variables.
@@ -177,16 +238,21 @@ func (a *Actor) recalculateDamage() {
 	// Base damage
 	a.minDamage = a.MinDamage
 	a.maxDamage = a.MaxDamage
-	// Add weapon damage
-	if a.Weapon != nil {
-		a.minDamage += a.Weapon.WeaponMinDamage
-		a.maxDamage += a.Weapon.WeaponMaxDamage
+	// Add weapon damage, firearms make poor clubs
+	if a.Weapon != nil && (!a.Weapon.Ranged || a.Weapon.Thrown) {
+		f := a.Weapon.Effectiveness()
+		a.minDamage += a.Weapon.WeaponMinDamage * f
+		a.maxDamage += a.Weapon.WeaponMaxDamage * f
 	}
 	// If mangled damage is cut by 75%
 	if a.BodyParts[BodyPartArms].Broken || a.BodyParts[BodyPartHand].Broken {
 		a.minDamage *= 0.25
 		a.maxDamage *= 0.25
 	}
+	// Status effects
+	f := max(1+a.effectModifier(func(e *StatusEffect) float64 { return e.Damage }), 0)
+	a.minDamage *= f
+	a.maxDamage *= f
 }
 
 // DamageMinMax returns the minimum and maximum amounts of damage this actor
@@ -195,12 +261,34 @@ func (a *Actor) DamageMinMax() (float64, float64) {
 	return a.minDamage, a.maxDamage
 }
 
-// TargetedDamage applies a random amount of damage in the range [min-max) to
-// the indicated body part scaled as needed and makes updates as necessary.
-// Returns the amount of damage done.
-func (a *Actor) TargetedDamage(which BodyPartCode, min, max float64, t time.Time, from *Actor) float64 {
+// WearWeapon wears down the wielded weapon, if any, for one attack.
+func (a *Actor) WearWeapon() {
+	if a.Weapon == nil {
+		return
+	}
+	if a.Weapon.Degrade(1) && a.IsPlayer {
+		Log.Log(termui.ColorYellow, "Your %s breaks.", a.Weapon.Name)
+	}
+	a.recalculateDamage()
+}
+
+// AttackDamageType returns the type of damage this actor's melee attacks
+// currently deal.
+func (a *Actor) AttackDamageType() DamageType {
+	if a.Weapon != nil && (!a.Weapon.Ranged || a.Weapon.Thrown) {
+		return a.Weapon.WeaponDamage
+	}
+	return a.DamageType
+}
+
+// TargetedDamage applies a random amount of damage of type dt in the range
+// [min-max) to the indicated body part scaled as needed, reduced by any armor
+// covering it, and makes updates as necessary. Returns the amount of damage
+// done.
+func (a *Actor) TargetedDamage(which BodyPartCode, dt DamageType, min, max float64, t time.Time, from *Actor) float64 {
 	p := a.BodyParts[which]
 	d := util.RandomF(min, max) * BodyPartInfo[which].DamageMod
+	d = a.absorbDamage(which, dt, d)
 	bs := ""
 	os := "the"
 	p.Health -= d
@@ -225,6 +313,20 @@ func (a *Actor) TargetedDamage(which BodyPartCode, min, max float64, t time.Time
 		p.BrokenUntil = t.Add(time.Hour * 24 * 14) // Takes two weeks for broken limbs to mend or zombies to get up
 	}
 	a.BodyParts[which] = p
+	a.LastHit = t
+	// Wound effects, all of which are optional for mods to define
+	if d >= 0.25 {
+		a.ApplyEffect("Bleeding", t)
+	}
+	if bs != "" {
+		a.ApplyEffect("Pain", t)
+	}
+	if a.IsPlayer && d > 0 {
+		a.ApplyEffect("Adrenaline", t)
+	}
+	if from.Infects != "" && d > 0 && util.RandomF(0, 1) < from.InfectRate {
+		a.ApplyEffect(from.Infects, t)
+	}
 	if a.IsPlayer {
 		Log.Log(
 			termui.ColorRed,
@@ -263,7 +365,7 @@ func (a *Actor) TargetedDamage(which BodyPartCode, min, max float64, t time.Time
 
 // Damage calls TargetedDamage with a random body part weighted to hit
 // probabilities. Returns the amount of damage done.
-func (a *Actor) Damage(min, max float64, t time.Time, from *Actor) float64 {
+func (a *Actor) Damage(dt DamageType, min, max float64, t time.Time, from *Actor) float64 {
 	var which BodyPartCode
 	r := util.Random(0, 99)
 	if r < 5 {
@@ -279,32 +381,39 @@ func (a *Actor) Damage(min, max float64, t time.Time, from *Actor) float64 {
 	} else {
 		which = BodyPartBody
 	}
-	return a.TargetedDamage(which, min, max, t, from)
+	return a.TargetedDamage(which, dt, min, max, t, from)
 }
 
 // WalkSpeed returns the current walking speed of this mobile in seconds.
 func (a *Actor) WalkSpeed() float64 {
+	// Status effects
+	f := max(1+a.effectModifier(func(e *StatusEffect) float64 { return e.WalkSpeed }), 0.25)
 	// Broken legs or feet mean we crawl
 	if a.BodyParts[BodyPartLegs].Broken || a.BodyParts[BodyPartFeet].Broken {
-		return a.Speed * 4
+		return a.Speed * 4 * f
 	}
 	// Otherwise we walk
-	return a.Speed
+	return a.Speed * f
 }
 
 // ActSpeed returns the current action speed of this mobile in seconds.
 func (a *Actor) ActSpeed() float64 {
+	// Status effects
+	f := max(1+a.effectModifier(func(e *StatusEffect) float64 { return e.ActSpeed }), 0.25)
 	// Broken arms or hands mean it's very difficult to take actions
 	if a.BodyParts[BodyPartArms].Broken || a.BodyParts[BodyPartHand].Broken {
-		return 4
+		return 4 * f
 	}
-	return 1
+	return f
 }
 
 // DropCorpse drops a corpse item for this actor.
 func (a *Actor) DropCorpse(m *CityMap) {
 	i := NewItem("Corpse", m.Now, false)
 	i.SArg = a.TemplateID
+	if a.Reanimates != "" {
+		i.SArg = a.Reanimates
+	}
 	i.TArg = m.Now.Add(time.Hour * 24 * 14) // Takes two weeks for a corpse to resurrect
 	i.Position = a.Position
 	if a.Weapon != nil {
@@ -340,8 +449,8 @@ func (a *Actor) WearItem(i *Item) string {
 // returned describing why the action failed as a complete, punctuated sentence.
 // On success an empty string is returned.
 func (a *Actor) WieldItem(i *Item) string {
-	if !i.Weapon {
-		return "That item is not a weapon."
+	if !i.Wieldable() {
+		return "That item can not be wielded."
 	}
 	if a.Weapon != nil {
 		return "An item is already being wielded as a weapon."
@@ -359,8 +468,7 @@ func (a *Actor) AddItemToInventory(i *Item) bool {
 		}
 		// Try to stack
 		if i.Stackable && i.TemplateID == o.TemplateID {
-			o.Amount += i.Amount
-			i.Destroyed = true
+			o.absorb(i)
 			return true
 		}
 	}
@@ -404,6 +512,33 @@ func (a *Actor) RemoveItemFromInventory(item *Item) bool {
 	return true
 }
 
+// Validate returns an error if the actor template references non-existent
+// factions, dialogues, actors or status effects. This must be called on all
+// actor prototypes after loading is complete.
+func (a *Actor) Validate() error {
+	if a.Faction != "" {
+		if _, found := FactionDefs[a.Faction]; !found {
+			return fmt.Errorf("actor %s references non-existent faction %s", a.TemplateID, a.Faction)
+		}
+	}
+	if a.Dialogue != "" {
+		if _, found := DialogueDefs[a.Dialogue]; !found {
+			return fmt.Errorf("actor %s references non-existent dialogue %s", a.TemplateID, a.Dialogue)
+		}
+	}
+	if a.Reanimates != "" {
+		if _, found := ActorDefs[a.Reanimates]; !found {
+			return fmt.Errorf("actor %s reanimates as non-existent actor %s", a.TemplateID, a.Reanimates)
+		}
+	}
+	if a.Infects != "" {
+		if _, found := StatusEffectDefs[a.Infects]; !found {
+			return fmt.Errorf("actor %s infects with non-existent status effect %s", a.TemplateID, a.Infects)
+		}
+	}
+	return nil
+}
+
 // CacheEquipmentStatements generates the cache of equipment statements. This
 // must be called on all actor prototypes after item and item gen loading is
 // complete.
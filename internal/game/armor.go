@@ -0,0 +1,39 @@
+package game
+
+import (
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// ArmorValue returns the fraction of damage of type dt the worn item currently
+// absorbs accounting for wear.
+func (i *Item) ArmorValue(dt DamageType) float64 {
+	return i.Armor[dt] * i.Effectiveness()
+}
+
+// absorbDamage reduces damage d of type dt dealt to body part which by the
+// armor worn over it, wearing the armor down as it does. Returns the damage
+// remaining.
+func (a *Actor) absorbDamage(which BodyPartCode, dt DamageType, d float64) float64 {
+	for slot, i := range a.WornItems {
+		if i == nil || EquipmentSlotCovers[slot] != which {
+			continue
+		}
+		av := i.ArmorValue(dt)
+		if av <= 0 || util.RandomF(0, 1) >= i.Coverage {
+			continue
+		}
+		ad := d * av
+		d -= ad
+		broke := i.Degrade(ad)
+		if a.IsPlayer {
+			Log.Log(termui.ColorAqua, "Your %s absorbed %d%%", i.Name, int(ad*100))
+			if broke {
+				Log.Log(termui.ColorYellow, "Your %s is worn through.", i.Name)
+			}
+		} else {
+			Log.Log(termui.ColorWhite, "%s's %s absorbed %d%%", a.Name, i.Name, int(ad*100))
+		}
+	}
+	return d
+}
@@ -0,0 +1,47 @@
+package game_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/qbradq/after/internal/game"
+)
+
+func TestArmorAbsorbsDamage(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	a := game.NewActor("Zombie", fixtureTime, false)
+	hit := func(dt game.DamageType) float64 {
+		return a.TargetedDamage(game.BodyPartBody, dt, 0.2, 0.2, fixtureTime, a)
+	}
+	bare := hit(game.DamageTypeCut)
+	j := game.NewItem("LeatherJacket", fixtureTime, false)
+	j.Coverage = 1
+	if s := a.WearItem(j); s != "" {
+		t.Fatal(s)
+	}
+	// Each damage type is resisted separately
+	for _, c := range []struct {
+		dt   game.DamageType
+		want float64
+	}{
+		{game.DamageTypeCut, 0.5},
+		{game.DamageTypePierce, 0.9},
+	} {
+		j.Wear = 0
+		if d := hit(c.dt); math.Abs(d-bare*c.want) > 1e-9 {
+			t.Fatalf("leather jacket let through %f of %f %s damage", d, bare, c.dt)
+		}
+	}
+	// Absorbing damage wears the armor down until it is useless
+	if j.Wear <= 0 {
+		t.Fatal("absorbing damage did not wear the armor")
+	}
+	if v := j.ArmorValue(game.DamageTypeCut); v >= 0.5 {
+		t.Fatalf("worn armor absorbs %f of cut damage", v)
+	}
+	j.Wear = 1
+	if d := hit(game.DamageTypeCut); math.Abs(d-bare) > 1e-9 {
+		t.Fatalf("worn through armor let through %f of %f cut damage", d, bare)
+	}
+}
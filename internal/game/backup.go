@@ -0,0 +1,131 @@
+package game
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// AutosaveGameInterval is the amount of in-game time between autosaves. Zero
+// disables game-time autosaves.
+var AutosaveGameInterval = time.Hour
+
+// AutosaveWallInterval is the amount of real time between autosaves. Zero
+// disables wall-clock autosaves.
+var AutosaveWallInterval = 5 * time.Minute
+
+// BackupCount is the number of rotating backup copies kept for each save. Zero
+// disables backups.
+var BackupCount = 3
+
+// Layout of backup file names
+const backupTimeLayout = "20060102-150405.000000"
+
+// Backup describes a single backup copy of a save database.
+type Backup struct {
+	Path string    // Path to the backup database
+	Time time.Time // Time the backup was taken
+}
+
+// backupDir returns the directory holding the backups of the given save.
+func backupDir(id string) string {
+	return filepath.Join(SaveRoot, id+".backups")
+}
+
+// BackupSave writes a backup copy of the open save database and removes the
+// oldest backups in excess of BackupCount. The copy is taken within a read
+// transaction so it is always consistent.
+func BackupSave() error {
+	if saveInfo == nil || save == nil || BackupCount < 1 {
+		return nil
+	}
+	d := backupDir(saveInfo.ID)
+	if err := os.MkdirAll(d, 0755); err != nil {
+		return err
+	}
+	p := filepath.Join(d, time.Now().UTC().Format(backupTimeLayout))
+	if err := save.View(func(tx *bbolt.Tx) error {
+		return tx.CopyFile(p+".tmp", 0644)
+	}); err != nil {
+		os.Remove(p + ".tmp")
+		return err
+	}
+	if err := os.Rename(p+".tmp", p); err != nil {
+		return err
+	}
+	backups, err := Backups(saveInfo.ID)
+	if err != nil {
+		return err
+	}
+	for i := BackupCount; i < len(backups); i++ {
+		if err := os.Remove(backups[i].Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Backups returns all backups of the given save, newest first.
+func Backups(id string) ([]Backup, error) {
+	files, err := os.ReadDir(backupDir(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var ret []Backup
+	for _, f := range files {
+		t, err := time.Parse(backupTimeLayout, f.Name())
+		if f.IsDir() || err != nil {
+			// Partial copies and foreign files
+			continue
+		}
+		ret = append(ret, Backup{
+			Path: filepath.Join(backupDir(id), f.Name()),
+			Time: t,
+		})
+	}
+	slices.SortFunc(ret, func(a, b Backup) int {
+		return b.Time.Compare(a.Time)
+	})
+	return ret, nil
+}
+
+// RestoreBackup replaces the database of the given save with the backup. The
+// save must not be open.
+func RestoreBackup(id string, b Backup) error {
+	if saveInfo != nil && saveInfo.ID == id {
+		return fmt.Errorf("save %s is open", id)
+	}
+	if _, found := Saves[id]; !found {
+		return fmt.Errorf("save file %s not found", id)
+	}
+	src, err := os.Open(b.Path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	p := savePath(id)
+	dst, err := os.Create(p + ".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if err == nil {
+		err = dst.Sync()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(p + ".tmp")
+		return err
+	}
+	return os.Rename(p+".tmp", p)
+}
@@ -0,0 +1,195 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/qbradq/after/lib/termui"
+)
+
+// Barter pricing parameters.
+const (
+	barterMarkup   float64 = 0.5  // Fraction of value a neutral trader adds to the price of their goods
+	barterDiscount float64 = 0.5  // Fraction of value a neutral trader pays for goods
+	barterGoodwill float64 = 0.02 // Reputation gained with the trader's faction for each completed trade
+)
+
+// Trade is a barter in progress between the player and either a trader actor
+// or a fixture such as a vending machine.
+type Trade struct {
+	Trader *Actor        // Actor trading with the player, nil when trading with a fixture
+	Stock  *Item         // Container holding the goods for trade
+	Offer  map[*Item]int // Number of each item from the player's inventory offered in trade
+	Ask    map[*Item]int // Number of each item from the stock the player asks for
+	m      *CityMap      // City map the trade takes place in
+}
+
+// tradeStock returns the container in the actor's inventory holding the goods
+// they trade, or nil if they do not trade.
+func (a *Actor) tradeStock() *Item {
+	for _, i := range a.Inventory {
+		if i.Stock && i.Container {
+			return i
+		}
+	}
+	return nil
+}
+
+// StartTrade returns a new trade with actor a. On failure nil is returned
+// along with a complete, punctuated sentence describing why the player can not
+// trade with the actor.
+func (m *CityMap) StartTrade(a *Actor) (*Trade, string) {
+	if a.Dead {
+		return nil, "The dead have nothing to trade."
+	}
+	s := a.tradeStock()
+	if s == nil {
+		return nil, fmt.Sprintf("The %s has nothing to trade.", a.Name)
+	}
+	if m.Attitude(a) == AttitudeHostile {
+		return nil, fmt.Sprintf("The %s is in no mood to trade.", a.Name)
+	}
+	return m.newTrade(a, s), ""
+}
+
+// StartFixtureTrade returns a new trade with a fixture item such as a vending
+// machine. On failure nil is returned along with a complete, punctuated
+// sentence describing why the player can not trade with the item.
+func (m *CityMap) StartFixtureTrade(i *Item) (*Trade, string) {
+	if !i.Stock || !i.Container {
+		return nil, fmt.Sprintf("The %s has nothing to trade.", i.Name)
+	}
+	return m.newTrade(nil, i), ""
+}
+
+// newTrade returns a new, empty trade.
+func (m *CityMap) newTrade(a *Actor, s *Item) *Trade {
+	return &Trade{
+		Trader: a,
+		Stock:  s,
+		Offer:  map[*Item]int{},
+		Ask:    map[*Item]int{},
+		m:      m,
+	}
+}
+
+// reputation returns the player's reputation with the trader.
+func (t *Trade) reputation() float64 {
+	if t.Trader == nil || t.Trader.Faction == "" {
+		return 0
+	}
+	return t.m.Reputation(t.Trader.Faction)
+}
+
+// OfferPrice returns what the trader will pay for n of item i from the
+// player's inventory.
+func (t *Trade) OfferPrice(i *Item, n int) float64 {
+	if i.Currency {
+		return i.Value * float64(n)
+	}
+	if t.Stock.CashOnly {
+		return 0
+	}
+	f := 1.0
+	if t.Trader != nil {
+		f = barterDiscount * (1 + t.reputation())
+	}
+	return i.Value * i.Condition() * float64(n) * f
+}
+
+// AskPrice returns what the trader wants for n of item i from the stock.
+func (t *Trade) AskPrice(i *Item, n int) float64 {
+	if i.Currency {
+		return i.Value * float64(n)
+	}
+	f := 1.0
+	if t.Trader != nil {
+		f = 1 + barterMarkup*(1-t.reputation())
+	}
+	return i.Value * i.Condition() * float64(n) * f
+}
+
+// SetOffer sets the number of item i from the player's inventory offered in
+// trade.
+func (t *Trade) SetOffer(i *Item, n int) {
+	if n = min(n, i.StackAmount()); n <= 0 {
+		delete(t.Offer, i)
+		return
+	}
+	t.Offer[i] = n
+}
+
+// SetAsk sets the number of item i from the stock the player asks for.
+func (t *Trade) SetAsk(i *Item, n int) {
+	if n = min(n, i.StackAmount()); n <= 0 {
+		delete(t.Ask, i)
+		return
+	}
+	t.Ask[i] = n
+}
+
+// OfferValue returns the total value of everything the player offers.
+func (t *Trade) OfferValue() float64 {
+	ret := 0.0
+	for i, n := range t.Offer {
+		ret += t.OfferPrice(i, n)
+	}
+	return ret
+}
+
+// AskValue returns the total value of everything the player asks for.
+func (t *Trade) AskValue() float64 {
+	ret := 0.0
+	for i, n := range t.Ask {
+		ret += t.AskPrice(i, n)
+	}
+	return ret
+}
+
+// Balanced returns true if the trader will accept the trade as it stands.
+func (t *Trade) Balanced() bool {
+	return len(t.Offer)+len(t.Ask) > 0 && t.OfferValue() >= t.AskValue()
+}
+
+// CompleteTrade exchanges the items of the trade between the player and the
+// trader. On failure a complete, punctuated sentence describing why the trade
+// did not happen is returned, otherwise the empty string.
+func (m *CityMap) CompleteTrade(t *Trade) string {
+	name := t.Stock.Name
+	if t.Trader != nil {
+		name = t.Trader.Name
+		if m.Attitude(t.Trader) == AttitudeHostile {
+			return fmt.Sprintf("The %s is in no mood to trade.", name)
+		}
+	}
+	if !t.Balanced() {
+		return fmt.Sprintf("The %s will not accept that trade.", name)
+	}
+	// Iterate the inventories rather than the maps so the results are
+	// reproducible
+	var offered, asked []*Item
+	for _, i := range m.Player.Inventory {
+		if n := t.Offer[i]; n > 0 {
+			offered = append(offered, i.Split(n))
+		}
+	}
+	for _, i := range t.Stock.Inventory {
+		if n := t.Ask[i]; n > 0 {
+			asked = append(asked, i.Split(n))
+		}
+	}
+	for _, i := range offered {
+		m.Player.RemoveItemFromInventory(i)
+		t.Stock.AddItem(i)
+	}
+	for _, i := range asked {
+		t.Stock.RemoveItem(i)
+		m.Player.AddItemToInventory(i)
+	}
+	clear(t.Offer)
+	clear(t.Ask)
+	Log.Log(termui.ColorAqua, "You trade with the %s.", name)
+	if t.Trader != nil && t.Trader.Faction != "" {
+		m.AdjustReputation(t.Trader.Faction, barterGoodwill)
+	}
+	return ""
+}
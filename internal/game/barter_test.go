@@ -0,0 +1,97 @@
+package game_test
+
+import (
+	"testing"
+
+	"github.com/qbradq/after/internal/game"
+)
+
+// newTradeMap returns a city map holding only a player with a twenty and a
+// five dollar bill, and a trader with two salami for sale.
+func newTradeMap() (*game.CityMap, *game.Actor, *game.Item) {
+	m := &game.CityMap{
+		Player:      game.NewPlayer(fixtureTime),
+		Reputations: map[string]float64{},
+	}
+	// Starting equipment is random
+	m.Player.Inventory = nil
+	m.Player.AddItemToInventory(game.NewItem("TwentyDollarBill", fixtureTime, false))
+	m.Player.AddItemToInventory(game.NewItem("FiveDollarBill", fixtureTime, false))
+	a := game.NewActor("Trader", fixtureTime, false)
+	stock := game.NewItem("TraderStock", fixtureTime, false)
+	salami := game.NewItem("Salami", fixtureTime, false)
+	salami.Amount = 2
+	stock.AddItem(salami)
+	a.Inventory = append(a.Inventory, stock)
+	return m, a, salami
+}
+
+func TestCompleteTrade(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	m, a, salami := newTradeMap()
+	tr, reason := m.StartTrade(a)
+	if tr == nil {
+		t.Fatalf("can not trade with a neutral trader: %s", reason)
+	}
+	// A neutral trader marks up their goods by half
+	if p := tr.AskPrice(salami, 1); p != 22.5 {
+		t.Fatalf("neutral trader asks %f for a salami", p)
+	}
+	twenty, five := m.Player.Inventory[0], m.Player.Inventory[1]
+	tr.SetAsk(salami, 1)
+	tr.SetOffer(twenty, 1)
+	if s := m.CompleteTrade(tr); s == "" {
+		t.Fatal("trader accepted twenty dollars for a salami")
+	}
+	if len(m.Player.Inventory) != 2 || salami.Amount != 2 {
+		t.Fatal("items changed hands in a rejected trade")
+	}
+	tr.SetOffer(five, 1)
+	if s := m.CompleteTrade(tr); s != "" {
+		t.Fatalf("trade failed: %s", s)
+	}
+	if len(m.Player.Inventory) != 1 || m.Player.Inventory[0].TemplateID != "Salami" ||
+		m.Player.Inventory[0].StackAmount() != 1 {
+		t.Fatalf("player has %d items after the trade", len(m.Player.Inventory))
+	}
+	if salami.Amount != 1 || len(tr.Stock.Inventory) != 3 {
+		t.Fatalf("trader stock holds %d items after the trade", len(tr.Stock.Inventory))
+	}
+	if len(tr.Offer)+len(tr.Ask) != 0 {
+		t.Fatal("trade was not cleared")
+	}
+	if r := m.Reputation("Scavengers"); r <= 0 {
+		t.Fatalf("trade left reputation at %f", r)
+	}
+	// Hostile traders refuse to trade
+	m.AdjustReputation("Scavengers", -1)
+	tr.SetAsk(salami, 1)
+	tr.SetOffer(twenty, 1)
+	if s := m.CompleteTrade(tr); s == "" {
+		t.Fatal("hostile trader completed a trade")
+	}
+	if tr, _ := m.StartTrade(a); tr != nil {
+		t.Fatal("hostile trader started a trade")
+	}
+}
+
+func TestVendingMachineTakesCashOnly(t *testing.T) {
+	m, _, _ := newTradeMap()
+	vm := game.NewItem("VendingMachine", fixtureTime, false)
+	drink := game.NewItem("WaterBottle", fixtureTime, false)
+	vm.AddItem(drink)
+	tr, reason := m.StartFixtureTrade(vm)
+	if tr == nil {
+		t.Fatalf("can not trade with a vending machine: %s", reason)
+	}
+	salami := game.NewItem("Salami", fixtureTime, false)
+	m.Player.AddItemToInventory(salami)
+	if p := tr.OfferPrice(salami, 1); p != 0 {
+		t.Fatalf("vending machine offers %f for a salami", p)
+	}
+	// Fixtures sell at face value
+	if p := tr.AskPrice(drink, 1); p != drink.Value {
+		t.Fatalf("vending machine asks %f for a %s worth %f", p, drink.Name, drink.Value)
+	}
+}
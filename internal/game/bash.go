@@ -0,0 +1,272 @@
+package game
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// Bash describes how a tile or fixed item stands up to being smashed.
+type Bash struct {
+	HP     float64  // Damage the structure withstands before it is smashed
+	Armor  float64  // Damage absorbed from every blow, blows doing no more than this do nothing
+	Result string   // ID of the tile, or template ID of the item, left in place when smashed, items may leave nothing
+	Debris []string // Item statements of the debris dropped when smashed
+
+	//
+	// Cache values
+	//
+
+	dCache []ItemStatement // Debris statements cache
+}
+
+// validate returns an error if the bash description is malformed and caches
+// the debris statements. what describes the owner for error messages.
+func (b *Bash) validate(what string) error {
+	if b.HP <= 0 {
+		return fmt.Errorf("%s has bash hit points %f which must be positive", what, b.HP)
+	}
+	if b.Armor < 0 {
+		return fmt.Errorf("%s has negative bash armor", what)
+	}
+	b.dCache = make([]ItemStatement, len(b.Debris))
+	for idx, s := range b.Debris {
+		is := ItemStatement{}
+		if err := is.UnmarshalJSON([]byte("\"" + s + "\"")); err != nil {
+			return fmt.Errorf("%s debris: %w", what, err)
+		}
+		b.dCache[idx] = is
+	}
+	return nil
+}
+
+// ValidateBash returns an error if the item can not be smashed the way it
+// describes. This must be called on all items after item gen loading is
+// complete.
+func (i *Item) ValidateBash() error {
+	if i.Bash == nil {
+		return nil
+	}
+	what := "item " + i.TemplateID
+	if !i.Fixed {
+		return fmt.Errorf("%s can be smashed but is not fixed", what)
+	}
+	if r := i.Bash.Result; r != "" {
+		if ri, found := ItemDefs[r]; !found {
+			return fmt.Errorf("%s is smashed into non-existent item %s", what, r)
+		} else if !ri.Fixed {
+			return fmt.Errorf("%s is smashed into item %s which is not fixed", what, r)
+		}
+	}
+	return i.Bash.validate(what)
+}
+
+// ValidateBash returns an error if the tile can not be smashed the way it
+// describes. This must be called on all tiles after tile and item gen loading
+// is complete.
+func (t *TileDef) ValidateBash() error {
+	if t.Bash == nil {
+		return nil
+	}
+	what := "tile " + t.ID
+	if _, found := TileRefs[t.Bash.Result]; !found {
+		return fmt.Errorf("%s is smashed into non-existent tile %s", what, t.Bash.Result)
+	}
+	return t.Bash.validate(what)
+}
+
+// readTileDamage reads the tile damage of the chunk from r.
+func (c *Chunk) readTileDamage(r io.Reader) {
+	n := int(util.GetUint16(r))
+	if n > 0 {
+		c.TileDamage = make(map[uint32]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		idx := uint32(util.GetUint16(r))
+		c.TileDamage[idx] = util.GetFloat(r)
+	}
+}
+
+// writeTileDamage writes the tile damage of the chunk to w.
+func (c *Chunk) writeTileDamage(w io.Writer) {
+	keys := make([]uint32, 0, len(c.TileDamage))
+	for k := range c.TileDamage {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	util.PutUint16(w, uint16(len(keys)))
+	for _, k := range keys {
+		util.PutUint16(w, uint16(k))      // Tile index
+		util.PutFloat(w, c.TileDamage[k]) // Damage
+	}
+}
+
+// smashTarget returns the top-most fixed item at p that can be smashed, or
+// nil and the tile at p if it can be smashed, or nil for both if there is
+// nothing to smash at p.
+func (m *CityMap) smashTarget(p util.Point) (*Item, *TileDef) {
+	items := m.ItemsAt(p)
+	for idx := len(items) - 1; idx >= 0; idx-- {
+		if i := items[idx]; i.Fixed && i.Bash != nil {
+			return i, nil
+		}
+	}
+	if t := m.GetTile(p); t != nil && t.Bash != nil {
+		return nil, t
+	}
+	return nil, nil
+}
+
+// CanSmash returns true if there is something at p on the actor's level the
+// actor is strong enough to damage and no actor standing in the way.
+func (m *CityMap) CanSmash(a *Actor, p util.Point) bool {
+	return m.smashRefusal(a, p) == ""
+}
+
+// smashRefusal returns the reason the actor can not smash at p phrased for the
+// player, or the empty string if it can.
+func (m *CityMap) smashRefusal(a *Actor, p util.Point) string {
+	if LevelOf(a.Position) != LevelOf(p) {
+		return "You can not reach that from here."
+	}
+	if o := m.ActorAt(p); o != nil {
+		return fmt.Sprintf("The %s is in the way.", o.Name)
+	}
+	if p == m.Player.Position {
+		return "You are standing there."
+	}
+	i, t := m.smashTarget(p)
+	_, max := a.DamageMinMax()
+	switch {
+	case i != nil:
+		if max <= i.Bash.Armor {
+			return fmt.Sprintf("You can not make a dent in the %s.", i.Name)
+		}
+	case t != nil:
+		if max <= t.Bash.Armor {
+			return fmt.Sprintf("You can not make a dent in the %s.", t.Name)
+		}
+	default:
+		return "There is nothing there to smash."
+	}
+	return ""
+}
+
+// Smash has the actor strike the top-most fixed item at p that can be smashed,
+// or the tile at p if there is no such item. Blows make noise and structures
+// taking more damage than they withstand are replaced by their broken variant
+// and drop their debris. Returns true if there was something to strike.
+func (m *CityMap) Smash(a *Actor, p util.Point) bool {
+	i, t := m.smashTarget(p)
+	if i == nil && t == nil {
+		return false
+	}
+	min, max := a.DamageMinMax()
+	d := util.RandomF(min, max)
+	seen := a.IsPlayer || (p.Distance(m.Player.Position) <= m.SightRange(&m.Player.Actor, p) &&
+		m.CanSeePlayerFrom(p))
+	m.MakeNoise(p, NoiseCombat)
+	if i != nil {
+		d -= i.Bash.Armor
+		if d <= 0 {
+			if a.IsPlayer {
+				Log.Log(termui.ColorYellow, "You can not make a dent in the %s.", i.Name)
+			}
+			return true
+		}
+		i.BashDamage += d
+		if i.BashDamage < i.Bash.HP {
+			if a.IsPlayer {
+				Log.Log(termui.ColorWhite, "You strike the %s.", i.Name)
+			}
+			return true
+		}
+		if seen {
+			Log.Log(termui.ColorOlive, "The %s is smashed!", i.Name)
+		}
+		m.smashItem(i)
+		return true
+	}
+	d -= t.Bash.Armor
+	if d <= 0 {
+		if a.IsPlayer {
+			Log.Log(termui.ColorYellow, "You can not make a dent in the %s.", t.Name)
+		}
+		return true
+	}
+	c := m.GetChunk(p)
+	idx := c.relOfs(p)
+	if c.TileDamage == nil {
+		c.TileDamage = map[uint32]float64{}
+	}
+	c.TileDamage[idx] += d
+	if c.TileDamage[idx] < t.Bash.HP {
+		if a.IsPlayer {
+			Log.Log(termui.ColorWhite, "You strike the %s.", t.Name)
+		}
+		return true
+	}
+	if seen {
+		Log.Log(termui.ColorOlive, "The %s is smashed!", t.Name)
+	}
+	delete(c.TileDamage, idx)
+	c.Tiles[idx] = TileDefs[TileRefs[t.Bash.Result]]
+	c.bitmapsDirty = true
+	m.dropDebris(t.Bash, p)
+	m.MakeNoise(p, NoiseSmash)
+	return true
+}
+
+// smashItem replaces the item with its broken variant, if any, spilling its
+// contents and dropping its debris.
+func (m *CityMap) smashItem(i *Item) {
+	p := i.Position
+	m.RemoveItem(i)
+	if i.Bash.Result != "" {
+		ni := NewItem(i.Bash.Result, m.Now, false)
+		ni.Position = p
+		m.PlaceItem(ni, true)
+	}
+	for _, ci := range i.Inventory {
+		ci.Position = p
+		m.PlaceItem(ci, true)
+	}
+	m.dropDebris(i.Bash, p)
+	m.MakeNoise(p, NoiseSmash)
+}
+
+// dropDebris places the debris of the smashed structure at p.
+func (m *CityMap) dropDebris(b *Bash, p util.Point) {
+	for _, s := range b.dCache {
+		for _, di := range s.Evaluate(m.Now) {
+			di.Position = p
+			m.PlaceItem(di, true)
+		}
+	}
+}
+
+// PlayerSmash has the player strike whatever can be smashed at p using the
+// wielded weapon or bare hands. Returns true if the blow was made, in which
+// case the caller is responsible for the time it takes.
+func (m *CityMap) PlayerSmash(p util.Point) bool {
+	a := m.Player
+	if why := m.smashRefusal(&a.Actor, p); why != "" {
+		Log.Log(termui.ColorYellow, "%s", why)
+		return false
+	}
+	sc := 0.05
+	if a.Weapon != nil {
+		sc = a.Weapon.WeaponSwingStam
+	}
+	if a.Stamina < sc {
+		Log.Log(termui.ColorRed, "You are too fatigued.")
+		return false
+	}
+	m.Smash(&a.Actor, p)
+	a.WearWeapon()
+	a.Stamina -= sc
+	return true
+}
@@ -0,0 +1,135 @@
+package game_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
+)
+
+func TestSmash(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	m := newQuietCity(t, 1)
+	p := m.Player
+	if p.Weapon != nil {
+		p.UnWieldItem(p.Weapon)
+	}
+	count := func(at util.Point, tid string) int {
+		n := 0
+		for _, i := range m.ItemsAt(at) {
+			if i.TemplateID == tid {
+				n++
+			}
+		}
+		return n
+	}
+	smash := func(at util.Point, done func() bool) {
+		t.Helper()
+		for n := 0; !done(); n++ {
+			if n >= 100 {
+				t.Fatalf("%v still standing after %d blows", at, n)
+			}
+			p.Stamina = 1
+			if !m.Smash(&p.Actor, at) {
+				t.Fatalf("nothing to smash at %v", at)
+			}
+		}
+	}
+	// Doors give way to bare hands and leave a broken door and planks
+	dp := p.Position.Add(util.NewPoint(-1, 0))
+	door := game.NewItem("Door", m.Now, false)
+	door.Position = dp
+	m.PlaceItem(door, true)
+	if !m.CanSmash(&p.Actor, dp) {
+		t.Fatal("can not smash a door bare handed")
+	}
+	m.Smash(&p.Actor, dp)
+	if door.BashDamage <= 0 {
+		t.Fatal("blow did not damage the door")
+	}
+	smash(dp, func() bool { return count(dp, "Door") == 0 })
+	if count(dp, "BrokenDoor") != 1 || count(dp, "Plank") < 1 {
+		t.Fatalf("smashed door left %v", m.ItemsAt(dp))
+	}
+	// Walls shrug off bare hands but not a sledgehammer
+	wp := p.Position.Add(util.NewPoint(1, 0))
+	if m.CanSmash(&p.Actor, wp) {
+		t.Fatal("can smash a wall bare handed")
+	}
+	p.WieldItem(game.NewItem("Sledgehammer", m.Now, false))
+	if !m.CanSmash(&p.Actor, wp) {
+		t.Fatal("can not smash a wall with a sledgehammer")
+	}
+	smash(wp, func() bool { return m.GetTile(wp).ID != "Wall" })
+	if id := m.GetTile(wp).ID; id != "Rubble" {
+		t.Fatalf("smashed wall left %s", id)
+	}
+	if count(wp, "Brick") < 1 {
+		t.Fatal("smashed wall dropped no bricks")
+	}
+	if d := m.GetChunk(wp).TileDamage; len(d) != 0 {
+		t.Fatalf("smashed wall left tile damage %v", d)
+	}
+	if m.GetTile(wp).BlocksWalk {
+		t.Fatal("smashed wall still blocks the way")
+	}
+}
+
+func TestPlayerSmashRefusals(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	log := &lastLog{}
+	game.Log = log
+	m := newQuietCity(t, 3)
+	p := m.Player.Position.Add(util.NewPoint(-1, 0))
+	door := game.NewItem("OpenDoor", m.Now, false)
+	door.Position = p
+	m.PlaceItem(door, true)
+	z := game.NewActor("Zombie", m.Now, false)
+	z.Position = p
+	m.PlaceActor(z, false)
+	smash := func(p util.Point, want bool, reason string) {
+		t.Helper()
+		log.line = ""
+		if got := m.PlayerSmash(p); got != want {
+			t.Fatalf("smashing %v returned %v, logged %q", p, got, log.line)
+		}
+		if !strings.Contains(log.line, reason) {
+			t.Fatalf("smashing %v logged %q, expected %q", p, log.line, reason)
+		}
+	}
+	// An actor standing on the door protects it
+	smash(p, false, "in the way")
+	if door.BashDamage != 0 {
+		t.Fatal("door was damaged with an actor standing on it")
+	}
+	m.RemoveActor(z)
+	// The same place on another level is out of reach
+	smash(game.OnLevel(p, 1), false, "can not reach")
+	smash(p, true, "")
+}
+
+func TestTileDamageWritesStably(t *testing.T) {
+	useWallCrossRef()
+	w := bytes.NewBuffer(nil)
+	putChunk(w, 3, 0)
+	c := game.NewChunk(0, 0, 0)
+	if err := c.Read(w); err != nil {
+		t.Fatal(err)
+	}
+	c.TileDamage = map[uint32]float64{}
+	for idx := uint32(0); idx < 32; idx++ {
+		c.TileDamage[idx*7] = float64(idx)
+	}
+	c.Write(w)
+	want := bytes.Clone(w.Bytes())
+	for n := 0; n < 10; n++ {
+		w.Reset()
+		c.Write(w)
+		if !bytes.Equal(w.Bytes(), want) {
+			t.Fatal("chunk with tile damage written differently each time")
+		}
+	}
+}
@@ -89,6 +89,28 @@ var BodyPartInfo = []struct {
 	{"Feet", 1.5},
 }
 
+// EquipmentSlotCovers maps each equipment slot to the body part protected by
+// armor worn in that slot.
+var EquipmentSlotCovers = [BodyPartEquipmentSlotCount]BodyPartCode{
+	BodyPartHead, // Head
+	BodyPartBody, // Body
+	BodyPartArms, // Arms
+	BodyPartLegs, // Legs
+	BodyPartHand, // Hand
+	BodyPartFeet, // Feet
+	BodyPartHead, // Ears
+	BodyPartHead, // Eyes
+	BodyPartHead, // Neck
+	BodyPartArms, // Elbow
+	BodyPartArms, // Wrist
+	BodyPartHand, // Finger
+	BodyPartBody, // Waist
+	BodyPartLegs, // Knees
+	BodyPartLegs, // Ankle
+	BodyPartFeet, // Soles
+	BodyPartBody, // Back
+}
+
 // BodyPart encapsulates information about an actor's body part.
 type BodyPart struct {
 	// Persistent
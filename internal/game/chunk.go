@@ -1,6 +1,7 @@
 package game
 
 import (
+	"fmt"
 	"io"
 	"time"
 
@@ -52,17 +53,21 @@ type Chunk struct {
 	// Persistent values
 	//
 
-	Tiles    []*TileDef    // Tile matrix
-	Items    []*Item       // All items within the chunk
-	Actors   []*Actor      // All actors within the chunk
-	Vehicles []*Vehicle    // All vehicles who's Northwest corner are in this chunk
-	HasSeen  bitmap.Bitmap // Bitmap of all spaces that have been previously viewed by the player
+	Tiles      []*TileDef         // Tile matrix
+	Items      []*Item            // All items within the chunk
+	Actors     []*Actor           // All actors within the chunk
+	Vehicles   []*Vehicle         // All vehicles who's Northwest corner are in this chunk
+	HasSeen    bitmap.Bitmap      // Bitmap of all spaces that have been previously viewed by the player
+	Scent      []uint32           // Time the player's scent was left at each position in seconds after ScentBase, zero for none, nil if there is no scent
+	ScentBase  time.Time          // Time all scent values are relative to
+	TileDamage map[uint32]float64 // Damage taken by smashable tiles by offset within the chunk, nil if there is none
 
 	//
 	// Reconstituted values
 	//
 
 	Position          util.Point   // Position of the chunk on the city map in chunks
+	Level             int          // Level of the chunk, see LevelOf
 	Ref               uint32       // Reference index for the chunk
 	Bounds            util.Rect    // Bounds of the chunk
 	Name              string       // Descriptive name of the chunk
@@ -78,6 +83,7 @@ type Chunk struct {
 	BlocksWalk   bitmap.Bitmap // Bitmap of all spaces that are blocked for walking
 	BlocksVis    bitmap.Bitmap // Bitmap of all spaces that are blocked for visibility
 	BlocksClimb  bitmap.Bitmap // Bitmap of all spaces that can be climbed
+	Indoors      bitmap.Bitmap // Bitmap of all spaces sheltered from the weather
 	bitmapsDirty bool          // If true the BlocksWalk and BlocksVis bitmaps need to be rebuilt before use
 
 }
@@ -89,6 +95,7 @@ func NewChunk(x, y int, r uint32) *Chunk {
 	c := &Chunk{
 		Position:          util.NewPoint(x, y),
 		Ref:               r,
+		Level:             chunkRefLevel(r),
 		Bounds:            util.NewRectXYWH(x*ChunkWidth, y*ChunkHeight, ChunkWidth, ChunkHeight),
 		Name:              "an error",
 		MinimapRune:       "!",
@@ -105,23 +112,8 @@ func (c *Chunk) relOfs(p util.Point) uint32 {
 
 // Write writes the chunk to w.
 func (c *Chunk) Write(w io.Writer) {
-	util.PutUint32(w, 0)        // Version
-	for _, t := range c.Tiles { // Tile map
-		util.PutUint16(w, uint16(getTileCrossRef(t.BackRef)))
-	}
-	util.PutUint16(w, uint16(len(c.Items))) // Number of items
-	for _, i := range c.Items {             // Items
-		i.Write(w)
-	}
-	util.PutUint16(w, uint16(len(c.Actors))) // Number of actors
-	for _, a := range c.Actors {             // Actors
-		a.Write(w)
-	}
-	util.PutUint16(w, uint16(len(c.Vehicles))) // Number of vehicles
-	for _, v := range c.Vehicles {             // Vehicles
-		v.Write(w)
-	}
-	c.HasSeen.WriteTo(w) // Remembered bitmap
+	util.PutUint32(w, chunkVersion) // Version
+	chunkLayout.write(c, w)
 }
 
 // Unload frees chunk-level persistent memory
@@ -131,30 +123,118 @@ func (c *Chunk) Unload() {
 	c.Actors = nil
 	c.Vehicles = nil
 	c.HasSeen = nil
+	c.Scent = nil
+	c.TileDamage = nil
 	c.Loaded = time.Time{}
 }
 
 // Read allocates memory and reads the chunk from r.
-func (c *Chunk) Read(r io.Reader) {
-	c.Tiles = make([]*TileDef, ChunkWidth*ChunkHeight)
-	_ = util.GetUint32(r)    // Version
-	for i := range c.Tiles { // Tile map
-		x := TileCrossRef(util.GetUint16(r))
-		c.Tiles[i] = TileCrossRefs[x]
-	}
-	n := int(util.GetUint16(r)) // Number of items
-	for i := 0; i < n; i++ {    // Items
-		c.Items = append(c.Items, NewItemFromReader(r))
-	}
-	n = int(util.GetUint16(r)) // Number of actors
-	for i := 0; i < n; i++ {   // Actors
-		c.Actors = append(c.Actors, NewActorFromReader(r))
-	}
-	n = int(util.GetUint16(r)) // Number of vehicles
-	for i := 0; i < n; i++ {   // Vehicles
-		c.Vehicles = append(c.Vehicles, NewVehicleFromReader(r))
-	}
-	c.HasSeen.ReadFrom(r) // Remembered bitmap
+func (c *Chunk) Read(r io.Reader) error {
+	ver, err := ReadVersion(r, "chunk", chunkVersion) // Version
+	if err != nil {
+		return err
+	}
+	if err := chunkLayout.read(c, r, ver); err != nil {
+		return fmt.Errorf("chunk %d %w", c.Ref, err)
+	}
+	if z := chunkRefLevel(c.Ref); c.Level != z {
+		return fmt.Errorf("chunk %d stored for level %d is on level %d", c.Ref, c.Level, z)
+	}
+	return nil
+}
+
+// chunkLayout is the layout of the chunk record following the version.
+var chunkLayout = recordLayout[*Chunk]{
+	{0, "tile map",
+		func(c *Chunk, r io.Reader) error {
+			c.Tiles = make([]*TileDef, ChunkWidth*ChunkHeight)
+			for i := range c.Tiles {
+				x := TileCrossRef(util.GetUint16(r))
+				if int(x) >= len(TileCrossRefs) || TileCrossRefs[x] == nil {
+					return fmt.Errorf("references unknown tile cross reference %d", x)
+				}
+				c.Tiles[i] = TileCrossRefs[x]
+			}
+			return nil
+		},
+		func(c *Chunk, w io.Writer) {
+			for _, t := range c.Tiles {
+				util.PutUint16(w, uint16(getTileCrossRef(t.BackRef)))
+			}
+		}, nil},
+	{0, "items",
+		func(c *Chunk, r io.Reader) error {
+			c.Items = nil
+			n := int(util.GetUint16(r))
+			for i := 0; i < n; i++ {
+				item, err := NewItemFromReader(r)
+				if err != nil {
+					return err
+				}
+				c.Items = append(c.Items, item)
+			}
+			return nil
+		},
+		func(c *Chunk, w io.Writer) {
+			util.PutUint16(w, uint16(len(c.Items)))
+			for _, i := range c.Items {
+				i.Write(w)
+			}
+		}, nil},
+	{0, "actors",
+		func(c *Chunk, r io.Reader) error {
+			c.Actors = nil
+			n := int(util.GetUint16(r))
+			for i := 0; i < n; i++ {
+				a, err := NewActorFromReader(r)
+				if err != nil {
+					return err
+				}
+				c.Actors = append(c.Actors, a)
+			}
+			return nil
+		},
+		func(c *Chunk, w io.Writer) {
+			util.PutUint16(w, uint16(len(c.Actors)))
+			for _, a := range c.Actors {
+				a.Write(w)
+			}
+		}, nil},
+	{0, "vehicles",
+		func(c *Chunk, r io.Reader) error {
+			c.Vehicles = nil
+			n := int(util.GetUint16(r))
+			for i := 0; i < n; i++ {
+				v, err := NewVehicleFromReader(r)
+				if err != nil {
+					return err
+				}
+				c.Vehicles = append(c.Vehicles, v)
+			}
+			return nil
+		},
+		func(c *Chunk, w io.Writer) {
+			util.PutUint16(w, uint16(len(c.Vehicles)))
+			for _, v := range c.Vehicles {
+				v.Write(w)
+			}
+		}, nil},
+	{0, "remembered bitmap",
+		func(c *Chunk, r io.Reader) error { _, err := c.HasSeen.ReadFrom(r); return err },
+		func(c *Chunk, w io.Writer) { c.HasSeen.WriteTo(w) }, nil},
+	{1, "scent layer",
+		func(c *Chunk, r io.Reader) error { c.readScent(r); return nil },
+		func(c *Chunk, w io.Writer) { c.writeScent(w) },
+		func(c *Chunk) { c.Scent = nil }},
+	{2, "tile damage",
+		func(c *Chunk, r io.Reader) error { c.TileDamage = nil; c.readTileDamage(r); return nil },
+		func(c *Chunk, w io.Writer) { c.writeTileDamage(w) },
+		func(c *Chunk) { c.TileDamage = nil }},
+	// Records from before levels existed are all of the ground level
+	{3, "level",
+		func(c *Chunk, r io.Reader) error { c.Level = int(int8(util.GetByte(r))); return nil },
+		func(c *Chunk, w io.Writer) { util.PutByte(w, byte(int8(c.Level))) },
+		func(c *Chunk) { c.Level = 0 }},
 }
 
 // RebuildBitmaps must be called after chunk load or generation in order to
@@ -171,8 +251,12 @@ func (c *Chunk) RebuildBitmaps(cm *CityMap) {
 	c.BlocksVis.Clear()
 	c.BlocksWalk.Clear()
 	c.BlocksClimb.Clear()
+	c.Indoors.Clear()
 	// Consider tiles
 	for i, t := range c.Tiles {
+		if t.Indoors {
+			c.Indoors.Set(uint32(i))
+		}
 		if t.BlocksVis {
 			c.BlocksVis.Set(uint32(i))
 		}
@@ -211,6 +295,9 @@ func (c *Chunk) RebuildBitmaps(cm *CityMap) {
 				if l.Solid {
 					c.BlocksWalk.Set(c.relOfs(p))
 				}
+				if l.Sheltered {
+					c.Indoors.Set(c.relOfs(p))
+				}
 			}
 		}
 	}
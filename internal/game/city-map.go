@@ -20,6 +20,7 @@ const (
 	purgeInMemoryChunksTarget int = 512  // Number of chunks to keep in hot memory after purging least-recently used chunks
 	chunkUpdateRadius         int = 4    // Number of chunks away from the player to update actors
 	chunkLoadRadius           int = 5    // Number of chunks away from the player to keep chunks hot-loaded
+	playerFOVRadius           int = 64   // Farthest distance at which the player's line of sight is calculated for other actors
 )
 
 // CityMap represents the entire world of the game in terms of which chunks go
@@ -29,56 +30,75 @@ type CityMap struct {
 	// Dynamic persistent data
 	//
 
-	Player *Player   // Player actor
-	Now    time.Time // Current in-game time
+	Player      *Player            // Player actor
+	Now         time.Time          // Current in-game time
+	Hordes      []*Horde           // Roaming hordes outside of the update radius
+	Reputations map[string]float64 // Player's reputation with each faction that has changed from its starting value
+	Weather     Weather            // Weather front passing over the city
 
 	//
 	// Static persistent data
 	//
 
 	Chunks []*Chunk // The chunks of the map
+	Seed   int64    // World generation seed
 
 	//
 	// Reconstructed values
 	//
 
-	Bounds     util.Rect // Bounds of the city map in chunks
-	TileBounds util.Rect // Bounds of the city map in tiles
+	Bounds          util.Rect // Bounds of the ground level of the city map in chunks
+	TileBounds      util.Rect // Bounds of the ground level of the city map in tiles
+	stackBounds     util.Rect // Bounds of all levels of the city map in chunks
+	stackTileBounds util.Rect // Bounds of all levels of the city map in tiles
 
 	//
 	// Working variables
 	//
 
-	Visibility          bitmap.Bitmap    // Last visibility set calculated for the player
-	Remembered          bitmap.Bitmap    // Last remembered set calculated for the player
-	BitmapBounds        util.Rect        // Bounds of the Visibility and Remembered bitmaps
-	inMemoryChunks      bitmap.Bitmap    // Bitmap of all chunks loaded into memory
-	inMemoryChunksCount int              // Running count of in-memory chunks to avoid excessive calls to bitmap.Count()
-	chunksGenerated     bitmap.Bitmap    // Bitmap of all chunks that have been generated
-	cgDirty             bool             // ChunksGenerated has been altered since the last call to SaveBitmaps
-	updateSet           map[int]struct{} // Set of all chunks in the current update set
-	usNewCache          []int            // Cache of chunk indexes of newly added chunks to the update set
-	usOldCache          []int            // Cache of chunk indexes of newly removed chunks to the update set
-	aq                  actorQueue       // Queue of all actors within update range
-	gaRet               []*Actor         // Return slice for GetActors
-	itemsWithinCache    []*Item          // Return slice for ItemsWithin()
-	actorsWithinCache   []*Actor         // Return slice for ActorsWithin()
-	chunksWithinCache   []*Chunk         // Return slice for ChunksWithin()
-	vehiclesWithinCache []*Vehicle       // Return slice for VehiclesWithin()
-	updateBounds        util.Rect        // Bounds of the current update
-	loadBounds          util.Rect        // Load bounds of the current update
+	Visibility          bitmap.Bitmap     // Last visibility set calculated for the player
+	Remembered          bitmap.Bitmap     // Last remembered set calculated for the player
+	Light               bitmap.Bitmap     // Last set of lit positions calculated for the player
+	playerFOV           bitmap.Bitmap     // Positions within playerFOVRadius in line of sight of the player
+	playerFOVOrigin     util.Point        // Player position playerFOV was calculated for
+	playerFOVTime       time.Time         // Time playerFOV was calculated at
+	BitmapBounds        util.Rect         // Bounds of the Visibility and Remembered bitmaps
+	inMemoryChunks      bitmap.Bitmap     // Bitmap of all chunks loaded into memory
+	inMemoryChunksCount int               // Running count of in-memory chunks to avoid excessive calls to bitmap.Count()
+	levelChunks         []*Chunk          // Chunks of all levels other than the ground level, nil until first used
+	chunksGenerated     bitmap.Bitmap     // Bitmap of all chunks that have been generated
+	cgDirty             bool              // ChunksGenerated has been altered since the last call to SaveBitmaps
+	unsaved             map[uint32][]byte // Chunks unloaded since the last full save by reference, see purgeOldChunks
+	updateSet           map[int]struct{}  // Set of all chunks in the current update set
+	usNewCache          []int             // Cache of chunk indexes of newly added chunks to the update set
+	usOldCache          []int             // Cache of chunk indexes of newly removed chunks to the update set
+	aq                  actorQueue        // Queue of all actors within update range
+	gaRet               []*Actor          // Return slice for GetActors
+	itemsWithinCache    []*Item           // Return slice for ItemsWithin()
+	actorsWithinCache   []*Actor          // Return slice for ActorsWithin()
+	chunksWithinCache   []*Chunk          // Return slice for ChunksWithin()
+	vehiclesWithinCache []*Vehicle        // Return slice for VehiclesWithin()
+	updateBounds        util.Rect         // Bounds of the current update
+	loadBounds          util.Rect         // Load bounds of the current update
+	lastSave            time.Time         // In-game time of the last full save
+	lastSaveWall        time.Time         // Wall-clock time of the last full save
+	hordeNext           time.Time         // In-game time of the next horde simulation step
 }
 
 // NewCityMap allocates and returns a new CityMap structure.
 func NewCityMap() *CityMap {
 	m := &CityMap{
-		Bounds:     util.NewRectWH(CityMapWidth, CityMapHeight),
-		TileBounds: util.NewRectWH(CityMapWidth*ChunkWidth, CityMapHeight*ChunkHeight),
-		Chunks:     make([]*Chunk, CityMapWidth*CityMapHeight),
-		updateSet:  map[int]struct{}{},
-		usNewCache: make([]int, 0, chunkUpdateRadius*chunkUpdateRadius),
-		usOldCache: make([]int, 0, chunkUpdateRadius*chunkUpdateRadius),
-		aq:         actorQueue{},
+		Bounds:          util.NewRectWH(CityMapWidth, CityMapHeight),
+		TileBounds:      util.NewRectWH(CityMapWidth*ChunkWidth, CityMapHeight*ChunkHeight),
+		stackBounds:     util.NewRectWH(CityMapWidth, CityMapHeight*CityMapLevels),
+		stackTileBounds: util.NewRectWH(CityMapWidth*ChunkWidth, CityMapHeight*ChunkHeight*CityMapLevels),
+		Chunks:          make([]*Chunk, CityMapWidth*CityMapHeight),
+		levelChunks:     make([]*Chunk, CityMapWidth*CityMapHeight*(CityMapLevels-1)),
+		updateSet:       map[int]struct{}{},
+		Reputations:     map[string]float64{},
+		usNewCache:      make([]int, 0, chunkUpdateRadius*chunkUpdateRadius),
+		usOldCache:      make([]int, 0, chunkUpdateRadius*chunkUpdateRadius),
+		aq:              actorQueue{},
 	}
 	// Configure the starting time as two years from now at 0800
 	t := time.Now().Add(time.Hour * 24 * 730)
@@ -91,18 +111,30 @@ func NewCityMap() *CityMap {
 }
 
 // SaveCityPlan saves the city plan in the current save database.
-func (m *CityMap) SaveCityPlan() {
+func (m *CityMap) SaveCityPlan() error {
 	// Write
 	var w = bytes.NewBuffer(nil)
 	m.Write(w)
-	SaveValue("CityMap.Plan", w.Bytes())
+	return SaveValue("CityMap.Plan", w.Bytes())
 }
 
 // LoadCityPlan loads the city plan from the current save database.
-func (m *CityMap) LoadCityPlan() {
-	r := LoadValue("CityMap.Plan")
-	m.Read(r)
-	m.LoadBitmaps()
+func (m *CityMap) LoadCityPlan() error {
+	r, err := LoadValue("CityMap.Plan")
+	if err != nil {
+		return err
+	}
+	v, err := m.Read(r)
+	if err != nil {
+		return err
+	}
+	if v < cityPlanVersion {
+		// Upgrade the plan record in place
+		if err := m.SaveCityPlan(); err != nil {
+			return err
+		}
+	}
+	return m.LoadBitmaps()
 }
 
 // Write writes the city-level map information to the writer.
@@ -114,7 +146,8 @@ func (m *CityMap) Write(w io.Writer) {
 		dict.Put(c.Generator.GetVariant())
 	}
 	// Write the file
-	util.PutUint32(w, 0) // Version
+	util.PutUint32(w, cityPlanVersion) // Version
+	util.PutUint64(w, uint64(m.Seed))  // World generation seed
 	util.PutDictionary(w, dict)
 	for _, c := range m.Chunks {
 		util.PutUint16(w, dict.Get(c.Generator.GetGroup()))
@@ -126,39 +159,93 @@ func (m *CityMap) Write(w io.Writer) {
 }
 
 // SaveDynamicData writes top-level dynamic map data.
-func (m *CityMap) SaveDynamicData() {
+func (m *CityMap) SaveDynamicData() error {
 	w := bytes.NewBuffer(nil)
-	util.PutUint32(w, 0)   // Version
-	m.Player.Write(w)      // Player
-	util.PutTime(w, m.Now) // Current time
-	SaveValue("CityMap.DynamicData", w.Bytes())
+	util.PutUint32(w, dynamicDataVersion) // Version
+	dynamicDataLayout.write(m, w)
+	return SaveValue("CityMap.DynamicData", w.Bytes())
 }
 
 // LoadDynamicData loads top-level dynamic map data.
-func (m *CityMap) LoadDynamicData() {
-	r := LoadValue("CityMap.DynamicData")
-	_ = util.GetUint32(r)             // Version
-	m.Player = NewPlayerFromReader(r) // Player
-	m.Now = util.GetTime(r)           // Current time
+func (m *CityMap) LoadDynamicData() error {
+	r, err := LoadValue("CityMap.DynamicData")
+	if err != nil {
+		return err
+	}
+	ver, err := ReadVersion(r, "dynamic data", dynamicDataVersion) // Version
+	if err != nil {
+		return err
+	}
+	if err := dynamicDataLayout.read(m, r, ver); err != nil {
+		return fmt.Errorf("dynamic data %w", err)
+	}
+	return nil
+}
+
+// dynamicDataLayout is the layout of the dynamic data record following the
+// version.
+var dynamicDataLayout = recordLayout[*CityMap]{
+	{0, "player",
+		func(m *CityMap, r io.Reader) (err error) { m.Player, err = NewPlayerFromReader(r); return err },
+		func(m *CityMap, w io.Writer) { m.Player.Write(w) }, nil},
+	{0, "current time",
+		func(m *CityMap, r io.Reader) error { m.Now = util.GetTime(r); return nil },
+		func(m *CityMap, w io.Writer) { util.PutTime(w, m.Now) }, nil},
+	{1, "player activity",
+		func(m *CityMap, r io.Reader) (err error) { m.Player.Activity, err = readActivity(r); return err },
+		func(m *CityMap, w io.Writer) { writeActivity(w, m.Player.Activity) }, nil},
+	{2, "hordes",
+		func(m *CityMap, r io.Reader) (err error) { m.Hordes, err = readHordeTemplates(r, m.Now); return err },
+		nil,
+		func(m *CityMap) { m.Hordes = nil }},
+	{3, "faction reputations",
+		func(m *CityMap, r io.Reader) (err error) { m.Reputations, err = readReputations(r); return err },
+		func(m *CityMap, w io.Writer) { writeReputations(w, m.Reputations) },
+		func(m *CityMap) { m.Reputations = map[string]float64{} }},
+	{4, "weather",
+		func(m *CityMap, r io.Reader) error { m.Weather = readWeather(r); return nil },
+		func(m *CityMap, w io.Writer) { writeWeather(w, m.Weather) },
+		func(m *CityMap) { m.Weather = Weather{} }},
+	{4, "player body temperature",
+		func(m *CityMap, r io.Reader) error { m.Player.BodyTemp = util.GetFloat(r); return nil },
+		func(m *CityMap, w io.Writer) { util.PutFloat(w, m.Player.BodyTemp) }, nil},
+	{4, "player wetness",
+		func(m *CityMap, r io.Reader) error { m.Player.Wetness = util.GetFloat(r); return nil },
+		func(m *CityMap, w io.Writer) { util.PutFloat(w, m.Player.Wetness) }, nil},
+	{5, "hordes",
+		func(m *CityMap, r io.Reader) (err error) { m.Hordes, err = readHordes(r); return err },
+		func(m *CityMap, w io.Writer) { writeHordes(w, m.Hordes) }, nil},
 }
 
-// Read reads the city-level map information from the buffer.
-func (m *CityMap) Read(r io.Reader) {
-	_ = util.GetUint32(r) // Version
+// Read reads the city-level map information from the buffer and returns the
+// version of the record read.
+func (m *CityMap) Read(r io.Reader) (uint32, error) {
+	ver, err := ReadVersion(r, "city plan", cityPlanVersion) // Version
+	if err != nil {
+		return ver, err
+	}
+	if ver >= 1 {
+		m.Seed = int64(util.GetUint64(r)) // World generation seed
+	}
 	dict := util.GetDictionary(r)
 	for _, c := range m.Chunks {
 		s := dict.Lookup(util.GetUint16(r))
 		v := dict.Lookup(util.GetUint16(r))
 		c.Generator = GetChunkGen(s, v)
+		if c.Generator == nil {
+			return ver, fmt.Errorf("city plan references non-existent chunk generator %s variant %s", s, v)
+		}
 		c.ChunkGenOffset = util.GetPoint(r)
 		c.Facing = util.Facing(util.GetByte(r))
 		c.Flags = ChunkFlags(util.GetByte(r))
 		c.Generator.AssignStaticInfo(c)
 	}
+	return ver, nil
 }
 
-// GetChunkFromMapPoint returns the chunk definition at the given map location
-// or nil if out of bounds. Note the point is in chunks not tiles.
+// GetChunkFromMapPoint returns the chunk definition of the ground level at the
+// given map location or nil if out of bounds. Note the point is in chunks not
+// tiles.
 func (m *CityMap) GetChunkFromMapPoint(p util.Point) *Chunk {
 	if !m.Bounds.Contains(p) {
 		return nil
@@ -166,28 +253,24 @@ func (m *CityMap) GetChunkFromMapPoint(p util.Point) *Chunk {
 	return m.Chunks[p.Y*CityMapWidth+p.X]
 }
 
-// GetChunk returns the correct chunk for the given absolute tile point or nil
-// if the point is out of bounds.
+// GetChunk returns the correct chunk on any level for the given absolute tile
+// point or nil if the point is out of bounds.
 func (m *CityMap) GetChunk(p util.Point) *Chunk {
-	if !m.TileBounds.Contains(p) {
+	if !m.stackTileBounds.Contains(p) {
 		return nil
 	}
-	return m.Chunks[(p.Y/ChunkHeight)*CityMapWidth+(p.X/ChunkWidth)]
+	return m.chunkByRef(uint32((p.Y/ChunkHeight)*CityMapWidth + (p.X / ChunkWidth)))
 }
 
 // ChunksWithin returns all chunks within the given bounds. The return value
 // will be reused on subsequent calls to GetChunksWithin.
 func (m *CityMap) ChunksWithin(b util.Rect) []*Chunk {
 	m.chunksWithinCache = m.chunksWithinCache[:0]
-	cb := m.TileBounds.Overlap(b).Divide(ChunkWidth)
+	cb := m.stackTileBounds.Overlap(b).Divide(ChunkWidth)
 	var p util.Point
 	for p.Y = cb.TL.Y; p.Y <= cb.BR.Y; p.Y++ {
 		for p.X = cb.TL.X; p.X <= cb.BR.X; p.X++ {
-			c := m.GetChunkFromMapPoint(p)
-			if c == nil {
-				continue
-			}
-			m.chunksWithinCache = append(m.chunksWithinCache, c)
+			m.chunksWithinCache = append(m.chunksWithinCache, m.chunkByRef(chunkRefForPoint(p)))
 		}
 	}
 	return m.chunksWithinCache
@@ -196,7 +279,7 @@ func (m *CityMap) ChunksWithin(b util.Rect) []*Chunk {
 // GetTile returns the tile at the given absolute tile point or nil if the point
 // is out of bounds.
 func (m *CityMap) GetTile(p util.Point) *TileDef {
-	c := m.Chunks[(p.Y/ChunkHeight)*CityMapWidth+(p.X/ChunkWidth)]
+	c := m.chunkByRef(uint32((p.Y/ChunkHeight)*CityMapWidth + (p.X / ChunkWidth)))
 	if c.Loaded.IsZero() {
 		return nil
 	}
@@ -204,71 +287,83 @@ func (m *CityMap) GetTile(p util.Point) *TileDef {
 	return t
 }
 
+// EnsureLoadedAround ensures that all chunks within loading range of the given
+// absolute tile point have been generated and are loaded into memory.
+func (m *CityMap) EnsureLoadedAround(p util.Point) error {
+	cp := util.NewPoint(p.X/ChunkWidth, p.Y/ChunkHeight)
+	return m.EnsureLoaded(util.NewRectFromRadius(cp, chunkLoadRadius))
+}
+
 // EnsureLoaded ensures that all chunks in the area given in chunk coordinates
 // have been generated and are loaded into memory.
-func (m *CityMap) EnsureLoaded(r util.Rect) {
-	if r.TL.X < 0 {
-		r.TL.X = 0
-	}
-	if r.TL.Y < 0 {
-		r.TL.Y = 0
-	}
-	if r.BR.X >= CityMapWidth {
-		r.BR.X = CityMapWidth - 1
-	}
-	if r.BR.Y >= CityMapHeight {
-		r.BR.Y = CityMapHeight - 1
-	}
+func (m *CityMap) EnsureLoaded(r util.Rect) error {
+	r = m.stackBounds.Overlap(r)
 	// Load all chunks within the area
 	var p util.Point
 	now := time.Now()
 	for p.Y = r.TL.Y; p.Y <= r.BR.Y; p.Y++ {
 		for p.X = r.TL.X; p.X <= r.BR.X; p.X++ {
-			ref := chunkRefForPoint(p)
-			c := m.Chunks[ref]
-			m.LoadChunk(c, now)
+			c := m.chunkByRef(chunkRefForPoint(p))
+			if err := m.LoadChunk(c, now); err != nil {
+				return err
+			}
 		}
 	}
 	// After we load chunks we need to make sure to purge old chunks so we don't
 	// fill all available RAM with chunk data.
 	m.purgeOldChunks()
+	return nil
 }
 
 // LoadChunk loads the passed-in chunk or generates it if needed. This function
-// is cheap if the chunk is already in memory.
-func (m *CityMap) LoadChunk(c *Chunk, now time.Time) {
+// is cheap if the chunk is already in memory. Generated chunks are only written
+// to the save database by the next full save.
+func (m *CityMap) LoadChunk(c *Chunk, now time.Time) error {
 	c.Loaded = now
 	// Bail if we are already loaded
 	if c.Tiles != nil {
-		return
+		return nil
 	}
-	// There is no possibility of error after this point so go ahead
-	// and mark the chunk as in-memory
-	m.inMemoryChunks.Set(c.Ref)
-	m.inMemoryChunksCount++
 	// Allocate memory
 	c.Tiles = make([]*TileDef, ChunkWidth*ChunkHeight)
 	// Generate the chunk if this has never happened before
 	if !m.chunksGenerated.Contains(c.Ref) {
 		m.chunksGenerated.Set(c.Ref)
 		m.cgDirty = true
-		c.Generator.Generate(c, m)
+		// Chunk contents are derived from the world seed and chunk reference
+		util.WithSeed(util.SubSeed(m.Seed, uint64(c.Ref)), func() {
+			c.Generator.Generate(c, m)
+		})
+		c.bitmapsDirty = true
+	} else {
+		// Otherwise load the chunk into memory from the save database, or from
+		// memory if it was unloaded since the last full save
+		var buf io.Reader
+		var err error
+		if d, found := m.unsaved[c.Ref]; found {
+			buf = bytes.NewReader(d)
+		} else {
+			buf, err = LoadValue(fmt.Sprintf("Chunk-%d", c.Ref))
+		}
+		if err == nil {
+			err = c.Read(buf)
+		}
+		if err != nil {
+			c.Unload()
+			return fmt.Errorf("loading chunk %d: %w", c.Ref, err)
+		}
 		c.bitmapsDirty = true
-		w := bytes.NewBuffer(nil)
-		c.Write(w)
-		SaveValue(fmt.Sprintf("Chunk-%d", c.Ref), w.Bytes())
-		return
 	}
-	// Otherwise load the chunk into memory from the save database
-	n := fmt.Sprintf("Chunk-%d", c.Ref)
-	buf := LoadValue(n)
-	c.Read(buf)
-	c.bitmapsDirty = true
+	// Mark the chunk as in-memory
+	m.inMemoryChunks.Set(c.Ref)
+	m.inMemoryChunksCount++
+	return nil
 }
 
 // purgeOldChunks purges chunks in least-recently-used first order down to the
 // target number if the number of chunks in the memory cache is greater than the
-// maximum.
+// maximum. Purged chunks are kept encoded in memory until the next full save
+// so the save database only ever changes within a full save.
 func (m *CityMap) purgeOldChunks() {
 	// Short-circuit condition
 	if m.inMemoryChunksCount <= maxInMemoryChunks {
@@ -280,83 +375,130 @@ func (m *CityMap) purgeOldChunks() {
 		cRefs = append(cRefs, x)
 	})
 	slices.SortFunc[[]uint32](cRefs, func(a, b uint32) int {
-		if m.Chunks[a].Loaded.Before(m.Chunks[b].Loaded) {
+		if m.chunkByRef(a).Loaded.Before(m.chunkByRef(b).Loaded) {
 			return -1
-		} else if m.Chunks[a].Loaded.After(m.Chunks[b].Loaded) {
+		} else if m.chunkByRef(a).Loaded.After(m.chunkByRef(b).Loaded) {
 			return 1
 		}
 		return 0
 	})
-	// Persist and unload the oldest chunks until we reach the purge target
-	buffers := map[uint32][]byte{}
+	// Encode and unload the oldest chunks until we reach the purge target
+	if m.unsaved == nil {
+		m.unsaved = map[uint32][]byte{}
+	}
 	for _, cr := range cRefs[:maxInMemoryChunks-purgeInMemoryChunksTarget] {
 		w := bytes.NewBuffer(nil)
-		c := m.Chunks[cr]
+		c := m.chunkByRef(cr)
+		if !m.inUpdateSet(c.Position) {
+			m.absorbActors(c)
+		}
 		c.Write(w)
-		buffers[cr] = w.Bytes()
+		m.unsaved[cr] = w.Bytes()
 		c.Unload()
 		m.inMemoryChunks.Remove(cr)
 		m.inMemoryChunksCount--
 	}
-	// Save all unloaded chunks to the database
-	for k, v := range buffers {
-		name := fmt.Sprintf("Chunk-%d", k)
-		SaveValue(name, v)
-	}
-	// If any chunks updated the tile cross references we need to save them
-	if crossReferencesDirty {
-		SaveTileRefs()
-	}
-	m.SaveBitmaps()
 }
 
-// saveAllChunks saves all in-memory chunks to the current save database without
-// freeing memory.
-func (m *CityMap) saveAllChunks() {
+// saveAllChunks saves all in-memory chunks and all chunks unloaded since the
+// last full save to the current save database without freeing memory.
+func (m *CityMap) saveAllChunks() error {
 	// Accumulate all data
 	buffers := map[uint32][]byte{}
+	for r, d := range m.unsaved {
+		buffers[r] = d
+	}
 	m.inMemoryChunks.Range(func(x uint32) {
 		w := bytes.NewBuffer(nil)
-		c := m.Chunks[x]
+		c := m.chunkByRef(x)
 		c.Write(w)
 		buffers[x] = w.Bytes()
 	})
 	// Write to database
 	for r, v := range buffers {
 		name := fmt.Sprintf("Chunk-%d", r)
-		SaveValue(name, v)
+		if err := SaveValue(name, v); err != nil {
+			return err
+		}
 	}
 	// If any chunks updated the tile cross references we need to save them
 	if crossReferencesDirty {
-		SaveTileRefs()
+		if err := SaveTileRefs(); err != nil {
+			return err
+		}
 	}
-	m.SaveBitmaps()
+	return m.SaveBitmaps()
 }
 
 // SaveBitmaps saves all persistent bitmaps.
-func (m *CityMap) SaveBitmaps() {
+func (m *CityMap) SaveBitmaps() error {
 	if !m.cgDirty {
-		return
+		return nil
 	}
 	w := bytes.NewBuffer(nil)
-	util.PutUint32(w, 0) // Version
+	util.PutUint32(w, bitmapsVersion) // Version
 	m.chunksGenerated.WriteTo(w)
-	SaveValue("CityMap.ChunksGenerated", w.Bytes())
+	if err := SaveValue("CityMap.ChunksGenerated", w.Bytes()); err != nil {
+		return err
+	}
 	m.cgDirty = false
+	return nil
 }
 
 // LoadBitmaps loads all persistent bitmaps.
-func (m *CityMap) LoadBitmaps() {
-	r := LoadValue("CityMap.ChunksGenerated")
-	_ = util.GetUint32(r) // Version
-	m.chunksGenerated.ReadFrom(r)
+func (m *CityMap) LoadBitmaps() error {
+	r, err := LoadValue("CityMap.ChunksGenerated")
+	if err != nil {
+		return err
+	}
+	if _, err := ReadVersion(r, "bitmaps", bitmapsVersion); err != nil { // Version
+		return err
+	}
+	_, err = m.chunksGenerated.ReadFrom(r)
+	return err
 }
 
 // FullSave commits the entire working set to the current save database without
-// freeing memory.
-func (m *CityMap) FullSave() {
-	m.saveAllChunks()
-	m.SaveDynamicData()
+// freeing memory. All data is written in a single transaction so the save is
+// consistent even if the process dies part way through.
+func (m *CityMap) FullSave() error {
+	if err := SaveTransaction(func() error {
+		if err := m.saveAllChunks(); err != nil {
+			return err
+		}
+		return m.SaveDynamicData()
+	}); err != nil {
+		return err
+	}
+	clear(m.unsaved)
+	m.lastSave = m.Now
+	m.lastSaveWall = time.Now()
+	return updatePlayTime()
+}
+
+// autosave saves the city and takes a backup of the save if either autosave
+// interval has elapsed since the last full save.
+func (m *CityMap) autosave() {
+	// Scratch saves are thrown away so there is no point saving them
+	if saveInfo == nil {
+		return
+	}
+	if m.lastSave.IsZero() {
+		m.lastSave = m.Now
+		m.lastSaveWall = time.Now()
+		return
+	}
+	if (AutosaveGameInterval <= 0 || m.Now.Sub(m.lastSave) < AutosaveGameInterval) &&
+		(AutosaveWallInterval <= 0 || time.Since(m.lastSaveWall) < AutosaveWallInterval) {
+		return
+	}
+	if err := m.FullSave(); err != nil {
+		Log.Log(termui.ColorRed, "Autosave failed: %v", err)
+		return
+	}
+	if err := BackupSave(); err != nil {
+		Log.Log(termui.ColorRed, "Backup failed: %v", err)
+	}
 }
 
 func chunkRefForPoint(p util.Point) uint32 {
@@ -368,11 +510,11 @@ func chunkRefForPoint(p util.Point) uint32 {
 func (m *CityMap) GetActors(b util.Rect) []*Actor {
 	m.gaRet = m.gaRet[:0]
 	cb := b.Divide(ChunkWidth)
-	cb = m.Bounds.Overlap(cb)
+	cb = m.stackBounds.Overlap(cb)
 	var p util.Point
 	for p.Y = cb.TL.Y; p.Y <= cb.BR.Y; p.Y++ {
 		for p.X = cb.TL.X; p.X <= cb.BR.X; p.X++ {
-			c := m.GetChunkFromMapPoint(p)
+			c := m.chunkByRef(chunkRefForPoint(p))
 			for _, a := range c.Actors {
 				if b.Contains(a.Position) {
 					m.gaRet = append(m.gaRet, a)
@@ -413,10 +555,10 @@ func (m *CityMap) ItemsAt(p util.Point) []*Item {
 func (m *CityMap) ItemsWithin(b util.Rect) []*Item {
 	m.itemsWithinCache = m.itemsWithinCache[:0]
 	cb := util.NewRect(b.TL.Divide(ChunkWidth), b.BR.Divide(ChunkWidth))
-	cb = m.Bounds.Overlap(cb)
+	cb = m.stackBounds.Overlap(cb)
 	for cy := cb.TL.Y; cy <= cb.BR.Y; cy++ {
 		for cx := cb.TL.X; cx <= cb.BR.X; cx++ {
-			c := m.Chunks[cy*CityMapWidth+cx]
+			c := m.chunkByRef(uint32(cy*CityMapWidth + cx))
 			for _, i := range c.Items {
 				if b.Contains(i.Position) {
 					m.itemsWithinCache = append(m.itemsWithinCache, i)
@@ -466,7 +608,7 @@ func (m *CityMap) RemoveVehicle(v *Vehicle) bool {
 // VehiclesWithin.
 func (m *CityMap) VehiclesWithin(b util.Rect) []*Vehicle {
 	m.vehiclesWithinCache = m.vehiclesWithinCache[:0]
-	qb := m.TileBounds.Overlap(b.Grow(16))
+	qb := m.stackTileBounds.Overlap(b.Grow(16))
 	for _, c := range m.ChunksWithin(qb) {
 		// Skip chunks that are not yet generated or in memory
 		var tz time.Time
@@ -543,9 +685,10 @@ func (m *CityMap) ActorAt(p util.Point) *Actor {
 func (m *CityMap) ActorsWithin(b util.Rect) []*Actor {
 	m.actorsWithinCache = m.actorsWithinCache[:0]
 	cb := util.NewRectXYWH(b.TL.X/ChunkWidth, b.TL.Y/ChunkHeight, b.Width()/ChunkWidth+1, b.Height()/ChunkHeight+1)
+	cb = m.stackBounds.Overlap(cb)
 	for cy := cb.TL.Y; cy <= cb.BR.Y; cy++ {
 		for cx := cb.TL.X; cx <= cb.BR.X; cx++ {
-			c := m.Chunks[cy*CityMapWidth+cx]
+			c := m.chunkByRef(uint32(cy*CityMapWidth + cx))
 			for _, a := range c.Actors {
 				if b.Contains(a.Position) {
 					m.actorsWithinCache = append(m.actorsWithinCache, a)
@@ -565,7 +708,7 @@ func (m *CityMap) StepActor(a *Actor, climbing bool, d util.Direction) (bool, bo
 		return false, false
 	}
 	np := a.Position.Add(util.DirectionOffsets[d.Bound()])
-	if !m.TileBounds.Contains(np) {
+	if !LevelBounds(LevelOf(a.Position)).Contains(np) {
 		return false, false
 	}
 	op := a.Position
@@ -589,7 +732,7 @@ func (m *CityMap) StepPlayer(climbing bool, d util.Direction) bool {
 		return false
 	}
 	np := m.Player.Position.Add(util.DirectionOffsets[d.Bound()])
-	if !m.TileBounds.Contains(np) {
+	if !LevelBounds(LevelOf(m.Player.Position)).Contains(np) {
 		return false
 	}
 	nc := m.GetChunk(np)
@@ -608,8 +751,10 @@ func (m *CityMap) StepPlayer(climbing bool, d util.Direction) bool {
 		if m.Player.Running && m.Player.Stamina <= 0 {
 			Log.Log(termui.ColorRed, "You are exhausted and slow to a walk.")
 			m.Player.Running = false
+			m.Player.ApplyEffect("Exhaustion", m.Now) // Optional for mods to define
 		}
 		if m.Player.Running {
+			m.MakeNoise(np, NoiseFootsteps)
 			dur /= 4
 			m.Player.Stamina -= float64(dur) / float64(time.Second*30) // Can run for about 30 seconds - the duration is not terribly realistic but the limit is for game play balance
 			m.Player.Stamina -= float64(dur) / float64(time.Minute*5)  // Counteract stamina gain
@@ -625,7 +770,7 @@ func (m *CityMap) PlayerCanClimb(d util.Direction) bool {
 		return false
 	}
 	np := m.Player.Position.Add(util.DirectionOffsets[d.Bound()])
-	if !m.TileBounds.Contains(np) {
+	if !LevelBounds(LevelOf(m.Player.Position)).Contains(np) {
 		return false
 	}
 	nc := m.GetChunk(np)
@@ -634,83 +779,41 @@ func (m *CityMap) PlayerCanClimb(d util.Direction) bool {
 }
 
 // MakeVisibilitySets constructs bitmaps representing the current and remembered
-// visibility of each position within the bounds relative to the player. This is
-// a no-op if the bounds do not contain the player. Visibility sets are stored
-// in Visibility and Remembered members.
+// visibility of each position within the bounds relative to the player. Only
+// the remembered set is constructed if the bounds do not contain the player,
+// as when viewing another level. Positions in line of sight are only visible
+// if they are lit or near enough to make out in the dark. Visibility sets are
+// stored in Visibility and Remembered members and the lit positions in the
+// Light member.
 func (m *CityMap) MakeVisibilitySets(b util.Rect) {
-	var dp util.Point
-	// Process one line of visibility calculations
-	fn := func(ps []util.Point) {
-		// Range over the points excluding the first
-		done := false
-		for _, p := range ps[1:] {
-			// Bail if we've already hit a non-visible position
-			if done {
-				break
-			}
-			// If this point blocks visibility we are done
-			c := m.GetChunk(p)
-			if c.BlocksVis.Contains(c.relOfs(p)) {
-				done = true
-			}
-			// Skip processing points that have already been marked visible
-			idx := uint32((p.Y-b.TL.Y)*b.Width() + (p.X - b.TL.X))
-			if m.Visibility.Contains(idx) {
-				continue
-			}
-			// Set this point as visible
-			m.Visibility.Set(idx)
-			// Set all neighbors as visible if they block vis this fixes wall
-			// looking issues
-			for dp.Y = p.Y - 1; dp.Y <= p.Y+1; dp.Y++ {
-				if dp.Y < b.TL.Y || dp.Y > b.BR.Y {
-					continue
-				}
-				for dp.X = p.X - 1; dp.X <= p.X+1; dp.X++ {
-					if dp.X < b.TL.X || dp.X > b.BR.X {
-						continue
-					}
-					c := m.GetChunk(dp)
-					if c.BlocksVis.Contains(c.relOfs(dp)) {
-						m.Visibility.Set(uint32((dp.Y-b.TL.Y)*b.Width() + (dp.X - b.TL.X)))
-					}
-				}
-			}
-		}
-	}
+	sr := m.Player.CurrentSightRange()
+	dr := m.darkSightRange(sr, m.Player.Position)
 	// Setup return values for reuse
 	m.BitmapBounds = b
 	m.Visibility.Clear()
 	m.Remembered.Clear()
-	// Sanity checks
-	if !b.Contains(m.Player.Position) {
-		return
-	}
-	// Mark the starting location as visible always
-	p := m.Player.Position
-	m.Visibility.Set(uint32((p.Y-b.TL.Y)*b.Width() + (p.X - b.TL.X)))
-	// Cast rays to the boarders of the rect
-	for i := 0; i < b.Width(); i++ {
-		fn(util.Ray(m.Player.Position, util.Point{
-			X: b.TL.X + i,
-			Y: b.TL.Y,
-		}))
-		fn(util.Ray(m.Player.Position, util.Point{
-			X: b.TL.X + i,
-			Y: b.BR.Y,
-		}))
-	}
-	for i := 1; i < b.Height()-1; i++ {
-		fn(util.Ray(m.Player.Position, util.Point{
-			X: b.TL.X,
-			Y: b.TL.Y + i,
-		}))
-		fn(util.Ray(m.Player.Position, util.Point{
-			X: b.BR.X,
-			Y: b.TL.Y + i,
-		}))
+	if b.Contains(m.Player.Position) {
+		m.makeLightSet(b)
+		// Positions in line of sight are visible if they are lit or near
+		// enough to make out in the dark
+		fov := util.FOV{
+			Blocks: m.visBlocker(LevelBounds(LevelOf(m.Player.Position))),
+			Visible: func(p util.Point) {
+				if !b.Contains(p) {
+					return
+				}
+				idx := uint32((p.Y-b.TL.Y)*b.Width() + (p.X - b.TL.X))
+				if m.Light.Contains(idx) || p.Distance(m.Player.Position) <= dr {
+					m.Visibility.Set(idx)
+				}
+			},
+		}
+		fov.Execute(m.Player.Position, sr)
+	} else {
+		m.Light.Clear()
 	}
 	// Construct remembered set for chunks and return value
+	var p util.Point
 	for p.Y = b.TL.Y; p.Y <= b.BR.Y; p.Y++ {
 		for p.X = b.TL.X; p.X <= b.BR.X; p.X++ {
 			idx := uint32((p.Y-b.TL.Y)*b.Width() + (p.X - b.TL.X))
@@ -727,38 +830,89 @@ func (m *CityMap) MakeVisibilitySets(b util.Rect) {
 }
 
 // CanSeePlayerFrom returns true if there is line of sight between the given
-// point and the player.
+// point and the player. This is symmetric with the player's view.
 func (m *CityMap) CanSeePlayerFrom(p util.Point) bool {
-	// Position is on-screen, use visibility set
-	if m.BitmapBounds.Contains(p) {
-		idx := (p.Y-m.BitmapBounds.TL.Y)*m.BitmapBounds.Width() + (p.X - m.BitmapBounds.TL.X)
-		return m.Visibility.Contains(uint32(idx))
-	}
-	// Position is off-screen, use a ray trace as the asymmetry won't be
-	// noticeable
-	for _, p := range util.Ray(p, m.Player.Position) {
-		c := m.GetChunk(p)
-		if c.BlocksVis.Contains(c.relOfs(p)) {
-			return false
+	pp := m.Player.Position
+	if p.Distance(pp) > playerFOVRadius {
+		return false
+	}
+	// Rebuild the player's field of view if the player or the world has moved
+	// on since it was last calculated
+	if pp != m.playerFOVOrigin || !m.Now.Equal(m.playerFOVTime) {
+		m.playerFOVOrigin = pp
+		m.playerFOVTime = m.Now
+		m.playerFOV.Clear()
+		fov := util.FOV{
+			Blocks: m.visBlocker(LevelBounds(LevelOf(pp))),
+			Visible: func(p util.Point) {
+				m.playerFOV.Set(playerFOVIndex(pp, p))
+			},
 		}
+		fov.Execute(pp, playerFOVRadius)
 	}
-	return true
+	return m.playerFOV.Contains(playerFOVIndex(pp, p))
+}
+
+// playerFOVIndex returns the index into the playerFOV bitmap of p for the
+// player at pp.
+func playerFOVIndex(pp, p util.Point) uint32 {
+	const w = playerFOVRadius*2 + 1
+	return uint32((p.Y-pp.Y+playerFOVRadius)*w + (p.X - pp.X + playerFOVRadius))
+}
+
+// visBlocker returns a function that returns true if the given point blocks
+// visibility for use with util.FOV. Points outside of the level bounds lb
+// always block.
+func (m *CityMap) visBlocker(lb util.Rect) func(util.Point) bool {
+	var c *Chunk
+	return func(p util.Point) bool {
+		if !lb.Contains(p) {
+			return true
+		}
+		if c == nil || !c.Bounds.Contains(p) {
+			if c = m.GetChunk(p); c == nil {
+				return true
+			}
+			if c.bitmapsDirty {
+				c.RebuildBitmaps(m)
+			}
+		}
+		return c.BlocksVis.Contains(c.relOfs(p))
+	}
+}
+
+// HasLineOfSight returns true if b is visible from a by the same rules as the
+// player's field of view. There is never line of sight between levels.
+func (m *CityMap) HasLineOfSight(a, b util.Point) bool {
+	if LevelOf(a) != LevelOf(b) {
+		return false
+	}
+	return util.LineOfSight(m.visBlocker(LevelBounds(LevelOf(a))), a, b)
 }
 
-// Update updates the game world for d duration based around point p.
-func (m *CityMap) Update(p util.Point, d time.Duration, update func()) {
+// Update updates the game world for d duration based around point p. If the
+// chunks around p can not be loaded the error is returned and nothing but the
+// clock and weather is updated.
+func (m *CityMap) Update(p util.Point, d time.Duration, update func()) error {
 	m.Now = m.Now.Add(d)
+	m.updateWeather(d)
 	// Updates of one minute or longer will use the wait handler automatically
 	if d < time.Minute {
-		m.updatePrepSets(p)
+		if err := m.updatePrepSets(p); err != nil {
+			return err
+		}
 		m.updateShort(d)
 		m.updateItemsAndPostProcessing(d)
 	} else {
 		m.Wait(d, update)
 	}
+	m.updateHordes()
+	return nil
 }
 
-func (m *CityMap) updatePrepSets(p util.Point) {
+// updatePrepSets loads the chunks around p and moves actors in and out of the
+// actor queue as chunks enter and leave the update radius.
+func (m *CityMap) updatePrepSets(p util.Point) error {
 	fn := func(p util.Point) int {
 		return p.Y*CityMapWidth + p.X
 	}
@@ -770,12 +924,15 @@ func (m *CityMap) updatePrepSets(p util.Point) {
 		X: p.X / ChunkWidth,
 		Y: p.Y / ChunkHeight,
 	}
-	lb := util.NewRectFromRadius(cp, chunkLoadRadius).Overlap(m.Bounds)
-	ub := util.NewRectFromRadius(cp, chunkUpdateRadius).Overlap(m.Bounds)
+	lvb := LevelBounds(LevelOf(p)).Divide(ChunkWidth)
+	lb := util.NewRectFromRadius(cp, chunkLoadRadius).Overlap(lvb)
+	ub := util.NewRectFromRadius(cp, chunkUpdateRadius).Overlap(lvb)
+	// Load chunks, leaving the update sets as they were on failure
+	if err := m.EnsureLoaded(lb); err != nil {
+		return fmt.Errorf("error loading chunks: %w", err)
+	}
 	m.loadBounds = lb.Multiply(ChunkWidth)
 	m.updateBounds = ub.Multiply(ChunkWidth)
-	// Load chunks
-	m.EnsureLoaded(lb)
 	// Prep new chunks set
 	newSet := map[int]struct{}{}
 	for p.Y = ub.TL.Y; p.Y <= ub.BR.Y; p.Y++ {
@@ -796,16 +953,17 @@ func (m *CityMap) updatePrepSets(p util.Point) {
 	m.updateSet = newSet
 	// Remove actors in the old chunks from the priority queue
 	for _, idx := range m.usOldCache {
-		c := m.Chunks[idx]
+		c := m.chunkByRef(uint32(idx))
 		for _, a := range c.Actors {
 			heap.Remove(&m.aq, a.pqIdx)
 		}
+		m.absorbActors(c)
 	}
 	// Add actors in the new chunks to the priority queue and reset their think
 	// times so the actors don't take a million turns when the chunk gets
 	// reloaded after a long winter
 	for _, idx := range m.usNewCache {
-		c := m.Chunks[idx]
+		c := m.chunkByRef(uint32(idx))
 		for _, a := range c.Actors {
 			if a.NextThink.Before(m.Now) {
 				a.NextThink = m.Now
@@ -813,6 +971,7 @@ func (m *CityMap) updatePrepSets(p util.Point) {
 			heap.Push(&m.aq, a)
 		}
 	}
+	return nil
 }
 
 // updateShort updates short-term updates for actors and vehicles.
@@ -873,6 +1032,25 @@ func (m *CityMap) Wait(d time.Duration, update func()) {
 	m.updateItemsAndPostProcessing(d)
 }
 
+// updateItem executes the update event of the item and of all of its contents,
+// removing destroyed contents.
+func (m *CityMap) updateItem(i *Item, d time.Duration) {
+	ExecuteItemUpdateEvent("Update", i, m, d)
+	if len(i.Inventory) == 0 {
+		return
+	}
+	n := 0
+	for _, c := range i.Inventory {
+		m.updateItem(c, d)
+		if !c.Destroyed {
+			i.Inventory[n] = c
+			n++
+		}
+	}
+	clear(i.Inventory[n:])
+	i.Inventory = i.Inventory[:n]
+}
+
 func (m *CityMap) updateItemsAndPostProcessing(d time.Duration) {
 	var p util.Point
 	var actorsToRemove []*Actor
@@ -882,7 +1060,7 @@ func (m *CityMap) updateItemsAndPostProcessing(d time.Duration) {
 		for p.X = m.updateBounds.TL.X; p.X <= m.updateBounds.BR.X; p.X += ChunkWidth {
 			c := m.GetChunk(p)
 			for _, i := range c.Items {
-				ExecuteItemUpdateEvent("Update", i, m, d)
+				m.updateItem(i, d)
 			}
 		}
 	}
@@ -891,13 +1069,13 @@ func (m *CityMap) updateItemsAndPostProcessing(d time.Duration) {
 		if i == nil {
 			continue
 		}
-		ExecuteItemUpdateEvent("Update", i, m, d)
+		m.updateItem(i, d)
 		if i.Destroyed {
 			m.Player.WornItems[idx] = nil
 		}
 	}
 	if m.Player.Weapon != nil {
-		ExecuteItemUpdateEvent("Update", m.Player.Weapon, m, d)
+		m.updateItem(m.Player.Weapon, d)
 		if m.Player.Weapon.Destroyed {
 			m.Player.Weapon = nil
 		}
@@ -907,7 +1085,7 @@ func (m *CityMap) updateItemsAndPostProcessing(d time.Duration) {
 		if i == nil {
 			continue
 		}
-		ExecuteItemUpdateEvent("Update", i, m, d)
+		m.updateItem(i, d)
 		if i.Destroyed {
 			itemsToRemove = append(itemsToRemove, i)
 		}
@@ -924,13 +1102,13 @@ func (m *CityMap) updateItemsAndPostProcessing(d time.Duration) {
 					if i == nil {
 						continue
 					}
-					ExecuteItemUpdateEvent("Update", i, m, d)
+					m.updateItem(i, d)
 					if i.Destroyed {
 						a.WornItems[idx] = nil
 					}
 				}
 				if a.Weapon != nil {
-					ExecuteItemUpdateEvent("Update", a.Weapon, m, d)
+					m.updateItem(a.Weapon, d)
 					if a.Weapon.Destroyed {
 						a.Weapon = nil
 					}
@@ -940,7 +1118,7 @@ func (m *CityMap) updateItemsAndPostProcessing(d time.Duration) {
 					if i == nil {
 						continue
 					}
-					ExecuteItemUpdateEvent("Update", i, m, d)
+					m.updateItem(i, d)
 					if i.Destroyed {
 						itemsToRemove = append(itemsToRemove, i)
 					}
@@ -994,12 +1172,23 @@ func (m *CityMap) updateItemsAndPostProcessing(d time.Duration) {
 // PlayerTookTurn is responsible for updating the city map model for the given
 // duration as well as anything else that should happen after the player's turn.
 func (m *CityMap) PlayerTookTurn(d time.Duration, update func()) {
+	if !m.Player.InControl {
+		m.LeaveScent(m.Player.Position)
+	}
+	m.updateBodyTemperature(d)
 	m.Player.TookTurn(m.Now, d)
-	m.Update(m.Player.Position, d, update)
+	if err := m.Update(m.Player.Position, d, update); err != nil {
+		// Saving now would persist the half-loaded world
+		util.Log("%v", err)
+		Log.Log(termui.ColorRed, "Error updating the world: %v", err)
+		return
+	}
 	// End conditions check
 	if m.Player.Dead {
 		Log.Log(termui.ColorRed, "YOU ARE DEAD! Press Escape to return to the main menu.")
+		return
 	}
+	m.autosave()
 }
 
 // FlagBitmapsForVehicle sets bitmaps dirty for all chunks occupied by the given
@@ -1013,7 +1202,7 @@ func (m *CityMap) FlagBitmapsForVehicle(v *Vehicle, nb util.Rect) {
 
 // VehicleFits returns true if the vehicle fits within the given bounds.
 func (m *CityMap) VehicleFits(v *Vehicle, nb util.Rect) bool {
-	if nb.Area() != m.TileBounds.Overlap(nb).Area() {
+	if nb.Area() != LevelBounds(LevelOf(nb.TL)).Overlap(nb).Area() {
 		// Not totally within the map
 		return false
 	}
@@ -0,0 +1,47 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DamageType is a code that indicates the kind of damage an attack deals.
+type DamageType uint8
+
+const (
+	DamageTypeBash   DamageType = 0
+	DamageTypeCut    DamageType = 1
+	DamageTypePierce DamageType = 2
+	DamageTypeBite   DamageType = 3
+	DamageTypeFire   DamageType = 4
+	DamageTypeCount  int        = int(DamageTypeFire) + 1
+)
+
+// DamageTypeNames maps DamageType codes to their names.
+var DamageTypeNames = []string{
+	"Bash",
+	"Cut",
+	"Pierce",
+	"Bite",
+	"Fire",
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface so damage
+// types may be used as both JSON values and map keys.
+func (t *DamageType) UnmarshalText(in []byte) error {
+	for i, n := range DamageTypeNames {
+		if strings.EqualFold(n, string(in)) {
+			*t = DamageType(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported damage type name %s", string(in))
+}
+
+// String implements the fmt.Stringer interface.
+func (t DamageType) String() string {
+	if int(t) >= DamageTypeCount {
+		return "Unknown"
+	}
+	return DamageTypeNames[t]
+}
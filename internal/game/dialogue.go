@@ -0,0 +1,103 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DialogueDefs is the map of all dialogue trees.
+var DialogueDefs = map[string]*Dialogue{}
+
+// Dialogue is a tree of conversation nodes the player walks through when
+// talking to an actor.
+type Dialogue struct {
+	ID    string                   // Unique ID
+	Start string                   // ID of the node the conversation starts at
+	Nodes map[string]*DialogueNode // All nodes of the conversation by ID
+}
+
+// DialogueNode is a single line spoken by the actor and the responses the
+// player may give.
+type DialogueNode struct {
+	Text    string            // What the actor says, %NAME% is replaced with the actor's name
+	Options []*DialogueOption // Responses available to the player, the conversation ends if there are none
+}
+
+// DialogueOption is a single response the player may give.
+type DialogueOption struct {
+	Text       string   // What the player says
+	Next       string   // ID of the node that follows, empty ends the conversation
+	Attitude   Attitude // The option is only offered when the actor has this attitude
+	Reputation float64  // Change in reputation with the actor's faction when chosen
+	Trade      bool     // If true choosing this option opens trade with the actor
+}
+
+// Validate returns an error if the dialogue is malformed. This must be called
+// on all dialogues after loading is complete.
+func (d *Dialogue) Validate() error {
+	if _, found := d.Nodes[d.Start]; !found {
+		return fmt.Errorf("dialogue %s starts at non-existent node %s", d.ID, d.Start)
+	}
+	for id, n := range d.Nodes {
+		for _, o := range n.Options {
+			if o.Text == "" {
+				return fmt.Errorf("dialogue %s node %s has an option with no text", d.ID, id)
+			}
+			if o.Next == "" {
+				continue
+			}
+			if _, found := d.Nodes[o.Next]; !found {
+				return fmt.Errorf("dialogue %s node %s references non-existent node %s", d.ID, id, o.Next)
+			}
+		}
+	}
+	return nil
+}
+
+// Say returns the text of the node as spoken by actor a.
+func (n *DialogueNode) Say(a *Actor) string {
+	return strings.ReplaceAll(n.Text, "%NAME%", a.Name)
+}
+
+// DialogueOptions returns the options of the node available to the player
+// when talking to actor a.
+func (m *CityMap) DialogueOptions(n *DialogueNode, a *Actor) []*DialogueOption {
+	var ret []*DialogueOption
+	at := m.Attitude(a)
+	for _, o := range n.Options {
+		if o.Attitude == AttitudeAny || o.Attitude == at {
+			ret = append(ret, o)
+		}
+	}
+	return ret
+}
+
+// StartDialogue returns the first node of the conversation with actor a. On
+// failure nil is returned along with a complete, punctuated sentence
+// describing why the player can not talk to the actor.
+func (m *CityMap) StartDialogue(a *Actor) (*DialogueNode, string) {
+	if a.Dead {
+		return nil, "The dead tell no tales."
+	}
+	d, found := DialogueDefs[a.Dialogue]
+	if !found {
+		return nil, fmt.Sprintf("The %s has nothing to say.", a.Name)
+	}
+	if m.Attitude(a) == AttitudeHostile {
+		return nil, fmt.Sprintf("The %s is in no mood to talk.", a.Name)
+	}
+	return d.Nodes[d.Start], ""
+}
+
+// ChooseDialogueOption applies the effects of the player choosing the option
+// while talking to actor a and returns the node that follows, or nil if the
+// conversation is over.
+func (m *CityMap) ChooseDialogueOption(a *Actor, o *DialogueOption) *DialogueNode {
+	if a.Faction != "" {
+		m.AdjustReputation(a.Faction, o.Reputation)
+	}
+	if o.Next == "" || m.Attitude(a) == AttitudeHostile {
+		return nil
+	}
+	return DialogueDefs[a.Dialogue].Nodes[o.Next]
+}
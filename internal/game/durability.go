@@ -0,0 +1,257 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+func init() {
+	RegisterActivity("Repair", &ActivityKind{
+		Interrupts: InterruptAll,
+		Start:      startRepair,
+		Stop:       stopRepair,
+	})
+}
+
+// wearPenalty is the fraction of its function an item loses just short of
+// breaking.
+const wearPenalty float64 = 0.5
+
+// RepairKitDefs is the map of all repair kit definitions.
+var RepairKitDefs = map[string]*RepairKit{}
+
+// RepairKit describes the materials and tools needed to repair a class of
+// items.
+type RepairKit struct {
+	ID     string         // Unique ID
+	Name   string         // Descriptive name
+	Inputs map[string]int // Item templates and amounts consumed by each repair
+	Tools  []string       // Item templates that must be at hand but are not consumed
+	Time   util.Duration  // Time each repair takes
+	Repair float64        // Amount of wear removed by each repair
+}
+
+// Validate returns an error if the repair kit references items that do not
+// exist or is otherwise malformed. This must be called on all repair kits after
+// item loading is complete.
+func (k *RepairKit) Validate() error {
+	if k.Repair <= 0 {
+		return fmt.Errorf("repair kit %s does not repair anything", k.ID)
+	}
+	if k.Time < 0 {
+		return fmt.Errorf("repair kit %s has a negative time", k.ID)
+	}
+	for tid, n := range k.Inputs {
+		if _, found := ItemDefs[tid]; !found {
+			return fmt.Errorf("repair kit %s input references non-existent item %s", k.ID, tid)
+		}
+		if n < 1 {
+			return fmt.Errorf("repair kit %s input %s amount must be at least one", k.ID, tid)
+		}
+	}
+	for _, tid := range k.Tools {
+		if _, found := ItemDefs[tid]; !found {
+			return fmt.Errorf("repair kit %s tool references non-existent item %s", k.ID, tid)
+		}
+	}
+	return nil
+}
+
+// Missing returns a description of each requirement of the repair kit the
+// player does not currently meet. An empty slice means repairs may be made.
+func (k *RepairKit) Missing(m *CityMap) []string {
+	r := Recipe{
+		ID:     k.ID,
+		Name:   k.Name,
+		Inputs: k.Inputs,
+		Tools:  k.Tools,
+	}
+	return r.Missing(m)
+}
+
+// ValidateDurability returns an error if the item references a repair kit
+// that does not exist. This must be called on all items after repair kit
+// loading is complete.
+func (i *Item) ValidateDurability() error {
+	if i.Durability < 0 {
+		return fmt.Errorf("item %s has negative durability", i.TemplateID)
+	}
+	if i.RepairKit == "" {
+		return nil
+	}
+	if i.Durability == 0 {
+		return fmt.Errorf("item %s has a repair kit but never wears", i.TemplateID)
+	}
+	if _, found := RepairKitDefs[i.RepairKit]; !found {
+		return fmt.Errorf("item %s references non-existent repair kit %s", i.TemplateID, i.RepairKit)
+	}
+	return nil
+}
+
+// Degrade wears the item down by d units of use. Items without a durability
+// never wear from use. Returns true if this broke the item.
+func (i *Item) Degrade(d float64) bool {
+	if i.Durability <= 0 || i.Broken() {
+		return false
+	}
+	i.Wear = min(i.Wear+d/i.Durability, 1)
+	return i.Broken()
+}
+
+// Broken returns true if the item is worn out and no longer functions.
+func (i *Item) Broken() bool {
+	return i.Durability > 0 && i.Wear >= 1
+}
+
+// Effectiveness returns the fraction of its function the item retains given
+// its wear, zero if broken.
+func (i *Item) Effectiveness() float64 {
+	if i.Broken() {
+		return 0
+	}
+	return 1 - i.Wear*wearPenalty
+}
+
+// DamageState returns a word describing the wear on the item, or the empty
+// string if the item is like new or never wears.
+func (i *Item) DamageState() string {
+	switch {
+	case i.Durability <= 0 || i.Wear < 0.1:
+		return ""
+	case i.Wear < 0.4:
+		return "worn"
+	case i.Wear < 0.7:
+		return "damaged"
+	case i.Wear < 1:
+		return "badly damaged"
+	default:
+		return "broken"
+	}
+}
+
+// repairSource is an item the player is able to repair.
+type repairSource struct {
+	item *Item            // The item
+	v    *Vehicle         // Vehicle the item is a part of, if any
+	l    *VehicleLocation // Location of the vehicle the item is attached to, if any
+}
+
+// repairSources returns all worn items the player could repair. These are the
+// player's equipment and inventory and the parts of vehicles within reach.
+func (m *CityMap) repairSources() []repairSource {
+	var ret []repairSource
+	add := func(i *Item, v *Vehicle, l *VehicleLocation) {
+		if i != nil && i.RepairKit != "" && i.Wear > 0 {
+			ret = append(ret, repairSource{item: i, v: v, l: l})
+		}
+	}
+	a := &m.Player.Actor
+	add(a.Weapon, nil, nil)
+	for _, i := range a.WornItems {
+		add(i, nil, nil)
+	}
+	for _, i := range a.Inventory {
+		add(i, nil, nil)
+	}
+	b := util.NewRectFromRadius(a.Position, 1)
+	// VehiclesWithin re-uses its return slice
+	for _, v := range slices.Clone(m.VehiclesWithin(b)) {
+		o := b.Overlap(v.Bounds)
+		for p := o.TL; p.Y <= o.BR.Y; p.Y++ {
+			for p.X = o.TL.X; p.X <= o.BR.X; p.X++ {
+				l := v.GetLocationAbsolute(p)
+				for _, i := range l.Parts {
+					add(i, v, l)
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// repairTarget returns the most worn item of the template the player could
+// repair.
+func (m *CityMap) repairTarget(tid string) (repairSource, bool) {
+	var ret repairSource
+	found := false
+	for _, s := range m.repairSources() {
+		if s.item.TemplateID == tid && (!found || s.item.Wear > ret.item.Wear) {
+			ret = s
+			found = true
+		}
+	}
+	return ret, found
+}
+
+// RepairableItems returns the most worn item of each template the player could
+// repair, ordered by name.
+func (m *CityMap) RepairableItems() []*Item {
+	var ret []*Item
+	for _, s := range m.repairSources() {
+		if t, _ := m.repairTarget(s.item.TemplateID); t.item == s.item {
+			ret = append(ret, s.item)
+		}
+	}
+	slices.SortStableFunc(ret, func(a, b *Item) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return ret
+}
+
+// Repair has the player repair the item as an activity, calling update after
+// every step. Repairs always go to the most worn item of the same template.
+// Materials are consumed only if the repair can still be made once the time
+// has passed. Returns true on success.
+func (m *CityMap) Repair(i *Item, update func()) bool {
+	k, found := RepairKitDefs[i.RepairKit]
+	if !found {
+		Log.Log(termui.ColorYellow, "You do not know how to repair the %s.", i.Name)
+		return false
+	}
+	a := NewActivity("Repair", "repairing the "+i.Name, time.Duration(k.Time))
+	a.SArg = i.TemplateID
+	return m.StartActivity(a, update)
+}
+
+// startRepair checks that the player has everything needed to repair the item
+// of the activity.
+func startRepair(m *CityMap, a *Activity) string {
+	s, found := m.repairTarget(a.SArg)
+	if !found {
+		return "You have nothing there that needs repair."
+	}
+	k := RepairKitDefs[s.item.RepairKit]
+	if missing := k.Missing(m); len(missing) > 0 {
+		return fmt.Sprintf("To repair the %s you need %s.", s.item.Name, strings.Join(missing, ", "))
+	}
+	return ""
+}
+
+// stopRepair finishes repairing the item of the activity if it was completed.
+func stopRepair(m *CityMap, a *Activity, completed bool) bool {
+	if !completed {
+		return false
+	}
+	s, found := m.repairTarget(a.SArg)
+	if !found || len(RepairKitDefs[s.item.RepairKit].Missing(m)) > 0 {
+		Log.Log(termui.ColorYellow, "You were unable to finish the repairs.")
+		return false
+	}
+	k := RepairKitDefs[s.item.RepairKit]
+	m.consumeInputs(k.Inputs)
+	s.item.Wear = max(s.item.Wear-k.Repair, 0)
+	if s.l != nil {
+		s.l.UpdateFlags()
+		m.FlagBitmapsForVehicle(s.v, s.v.Bounds)
+	}
+	if s.item == m.Player.Weapon {
+		m.Player.recalculateDamage()
+	}
+	Log.Log(termui.ColorLime, "You repair the %s.", s.item.Name)
+	return true
+}
@@ -0,0 +1,122 @@
+package game_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/qbradq/after/internal/game"
+)
+
+func TestDegrade(t *testing.T) {
+	i := game.NewItem("Crowbar", fixtureTime, false)
+	for _, c := range []struct {
+		use   float64
+		state string
+		eff   float64
+		broke bool
+	}{
+		{50, "", 0.975, false},
+		{250, "worn", 0.85, false},
+		{300, "damaged", 0.7, false},
+		{300, "badly damaged", 0.55, false},
+		{200, "broken", 0, true},
+		{100, "broken", 0, false},
+	} {
+		if broke := i.Degrade(c.use); broke != c.broke {
+			t.Fatalf("degrading to wear %f reported broken %v", i.Wear, broke)
+		}
+		if s := i.DamageState(); s != c.state {
+			t.Fatalf("wear %f described as %q", i.Wear, s)
+		}
+		if e := i.Effectiveness(); math.Abs(e-c.eff) > 1e-9 {
+			t.Fatalf("wear %f left effectiveness %f", i.Wear, e)
+		}
+	}
+	// Items without durability never wear
+	b := game.NewItem("Brick", fixtureTime, false)
+	if b.Degrade(1000) || b.Wear != 0 || b.Broken() || b.DamageState() != "" {
+		t.Fatal("item without durability wore from use")
+	}
+}
+
+func TestRepair(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	log := &lastLog{}
+	game.Log = log
+	m := newQuietCity(t, 1)
+	m.Player.Inventory = nil
+	give := func(tid string) *game.Item {
+		i := game.NewItem(tid, m.Now, false)
+		m.Player.AddItemToInventory(i)
+		return i
+	}
+	worn := give("Crowbar")
+	worn.Wear = 0.75
+	fresh := give("Crowbar")
+	fresh.Wear = 0.25
+	if got := m.RepairableItems(); !slices.Equal(got, []*game.Item{worn}) {
+		t.Fatalf("repairable items are %v", got)
+	}
+	if m.Repair(fresh, nil) {
+		t.Fatal("repaired without materials")
+	}
+	give("ScrapMetal")
+	give("DuctTape")
+	give("Toolbox")
+	start := m.Now
+	// Repairs go to the most worn item of the template
+	if !m.Repair(fresh, nil) {
+		t.Fatalf("repair failed: %s", log.line)
+	}
+	if d := m.Now.Sub(start); d != time.Minute*30 {
+		t.Fatalf("repair took %v", d)
+	}
+	if worn.Wear != 0.25 || fresh.Wear != 0.25 {
+		t.Fatalf("repair left wear at %f and %f", worn.Wear, fresh.Wear)
+	}
+	for _, i := range m.Player.Inventory {
+		if i.TemplateID == "ScrapMetal" || i.TemplateID == "DuctTape" {
+			t.Fatalf("repair did not consume the %s", i.Name)
+		}
+	}
+}
+
+func TestArmorWithoutDurabilityNeverWears(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	a := game.NewActor("Zombie", fixtureTime, false)
+	j := game.NewItem("LeatherJacket", fixtureTime, false)
+	j.Durability = 0
+	j.Coverage = 1
+	if s := a.WearItem(j); s != "" {
+		t.Fatal(s)
+	}
+	for n := 0; n < 100; n++ {
+		a.BodyParts[game.BodyPartBody].Health = 1
+		a.TargetedDamage(game.BodyPartBody, game.DamageTypeCut, 0.2, 0.2, fixtureTime, a)
+	}
+	if j.Wear != 0 || j.Broken() || j.DamageState() != "" {
+		t.Fatalf("armor without durability wore to %f", j.Wear)
+	}
+	if v := j.ArmorValue(game.DamageTypeCut); v != 0.5 {
+		t.Fatalf("armor without durability absorbs %f of cut damage", v)
+	}
+}
+
+func TestEnginePower(t *testing.T) {
+	v := &game.Vehicle{Locations: []game.VehicleLocation{{Parts: []*game.Item{{Name: "seat"}}}}}
+	if p := v.EnginePower(); p != 1 {
+		t.Fatalf("vehicle without engine parts has power %f", p)
+	}
+	engine := &game.Item{Name: "engine", VehicleEngine: true, Durability: 10}
+	v.Locations[0].Parts = append(v.Locations[0].Parts, engine)
+	if p := v.EnginePower(); p != 1 {
+		t.Fatalf("new engine has power %f", p)
+	}
+	engine.Wear = 1
+	if p := v.EnginePower(); p != 0 {
+		t.Fatalf("broken engine has power %f", p)
+	}
+}
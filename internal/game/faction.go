@@ -0,0 +1,188 @@
+package game
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// FactionDefs is the map of all faction definitions.
+var FactionDefs = map[string]*FactionDef{}
+
+// Reputation thresholds and changes.
+const (
+	ReputationHostile  float64 = -0.25 // Reputation below which members of a faction are hostile to the player
+	ReputationFriendly float64 = 0.25  // Reputation at or above which members of a faction are friendly to the player
+	reputationAttack   float64 = 0.75  // Reputation lost with a faction when the player attacks one of its members
+)
+
+// Attitude describes how an actor feels about the player.
+type Attitude uint8
+
+const (
+	AttitudeAny      Attitude = 0 // Matches any attitude, never the attitude of an actor
+	AttitudeHostile  Attitude = 1 // Attacks the player on sight
+	AttitudeNeutral  Attitude = 2 // Leaves the player alone
+	AttitudeFriendly Attitude = 3 // Happy to help the player
+)
+
+func (a *Attitude) UnmarshalJSON(in []byte) error {
+	switch strings.ToLower(string(in[1 : len(in)-1])) {
+	case "", "any":
+		*a = AttitudeAny
+	case "hostile":
+		*a = AttitudeHostile
+	case "neutral":
+		*a = AttitudeNeutral
+	case "friendly":
+		*a = AttitudeFriendly
+	default:
+		return fmt.Errorf("unknown attitude %s", string(in))
+	}
+	return nil
+}
+
+// String returns the descriptive name of the attitude.
+func (a Attitude) String() string {
+	switch a {
+	case AttitudeHostile:
+		return "hostile"
+	case AttitudeNeutral:
+		return "neutral"
+	case AttitudeFriendly:
+		return "friendly"
+	}
+	return "any"
+}
+
+// FactionDef defines a group of actors that share a reputation with the
+// player.
+type FactionDef struct {
+	ID         string   // Unique ID
+	Name       string   // Descriptive name, completes the sentence "The ... are hostile."
+	Reputation float64  // Starting reputation of the player with the faction from -1 to 1
+	Enemies    []string // IDs of the factions members of this faction always fight
+}
+
+// Validate returns an error if the faction definition is malformed. This must
+// be called on all factions after loading is complete.
+func (f *FactionDef) Validate() error {
+	if f.Name == "" {
+		return fmt.Errorf("faction %s has no name", f.ID)
+	}
+	if f.Reputation < -1 || f.Reputation > 1 {
+		return fmt.Errorf("faction %s starting reputation out of range", f.ID)
+	}
+	for _, e := range f.Enemies {
+		if _, found := FactionDefs[e]; !found {
+			return fmt.Errorf("faction %s references non-existent enemy faction %s", f.ID, e)
+		}
+	}
+	return nil
+}
+
+// readReputations reads the table of player reputations from r.
+func readReputations(r io.Reader) (map[string]float64, error) {
+	ret := map[string]float64{}
+	n := int(util.GetUint16(r))
+	for i := 0; i < n; i++ {
+		id := util.GetString(r) // Faction ID
+		v := util.GetFloat(r)   // Reputation
+		if _, found := FactionDefs[id]; !found {
+			if !Repair {
+				return nil, fmt.Errorf("reference to non-existent faction %s", id)
+			}
+			LogRepair("dropped reputation with unknown faction %s", id)
+			continue
+		}
+		ret[id] = v
+	}
+	return ret, nil
+}
+
+// writeReputations writes the table of player reputations to w.
+func writeReputations(w io.Writer, reps map[string]float64) {
+	keys := make([]string, 0, len(reps))
+	for k := range reps {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	util.PutUint16(w, uint16(len(keys)))
+	for _, k := range keys {
+		util.PutString(w, k)      // Faction ID
+		util.PutFloat(w, reps[k]) // Reputation
+	}
+}
+
+// Reputation returns the player's reputation with the faction from -1 to 1.
+func (m *CityMap) Reputation(faction string) float64 {
+	if v, found := m.Reputations[faction]; found {
+		return v
+	}
+	if f, found := FactionDefs[faction]; found {
+		return f.Reputation
+	}
+	return 0
+}
+
+// AdjustReputation changes the player's reputation with the faction by d. The
+// player is told when the faction's attitude toward them changes.
+func (m *CityMap) AdjustReputation(faction string, d float64) {
+	f, found := FactionDefs[faction]
+	if !found || d == 0 {
+		return
+	}
+	old := reputationAttitude(m.Reputation(faction))
+	m.Reputations[faction] = min(max(m.Reputation(faction)+d, -1), 1)
+	if a := reputationAttitude(m.Reputations[faction]); a != old {
+		c := termui.ColorYellow
+		switch a {
+		case AttitudeHostile:
+			c = termui.ColorRed
+		case AttitudeFriendly:
+			c = termui.ColorLime
+		}
+		Log.Log(c, "The %s are now %s toward you.", f.Name, a)
+	}
+}
+
+// reputationAttitude returns the attitude that goes with the reputation.
+func reputationAttitude(r float64) Attitude {
+	if r < ReputationHostile {
+		return AttitudeHostile
+	}
+	if r >= ReputationFriendly {
+		return AttitudeFriendly
+	}
+	return AttitudeNeutral
+}
+
+// Attitude returns the attitude of the actor toward the player. Actors that do
+// not belong to a faction are always hostile.
+func (m *CityMap) Attitude(a *Actor) Attitude {
+	if a.Faction == "" {
+		return AttitudeHostile
+	}
+	return reputationAttitude(m.Reputation(a.Faction))
+}
+
+// Hostile returns true if actors a and b will fight each other.
+func (m *CityMap) Hostile(a, b *Actor) bool {
+	switch {
+	case a == b:
+		return false
+	case a.IsPlayer:
+		return m.Attitude(b) == AttitudeHostile
+	case b.IsPlayer:
+		return m.Attitude(a) == AttitudeHostile
+	case a.Faction == "" || b.Faction == "":
+		return a.Faction != b.Faction
+	}
+	fa := FactionDefs[a.Faction]
+	fb := FactionDefs[b.Faction]
+	return slices.Contains(fa.Enemies, b.Faction) || slices.Contains(fb.Enemies, a.Faction)
+}
@@ -0,0 +1,92 @@
+package game_test
+
+import (
+	"testing"
+
+	"github.com/qbradq/after/internal/game"
+)
+
+func TestFactionAttitudes(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	log := &lastLog{}
+	game.Log = log
+	m := &game.CityMap{Reputations: map[string]float64{}}
+	survivor := game.NewActor("Survivor", fixtureTime, false)
+	scavenger := game.NewActor("Scavenger", fixtureTime, false)
+	raider := game.NewActor("Raider", fixtureTime, false)
+	zombie := game.NewActor("Zombie", fixtureTime, false)
+	for _, c := range []struct {
+		a    *game.Actor
+		want game.Attitude
+	}{
+		{survivor, game.AttitudeFriendly},
+		{scavenger, game.AttitudeNeutral},
+		{raider, game.AttitudeHostile},
+		{zombie, game.AttitudeHostile},
+	} {
+		if got := m.Attitude(c.a); got != c.want {
+			t.Errorf("%s is %s toward the player, expected %s", c.a.TemplateID, got, c.want)
+		}
+	}
+	for _, c := range []struct {
+		a, b *game.Actor
+		want bool
+	}{
+		{survivor, raider, true},
+		{raider, survivor, true},
+		{survivor, scavenger, false},
+		{zombie, scavenger, true},
+		{zombie, zombie, false},
+	} {
+		if got := m.Hostile(c.a, c.b); got != c.want {
+			t.Errorf("%s hostile to %s is %v", c.a.TemplateID, c.b.TemplateID, got)
+		}
+	}
+	// Reputation is clamped and changes of attitude are announced
+	m.AdjustReputation("Survivors", -2)
+	if r := m.Reputation("Survivors"); r != -1 {
+		t.Fatalf("reputation fell to %f", r)
+	}
+	if log.line != "The survivors are now hostile toward you." {
+		t.Fatalf("attitude change logged %q", log.line)
+	}
+}
+
+func TestDialogue(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	m := &game.CityMap{Reputations: map[string]float64{}}
+	a := game.NewActor("Survivor", fixtureTime, false)
+	n, reason := m.StartDialogue(a)
+	if n == nil {
+		t.Fatalf("can not talk to a friendly survivor: %s", reason)
+	}
+	// Friendly-only options are offered to friends
+	if got := len(m.DialogueOptions(n, a)); got != 5 {
+		t.Fatalf("friendly survivor offers %d options", got)
+	}
+	// Threatening the survivor twice makes them hostile and ends the
+	// conversation
+	var threat *game.DialogueOption
+	for _, o := range n.Options {
+		if o.Next == "Threat" {
+			threat = o
+		}
+	}
+	for i := 0; i < 2; i++ {
+		n = m.ChooseDialogueOption(a, threat)
+		if n == nil {
+			t.Fatal("threat ended the conversation")
+		}
+		n = m.ChooseDialogueOption(a, n.Options[1])
+		if n != nil {
+			t.Fatal("conversation continued after the threat")
+		}
+	}
+	if at := m.Attitude(a); at != game.AttitudeHostile {
+		t.Fatalf("threatened survivor is %s", at)
+	}
+	if n, _ := m.StartDialogue(a); n != nil {
+		t.Fatal("hostile survivor agreed to talk")
+	}
+}
@@ -3,16 +3,11 @@
 package game
 
 import (
-	"os"
 	"time"
 
 	"github.com/qbradq/after/lib/termui"
 )
 
-func init() {
-	os.MkdirAll("saves", 0664)
-}
-
 // GetChunkGen is the ChunkGen getter.
 var GetChunkGen func(string, string) ChunkGen
 
@@ -0,0 +1,280 @@
+package game
+
+import (
+	"container/heap"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/qbradq/after/lib/util"
+)
+
+// Horde simulation parameters.
+const (
+	hordeStep         time.Duration = time.Minute // Interval between horde simulation steps
+	hordeInterest     time.Duration = time.Hour   // How long a horde pursues a noise it heard
+	hordeMaxSize      int           = 24          // Maximum number of members in one horde
+	hordeHearingScale int           = 4           // Hordes hear noises this many times farther away than individual actors
+	hordePlaceTries   int           = 16          // Number of random positions tried when materializing each member
+	hordeNightStart   int           = 20          // Hour of the day hordes become restless
+	hordeNightEnd     int           = 6           // Hour of the day hordes settle down
+	hordeWanderDay    float64       = 0.02        // Chance per step an idle horde wanders during the day
+	hordeWanderNight  float64       = 0.25        // Chance per step an idle horde wanders during the night
+)
+
+// Horde is a group of roaming actors tracked abstractly on the city map while
+// they are outside of the update radius. Actors with the Horde flag are
+// absorbed into hordes when their chunk leaves the update radius or unloads,
+// and hordes materialize back into actors when they are within the update
+// radius.
+type Horde struct {
+	Position util.Point // Position of the horde on the city map in chunks, see LevelOf
+	Members  []*Actor   // All members, positions are meaningless until materialized
+	Target   util.Point // Position of the noise being pursued in tiles
+	Until    time.Time  // Time the horde loses interest in Target
+}
+
+// readHordes reads the list of hordes from r.
+func readHordes(r io.Reader) ([]*Horde, error) {
+	var ret []*Horde
+	n := int(util.GetUint32(r))
+	for i := 0; i < n; i++ {
+		h := &Horde{
+			Position: util.GetPoint(r), // Position
+			Target:   util.GetPoint(r), // Noise target
+			Until:    util.GetTime(r),  // Interest time
+		}
+		h.Members = make([]*Actor, util.GetUint16(r)) // Members
+		for j := range h.Members {
+			a, err := NewActorFromReader(r)
+			if err != nil {
+				return nil, err
+			}
+			h.Members[j] = a
+		}
+		ret = append(ret, h)
+	}
+	return ret, nil
+}
+
+// readHordeTemplates reads the list of hordes from r in the layout used before
+// the state of members was saved, when only their template IDs were. Members
+// are created fresh at time now.
+func readHordeTemplates(r io.Reader, now time.Time) ([]*Horde, error) {
+	var ret []*Horde
+	n := int(util.GetUint32(r))
+	for i := 0; i < n; i++ {
+		h := &Horde{
+			Position: util.GetPoint(r), // Position
+			Target:   util.GetPoint(r), // Noise target
+			Until:    util.GetTime(r),  // Interest time
+		}
+		mc := int(util.GetUint16(r))
+		for j := 0; j < mc; j++ { // Members
+			id := util.GetString(r)
+			if _, found := ActorDefs[id]; !found {
+				if !Repair {
+					return nil, fmt.Errorf("horde references non-existent actor template %s", id)
+				}
+				LogRepair("dropped unknown horde member %s", id)
+				continue
+			}
+			h.Members = append(h.Members, NewActor(id, now, true))
+		}
+		if len(h.Members) > 0 {
+			ret = append(ret, h)
+		}
+	}
+	return ret, nil
+}
+
+// writeHordes writes the list of hordes to w.
+func writeHordes(w io.Writer, hordes []*Horde) {
+	util.PutUint32(w, uint32(len(hordes)))
+	for _, h := range hordes {
+		util.PutPoint(w, h.Position)              // Position
+		util.PutPoint(w, h.Target)                // Noise target
+		util.PutTime(w, h.Until)                  // Interest time
+		util.PutUint16(w, uint16(len(h.Members))) // Members
+		for _, a := range h.Members {
+			a.Write(w)
+		}
+	}
+}
+
+// inUpdateSet returns true if the chunk at the given city map position is
+// within the current update set.
+func (m *CityMap) inUpdateSet(p util.Point) bool {
+	_, found := m.updateSet[p.Y*CityMapWidth+p.X]
+	return found
+}
+
+// absorbActors removes all living horde actors from the chunk and adds them to
+// the horde at the chunk's position. The chunk must not be in the update set.
+func (m *CityMap) absorbActors(c *Chunk) {
+	var members []*Actor
+	actors := c.Actors[:0]
+	for _, a := range c.Actors {
+		if a.Horde && !a.IsPlayer && !a.Dead {
+			members = append(members, a)
+			continue
+		}
+		actors = append(actors, a)
+	}
+	if len(members) == 0 {
+		return
+	}
+	clear(c.Actors[len(actors):])
+	c.Actors = actors
+	c.bitmapsDirty = true
+	for len(members) > 0 {
+		h := m.hordeAt(c.Position)
+		if h == nil {
+			h = &Horde{Position: c.Position}
+			m.Hordes = append(m.Hordes, h)
+		}
+		n := min(hordeMaxSize-len(h.Members), len(members))
+		h.Members = append(h.Members, members[:n]...)
+		members = members[n:]
+	}
+}
+
+// level returns the level the horde is on.
+func (h *Horde) level() int {
+	return LevelOf(h.Position.Multiply(ChunkWidth))
+}
+
+// hordeAt returns a horde at the given city map position that has room for
+// more members, or nil if there is none.
+func (m *CityMap) hordeAt(p util.Point) *Horde {
+	for _, h := range m.Hordes {
+		if h.Position == p && len(h.Members) < hordeMaxSize {
+			return h
+		}
+	}
+	return nil
+}
+
+// materializeHorde places the members of the horde as actors within its chunk.
+// Members are never placed where the player can see them. Members that could
+// not be placed remain in the horde.
+func (m *CityMap) materializeHorde(h *Horde) {
+	if !m.stackBounds.Contains(h.Position) {
+		return
+	}
+	c := m.chunkByRef(chunkRefForPoint(h.Position))
+	if c.Tiles == nil {
+		return
+	}
+	var left []*Actor
+	for _, a := range h.Members {
+		placed := false
+		for i := 0; i < hordePlaceTries; i++ {
+			a.Position = util.RandomPoint(c.Bounds)
+			if a.Position.Distance(m.Player.Position) <= m.SightRange(&m.Player.Actor, a.Position) &&
+				m.CanSeePlayerFrom(a.Position) {
+				continue
+			}
+			if cw, _ := c.PlaceActor(a, false, m); cw {
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			left = append(left, a)
+			continue
+		}
+		if a.NextThink.Before(m.Now) {
+			a.NextThink = m.Now
+		}
+		heap.Push(&m.aq, a)
+		if h.Until.After(m.Now) {
+			a.AIModel.Heard(a, m, h.Target, 1)
+		}
+	}
+	h.Members = left
+}
+
+// materializeHordes materializes all hordes within the update set and removes
+// hordes that have no members left.
+func (m *CityMap) materializeHordes() {
+	hordes := m.Hordes[:0]
+	for _, h := range m.Hordes {
+		if m.inUpdateSet(h.Position) {
+			m.materializeHorde(h)
+		}
+		if len(h.Members) > 0 {
+			hordes = append(hordes, h)
+		}
+	}
+	clear(m.Hordes[len(hordes):])
+	m.Hordes = hordes
+}
+
+// hordesHear alerts all hordes within hearing range of a noise of the given
+// loudness made at p. Noises carry between levels.
+func (m *CityMap) hordesHear(p util.Point, loudness int) {
+	r := loudness * hordeHearingScale
+	for _, h := range m.Hordes {
+		if h.Position.Multiply(ChunkWidth).Add(util.NewPoint(ChunkWidth/2, ChunkHeight/2)).Distance(OnLevel(p, h.level())) > r {
+			continue
+		}
+		h.Target = p
+		h.Until = m.Now.Add(hordeInterest)
+	}
+}
+
+// updateHordes advances the horde simulation up to the current time.
+func (m *CityMap) updateHordes() {
+	if m.hordeNext.IsZero() {
+		m.hordeNext = m.Now.Add(hordeStep)
+	}
+	for !m.Now.Before(m.hordeNext) {
+		m.stepHordes(m.hordeNext)
+		m.hordeNext = m.hordeNext.Add(hordeStep)
+	}
+	m.materializeHordes()
+}
+
+// stepHordes executes one step of the horde simulation at time t. Hordes
+// pursuing a noise move one chunk toward it on their own level. Idle hordes
+// wander, mostly at night. Hordes sharing a chunk merge when they have room.
+func (m *CityMap) stepHordes(t time.Time) {
+	wander := hordeWanderDay
+	if hr := t.Hour(); hr >= hordeNightStart || hr < hordeNightEnd {
+		wander = hordeWanderNight
+	}
+	for _, h := range m.Hordes {
+		np := h.Position
+		lb := LevelBounds(h.level()).Divide(ChunkWidth)
+		if h.Until.After(t) {
+			tp := OnLevel(h.Target, h.level()).Divide(ChunkWidth)
+			if tp == h.Position {
+				continue
+			}
+			np = h.Position.Step(h.Position.DirectionTo(tp))
+		} else if util.RandomF(0, 1) < wander {
+			np = h.Position.Add(util.RandomValue(util.DirectionOffsets))
+		}
+		if lb.Contains(np) {
+			h.Position = np
+		}
+	}
+	// Merge hordes
+	byPos := map[util.Point]*Horde{}
+	hordes := m.Hordes[:0]
+	for _, h := range m.Hordes {
+		if o := byPos[h.Position]; o != nil && len(o.Members)+len(h.Members) <= hordeMaxSize {
+			o.Members = append(o.Members, h.Members...)
+			if h.Until.After(o.Until) {
+				o.Target = h.Target
+				o.Until = h.Until
+			}
+			continue
+		}
+		byPos[h.Position] = h
+		hordes = append(hordes, h)
+	}
+	clear(m.Hordes[len(hordes):])
+	m.Hordes = hordes
+}
@@ -0,0 +1,122 @@
+package game_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/qbradq/after/internal/citygen"
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
+)
+
+func TestHordeMigration(t *testing.T) {
+	m := newQuietCity(t, 1)
+	util.WithSeed(1, func() {
+		home := m.Player.Position
+		hc := util.NewPoint(home.X/game.ChunkWidth, home.Y/game.ChunkHeight)
+		update := func(p util.Point) {
+			m.Player.Position = p
+			if err := m.Update(p, 0, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		z := game.NewActor("Zombie", m.Now, false)
+		z.Position = home.Add(util.NewPoint(-1, 0))
+		m.PlaceActor(z, false)
+		// Walk far enough away for the zombie to join a horde
+		far := home.Add(util.NewPoint(game.ChunkWidth*10, 0))
+		update(far)
+		var h *game.Horde
+		for _, o := range m.Hordes {
+			if o.Position == hc {
+				h = o
+			}
+		}
+		if h == nil || !slices.Contains(h.Members, z) {
+			t.Fatal("expected the zombie to join a horde")
+		}
+		if slices.Contains(m.GetChunk(home).Actors, z) {
+			t.Fatal("zombie remained in its chunk")
+		}
+		// The horde hears a gunshot from far away and heads toward it
+		m.MakeNoise(far, game.NoiseGunshot)
+		if h.Target != far {
+			t.Fatalf("horde is pursuing %v rather than the gunshot at %v", h.Target, far)
+		}
+		m.Now = m.Now.Add(time.Minute * 3)
+		update(far)
+		if want := hc.Add(util.NewPoint(3, 0)); h.Position != want {
+			t.Fatalf("horde is at %v after three minutes, expected %v", h.Position, want)
+		}
+		// Once within the update radius the horde becomes actors again
+		m.Now = m.Now.Add(time.Minute * 3)
+		update(far)
+		if len(h.Members) != 0 || slices.Contains(m.Hordes, h) {
+			t.Fatal("horde did not materialize within the update radius")
+		}
+		c := m.GetChunkFromMapPoint(hc.Add(util.NewPoint(6, 0)))
+		if !slices.ContainsFunc(c.Actors, func(a *game.Actor) bool { return a.TemplateID == "Zombie" }) {
+			t.Fatal("horde members were not placed in the horde's chunk")
+		}
+	})
+}
+
+func TestHordeKeepsMemberState(t *testing.T) {
+	if err := game.NewScratchSave(); err != nil {
+		t.Fatal(err)
+	}
+	defer game.CloseSave()
+	m, err := citygen.Generate("Interstate Town", "CombatTest", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Now = time.Date(2030, time.May, 1, 8, 0, 0, 0, time.UTC)
+	util.WithSeed(3, func() {
+		home := m.Player.Position
+		update := func(p util.Point) {
+			t.Helper()
+			m.Player.Position = p
+			if err := m.Update(p, 0, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		update(home)
+		var z *game.Actor
+		for _, a := range m.ActorsWithin(util.NewRectFromRadius(home, game.ChunkWidth*2)) {
+			if a.Horde {
+				z = a
+				break
+			}
+		}
+		if z == nil {
+			t.Fatal("no horde actor near the player")
+		}
+		z.BodyParts[game.BodyPartBody].Health = 0.25
+		z.Inventory = append(z.Inventory, game.NewItem("Crowbar", m.Now, false))
+		weapon := z.Weapon
+		// Walk far enough away for the zombie to join a horde
+		update(home.Add(util.NewPoint(game.ChunkWidth*10, 0)))
+		absorbed := false
+		for _, h := range m.Hordes {
+			absorbed = absorbed || slices.Contains(h.Members, z)
+		}
+		if !absorbed {
+			t.Fatal("expected the zombie to join a horde")
+		}
+		// Come back and meet the same zombie again
+		update(home)
+		if !slices.Contains(m.ActorsWithin(util.NewRectFromRadius(home, game.ChunkWidth*5)), z) {
+			t.Fatal("expected the zombie to leave the horde")
+		}
+		if z.BodyParts[game.BodyPartBody].Health != 0.25 {
+			t.Errorf("zombie healed to %f while in the horde", z.BodyParts[game.BodyPartBody].Health)
+		}
+		if len(z.Inventory) != 1 || z.Inventory[0].TemplateID != "Crowbar" {
+			t.Error("zombie lost its inventory while in the horde")
+		}
+		if z.Weapon != weapon {
+			t.Error("zombie was re-equipped while in the horde")
+		}
+	})
+}
@@ -28,32 +28,68 @@ type Item struct {
 	SArg       string     // Generic string argument
 	TArg       time.Time  // Generic time argument
 	Inventory  []*Item    // Container contents if any
+	Spoilage   float64    // Spoilage from zero (fresh) to one (rotten) and beyond
+	Wear       float64    // Wear from zero (new) to one (broken)
+	BashDamage float64    // Damage taken from being smashed
 
 	//
 	// Reconstructed values
 	//
 
-	Events          map[string]string // Map of event names to event handler names
-	Name            string            // Descriptive name
-	Rune            string            // Display rune
-	Fg              termui.Color      // Display foreground color
-	Bg              termui.Color      // Display background color
-	BlocksVis       bool              // If true this item blocks visibility
-	BlocksWalk      bool              // If true this item blocks walking
-	BlocksStack     bool              // If true this item blocks any other items being placed on that spot
-	Climbable       bool              // If true this item may be climbed over
-	Destroyed       bool              // If true something has happened to this item to cause it to be destroyed, it will be removed from the world at the end of the next update cycle
-	Stackable       bool              // If true this item can stack with others of the exact same template name
-	Fixed           bool              // If true the item cannot be moved at all
-	Wearable        bool              // If true this item can be worn as a piece of clothing
-	WornBodyPart    BodyPartCode      // Code of the body part this item is worn on
-	Weapon          bool              // If true this item can be wielded as a weapon
-	WeaponMinDamage float64           // Minimum damage bonus when using this item as a weapon
-	WeaponMaxDamage float64           // Maximum damage bonus when using this item as a weapon
-	WeaponSwingStam float64           // Amount of stamina required to swing this weapon
-	Container       bool              // If true this item contains other items
-	Contents        []string          // Container content item statements if any
-	VehicleSolid    bool              // If true this part prevents actors from standing on the part
+	Events          map[string]string      // Map of event names to event handler names
+	Name            string                 // Descriptive name
+	Rune            string                 // Display rune
+	Fg              termui.Color           // Display foreground color
+	Bg              termui.Color           // Display background color
+	BlocksVis       bool                   // If true this item blocks visibility
+	BlocksWalk      bool                   // If true this item blocks walking
+	BlocksStack     bool                   // If true this item blocks any other items being placed on that spot
+	Climbable       bool                   // If true this item may be climbed over
+	Destroyed       bool                   // If true something has happened to this item to cause it to be destroyed, it will be removed from the world at the end of the next update cycle
+	Stackable       bool                   // If true this item can stack with others of the exact same template name
+	Fixed           bool                   // If true the item cannot be moved at all
+	Wearable        bool                   // If true this item can be worn as a piece of clothing
+	WornBodyPart    BodyPartCode           // Code of the body part this item is worn on
+	Weapon          bool                   // If true this item can be wielded as a weapon
+	WeaponMinDamage float64                // Minimum damage bonus when using this item as a weapon
+	WeaponMaxDamage float64                // Maximum damage bonus when using this item as a weapon
+	WeaponSwingStam float64                // Amount of stamina required to swing this weapon
+	WeaponDamage    DamageType             // Type of damage dealt when using this item as a weapon
+	Armor           map[DamageType]float64 // Fraction of each type of damage absorbed when worn
+	Coverage        float64                // Chance the armor is in the way of a blow to the body part it covers
+	Warmth          float64                // Degrees Fahrenheit of warmth the item provides when worn
+	Heat            float64                // Degrees Fahrenheit of warmth the item gives off to those standing next to it
+	Light           int                    // Radius in tiles of the light the item casts when fixed in place, wielded, worn or part of a vehicle being driven
+	Stairs          int                    // Number of levels up, or down if negative, the stairs or ladder lead
+	Bash            *Bash                  // How the item stands up to being smashed when fixed, nil if it can not be smashed
+	Ranged          bool                   // If true this weapon attacks at range
+	Thrown          bool                   // If true this ranged weapon is itself thrown at the target
+	Range           int                    // Distance in tiles the ranged weapon is fully effective, it reaches twice as far with falloff
+	Accuracy        float64                // Chance to hit a target within range
+	Ammo            string                 // Type of ammunition this item is, if any
+	AmmoType        string                 // Type of ammunition this weapon or magazine is loaded with directly, if any
+	Capacity        int                    // Number of rounds this weapon or magazine holds
+	MagazineType    string                 // Template ID of the magazine this weapon is loaded with, if any
+	ReloadTime      util.Duration          // Time it takes to reload this weapon
+	Container       bool                   // If true this item contains other items
+	Contents        []string               // Container content item statements if any
+	VehicleSolid    bool                   // If true this part prevents actors from standing on the part
+	VehicleEngine   bool                   // If true this part powers the vehicle
+	VehicleShelter  bool                   // If true this part shelters its location from the weather
+	Calories        float64                // Amount of hunger restored when consumed
+	Hydration       float64                // Amount of thirst restored when consumed
+	ShelfLife       util.Duration          // Time it takes for the item to rot, zero if it never spoils
+	Sickness        float64                // Chance of food poisoning when consumed rotten
+	Effects         []string               // Status effects applied when used or consumed
+	Cures           []string               // Status effects removed when used or consumed
+	Comfort         float64                // Comfort of sleeping on or next to this item
+	Value           float64                // Value of a single item in perfect condition in dollars
+	Currency        bool                   // If true this item is money and always trades at face value
+	Stock           bool                   // If true this container holds goods for trade
+	CashOnly        bool                   // If true this trade stock only accepts currency in payment
+	RestockTime     util.Duration          // Time between restocks of the container's contents, zero never restocks
+	Durability      float64                // Use the item withstands before breaking: attacks for weapons, damage absorbed for armor and damage taken for vehicle parts, zero never wears
+	RepairKit       string                 // ID of the repair kit used to repair the item, empty if it can not be repaired
 
 	//
 	// Cache values
@@ -62,6 +98,68 @@ type Item struct {
 	csCache []ItemStatement // Content statements cache
 }
 
+// ValidateEffects returns an error if the item references status effects that
+// do not exist. This must be called on all items after status effect loading is
+// complete.
+func (i *Item) ValidateEffects() error {
+	for _, ids := range [][]string{i.Effects, i.Cures} {
+		for _, id := range ids {
+			if _, found := StatusEffectDefs[id]; !found {
+				return fmt.Errorf("item %s references non-existent status effect %s", i.TemplateID, id)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateWeapon returns an error if the ranged weapon, magazine or ammunition
+// properties of the item are inconsistent. This must be called on all items
+// after item loading is complete.
+func (i *Item) ValidateWeapon() error {
+	if i.AmmoType != "" && (!i.Container || i.Capacity < 1) {
+		return fmt.Errorf("item %s takes ammunition but is not a container with capacity", i.TemplateID)
+	}
+	if i.MagazineType != "" {
+		m, found := ItemDefs[i.MagazineType]
+		if !found {
+			return fmt.Errorf("item %s references non-existent magazine %s", i.TemplateID, i.MagazineType)
+		}
+		if !i.Container || m.AmmoType == "" {
+			return fmt.Errorf("item %s takes magazine %s which does not take ammunition", i.TemplateID, i.MagazineType)
+		}
+	}
+	if !i.Ranged {
+		return nil
+	}
+	if !i.Weapon || i.Range < 1 {
+		return fmt.Errorf("ranged item %s is not a weapon with range", i.TemplateID)
+	}
+	if !i.Thrown && i.AmmoType == "" && i.MagazineType == "" {
+		return fmt.Errorf("ranged weapon %s is not thrown and takes no ammunition", i.TemplateID)
+	}
+	return nil
+}
+
+// ValidateArmor returns an error if the armor properties of the item are out of
+// range. This must be called on all items after item loading is complete.
+func (i *Item) ValidateArmor() error {
+	if (len(i.Armor) > 0 || i.Coverage > 0) && !i.Wearable {
+		return fmt.Errorf("item %s has armor but is not wearable", i.TemplateID)
+	}
+	if i.Warmth != 0 && !i.Wearable {
+		return fmt.Errorf("item %s has warmth but is not wearable", i.TemplateID)
+	}
+	if i.Coverage < 0 || i.Coverage > 1 {
+		return fmt.Errorf("item %s has coverage %f outside of [0-1]", i.TemplateID, i.Coverage)
+	}
+	for t, v := range i.Armor {
+		if v < 0 || v > 1 {
+			return fmt.Errorf("item %s has %s armor %f outside of [0-1]", i.TemplateID, t, v)
+		}
+	}
+	return nil
+}
+
 // NewItem creates a new item from the named template.
 func NewItem(template string, now time.Time, genContents bool) *Item {
 	i, found := ItemDefs[template]
@@ -82,51 +180,207 @@ func NewItem(template string, now time.Time, genContents bool) *Item {
 
 // NewItemFromReader reads the item information from r and returns a new Item
 // with this information.
-func NewItemFromReader(r io.Reader) *Item {
-	_ = util.GetUint32(r)                 // Version
-	tid := util.GetString(r)              // Template ID
-	i := NewItem(tid, time.Time{}, false) // Create new object
-	i.Position = util.GetPoint(r)         // Map position
-	i.LastUpdate = util.GetTime(r)        // Time of last update
-	i.Amount = int(util.GetUint32(r))     // Stack amount
-	i.SArg = util.GetString(r)            // Generic string argument
-	i.TArg = util.GetTime(r)              // Generic time argument
-	n := int(util.GetUint16(r))           // Contents
-	i.Inventory = make([]*Item, n)
-	for idx := 0; idx < n; idx++ {
-		i.Inventory[idx] = NewItemFromReader(r)
-	}
-	return i
-}
-
-// Write writes the actor to the writer.
-func (i *Item) Write(w io.Writer) {
-	util.PutUint32(w, 0)                        // Version
-	util.PutString(w, i.TemplateID)             // Template ID
-	util.PutPoint(w, i.Position)                // Map position
-	util.PutTime(w, i.LastUpdate)               // Time of last update
-	util.PutUint32(w, uint32(i.Amount))         // Stack amount
-	util.PutString(w, i.SArg)                   // Generic string argument
-	util.PutTime(w, i.TArg)                     // Generic time argument
-	util.PutUint16(w, uint16(len(i.Inventory))) // Contents
-	for _, i := range i.Inventory {
-		i.Write(w)
+func NewItemFromReader(r io.Reader) (*Item, error) {
+	var i *Item
+	ver, err := ReadVersion(r, "item", itemVersion) // Version
+	if err != nil {
+		return nil, err
+	}
+	tid := util.GetString(r) // Template ID
+	if _, found := ItemDefs[tid]; found {
+		i = NewItem(tid, time.Time{}, false)
+	} else if Repair {
+		i = placeholderItem(tid)
+	} else {
+		return nil, fmt.Errorf("reference to non-existent item template %s", tid)
+	}
+	if err := itemLayout.read(i, r, ver); err != nil {
+		return nil, fmt.Errorf("item %s %w", tid, err)
 	}
+	return i, nil
+}
+
+// itemLayout is the layout of the item record following the version and
+// template ID. It is built in init as items contain items.
+var itemLayout recordLayout[*Item]
+
+func init() {
+	itemLayout = recordLayout[*Item]{
+		{0, "map position",
+			func(i *Item, r io.Reader) error { i.Position = util.GetPoint(r); return nil },
+			func(i *Item, w io.Writer) { util.PutPoint(w, i.Position) }, nil},
+		{0, "time of last update",
+			func(i *Item, r io.Reader) error { i.LastUpdate = util.GetTime(r); return nil },
+			func(i *Item, w io.Writer) { util.PutTime(w, i.LastUpdate) }, nil},
+		{0, "stack amount",
+			func(i *Item, r io.Reader) error { i.Amount = int(util.GetUint32(r)); return nil },
+			func(i *Item, w io.Writer) { util.PutUint32(w, uint32(i.Amount)) }, nil},
+		{0, "generic string argument",
+			func(i *Item, r io.Reader) error { i.SArg = util.GetString(r); return nil },
+			func(i *Item, w io.Writer) { util.PutString(w, i.SArg) }, nil},
+		{0, "generic time argument",
+			func(i *Item, r io.Reader) error { i.TArg = util.GetTime(r); return nil },
+			func(i *Item, w io.Writer) { util.PutTime(w, i.TArg) }, nil},
+		{1, "spoilage",
+			func(i *Item, r io.Reader) error { i.Spoilage = util.GetFloat(r); return nil },
+			func(i *Item, w io.Writer) { util.PutFloat(w, i.Spoilage) }, nil},
+		{2, "wear",
+			func(i *Item, r io.Reader) error { i.Wear = util.GetFloat(r); return nil },
+			func(i *Item, w io.Writer) { util.PutFloat(w, i.Wear) }, nil},
+		{3, "bash damage",
+			func(i *Item, r io.Reader) error { i.BashDamage = util.GetFloat(r); return nil },
+			func(i *Item, w io.Writer) { util.PutFloat(w, i.BashDamage) }, nil},
+		{0, "contents",
+			func(i *Item, r io.Reader) error {
+				i.Inventory = make([]*Item, util.GetUint16(r))
+				for idx := range i.Inventory {
+					item, err := NewItemFromReader(r)
+					if err != nil {
+						return err
+					}
+					i.Inventory[idx] = item
+				}
+				return nil
+			},
+			func(i *Item, w io.Writer) {
+				util.PutUint16(w, uint16(len(i.Inventory)))
+				for _, ci := range i.Inventory {
+					ci.Write(w)
+				}
+			}, nil},
+	}
+}
+
+// Write writes the item to the writer.
+func (i *Item) Write(w io.Writer) {
+	util.PutUint32(w, itemVersion)  // Version
+	util.PutString(w, i.TemplateID) // Template ID
+	itemLayout.write(i, w)
+}
+
+// Wieldable returns true if the item may be held in hand, either as a weapon
+// or as a light.
+func (i *Item) Wieldable() bool {
+	return i.Weapon || i.Light > 0
 }
 
 // DisplayName returns the string to display for this item in user-facing
 // displays.
 func (i *Item) DisplayName() string {
 	ret := i.Name
+	if s := i.DamageState(); s != "" {
+		ret = s + " " + ret
+	}
 	if i.Amount > 1 {
 		ret += " x" + strconv.FormatInt(int64(i.Amount), 10)
 	}
 	return ret
 }
 
+// Freshness returns a word describing how spoiled the item is, or the empty
+// string if the item does not spoil.
+func (i *Item) Freshness() string {
+	switch {
+	case i.ShelfLife <= 0:
+		return ""
+	case i.Spoilage < 0.5:
+		return "fresh"
+	case i.Spoilage < 1:
+		return "stale"
+	default:
+		return "rotten"
+	}
+}
+
+// Rotten returns true if the item has spoiled.
+func (i *Item) Rotten() bool {
+	return i.ShelfLife > 0 && i.Spoilage >= 1
+}
+
+// Spoil accumulates spoilage for all time that has passed since the item's last
+// update. This executes in constant time no matter how much time has passed.
+func (i *Item) Spoil(now time.Time) {
+	if i.ShelfLife > 0 && !i.LastUpdate.IsZero() && now.After(i.LastUpdate) {
+		i.Spoilage += float64(now.Sub(i.LastUpdate)) / float64(i.ShelfLife)
+	}
+	i.LastUpdate = now
+}
+
+// Condition returns the condition of the item from zero (worthless) to one
+// (perfect).
+func (i *Item) Condition() float64 {
+	ret := max(1-i.Wear, 0)
+	if i.ShelfLife > 0 {
+		ret = min(ret, max(1-i.Spoilage, 0))
+	}
+	return ret
+}
+
+// Restock replaces the goods within the container with freshly generated
+// contents each time the restock time passes. Currency is kept.
+func (i *Item) Restock(now time.Time) {
+	if i.RestockTime <= 0 || (!i.TArg.IsZero() && now.Before(i.TArg)) {
+		return
+	}
+	if !i.TArg.IsZero() {
+		inv := i.Inventory[:0]
+		for _, c := range i.Inventory {
+			if c.Currency {
+				inv = append(inv, c)
+			}
+		}
+		clear(i.Inventory[len(inv):])
+		i.Inventory = inv
+		for _, s := range i.csCache {
+			for _, item := range s.Evaluate(now) {
+				i.AddItem(item)
+			}
+		}
+	}
+	i.TArg = now.Add(time.Duration(i.RestockTime))
+}
+
+// Split removes n items from the stack and returns them as a new stack. The
+// item itself is returned if n is the whole stack.
+func (i *Item) Split(n int) *Item {
+	if n >= i.StackAmount() {
+		return i
+	}
+	ret := *i
+	ret.Amount = n
+	i.Amount -= n
+	return &ret
+}
+
+// StackAmount returns the number of items in the stack.
+func (i *Item) StackAmount() int {
+	if i.Amount < 1 {
+		return 1
+	}
+	return i.Amount
+}
+
+// absorb adds the stack of item to this stack and destroys item. Spoilage is
+// averaged over both stacks.
+func (i *Item) absorb(item *Item) {
+	a, b := i.StackAmount(), item.StackAmount()
+	if i.ShelfLife > 0 {
+		i.Spoilage = (i.Spoilage*float64(a) + item.Spoilage*float64(b)) / float64(a+b)
+	}
+	i.Amount = a + b
+	item.Destroyed = true
+}
+
 // UIDisplayName returns the string to display in UIs like the inventory.
 func (i *Item) UIDisplayName() string {
 	ret := i.DisplayName()
+	if f := i.Freshness(); f != "" {
+		ret += " (" + f + ")"
+	}
+	if i.AmmoType != "" || i.MagazineType != "" {
+		n, c := i.Rounds()
+		ret += fmt.Sprintf(" [%d/%d]", n, c)
+	}
 	if i.Container {
 		if len(i.Inventory) > 0 {
 			return "+" + ret
@@ -143,14 +397,22 @@ func (i *Item) AddItem(item *Item) bool {
 	if !i.Container {
 		return false
 	}
+	// Weapons and magazines only take what they are loaded with
+	if i.AmmoType != "" {
+		if n, c := i.Rounds(); item.Ammo != i.AmmoType || n+item.StackAmount() > c {
+			return false
+		}
+	}
+	if i.MagazineType != "" && (item.TemplateID != i.MagazineType || i.magazine() != nil) {
+		return false
+	}
 	for _, o := range i.Inventory {
 		if item == o {
 			return false
 		}
 		// Try to stack
 		if item.Stackable && item.TemplateID == o.TemplateID {
-			o.Amount += item.Amount
-			item.Destroyed = true
+			o.absorb(item)
 			return true
 		}
 	}
@@ -0,0 +1,85 @@
+package game_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/qbradq/after/internal/events"
+	"github.com/qbradq/after/internal/game"
+)
+
+func TestSpoilage(t *testing.T) {
+	i := game.NewItem("Salami", fixtureTime, false)
+	life := time.Duration(i.ShelfLife)
+	if f := i.Freshness(); f != "fresh" {
+		t.Fatalf("new salami is %s", f)
+	}
+	// Spoilage is proportional to elapsed time no matter how it is divided
+	i.Spoil(fixtureTime.Add(life / 4))
+	i.Spoil(fixtureTime.Add(life / 2))
+	if math.Abs(i.Spoilage-0.5) > 1e-9 || i.Freshness() != "stale" || i.Rotten() {
+		t.Fatalf("half way salami spoilage %f is %s", i.Spoilage, i.Freshness())
+	}
+	i.Spoil(fixtureTime.Add(life))
+	if !i.Rotten() || i.Freshness() != "rotten" {
+		t.Fatalf("salami past its shelf life has spoilage %f", i.Spoilage)
+	}
+	// Items without a shelf life never spoil
+	c := game.NewItem("Crowbar", fixtureTime, false)
+	c.Spoil(fixtureTime.Add(life * 10))
+	if c.Spoilage != 0 || c.Freshness() != "" || c.Rotten() {
+		t.Fatal("crowbar spoiled")
+	}
+}
+
+func TestStackSpoilageAverages(t *testing.T) {
+	c := game.NewItem("TestBackpack", fixtureTime, false)
+	fresh := game.NewItem("Salami", fixtureTime, false)
+	rotten := game.NewItem("Salami", fixtureTime, false)
+	rotten.Amount = 3
+	rotten.Spoilage = 1
+	c.AddItem(fresh)
+	c.AddItem(rotten)
+	if len(c.Inventory) != 1 || !rotten.Destroyed {
+		t.Fatal("salami did not stack")
+	}
+	if fresh.Amount != 4 || fresh.Spoilage != 0.75 {
+		t.Fatalf("stack of %d has spoilage %f", fresh.Amount, fresh.Spoilage)
+	}
+}
+
+func TestContainedFoodSpoils(t *testing.T) {
+	m := newQuietCity(t, 1)
+	pack := game.NewItem("TestBackpack", m.Now, false)
+	salami := game.NewItem("Salami", m.Now, false)
+	pack.AddItem(salami)
+	m.Player.AddItemToInventory(pack)
+	d := time.Duration(salami.ShelfLife) / 10
+	if err := m.Update(m.Player.Position, d, nil); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(salami.Spoilage-0.1) > 1e-9 {
+		t.Fatalf("salami in a backpack has spoilage %f after a tenth of its shelf life", salami.Spoilage)
+	}
+}
+
+func TestEatRottenFood(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	m := newQuietCity(t, 1)
+	i := game.NewItem("Salami", m.Now, false)
+	i.Amount = 2
+	i.Spoilage = 1
+	i.Sickness = 0
+	m.Player.Hunger = 0.5
+	if err, ok := events.ExecuteItemUseEvent("Use", i, &m.Player.Actor, m); err != nil || !ok {
+		t.Fatalf("eating salami: %v %v", err, ok)
+	}
+	if want := 0.5 + i.Calories/2; math.Abs(m.Player.Hunger-want) > 1e-9 {
+		t.Fatalf("rotten salami left hunger at %f, expected %f", m.Player.Hunger, want)
+	}
+	if i.Amount != 1 || i.Destroyed {
+		t.Fatalf("eating one of two salami left %d, destroyed %v", i.Amount, i.Destroyed)
+	}
+}
@@ -0,0 +1,200 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// Levels are stacked one after the other along the Y axis of tile space so
+// everything that works in two dimensions works unchanged within a level. The
+// ground level keeps the coordinates it has always had, the levels above it
+// follow it and the basements follow those. Chunks of the levels other than
+// the ground level are allocated on first use and generated by the generator
+// of the ground level chunk beneath them.
+const (
+	MinLevel       int    = -1                          // Lowest level
+	MaxLevel       int    = 2                           // Highest level
+	CityMapLevels  int    = MaxLevel - MinLevel + 1     // Number of levels
+	levelHeight    int    = CityMapHeight * ChunkHeight // Height of each level in tiles
+	levelAirTile   string = "OpenAir"                   // Tile filling levels above ground without a map
+	levelEarthTile string = "Earth"                     // Tile filling levels below ground without a map
+)
+
+// levelSlot returns the position of level z in the stack of levels.
+func levelSlot(z int) int {
+	if z >= 0 {
+		return z
+	}
+	return MaxLevel - z
+}
+
+// slotLevel returns the level at position s in the stack of levels.
+func slotLevel(s int) int {
+	if s <= MaxLevel {
+		return s
+	}
+	return MaxLevel - s
+}
+
+// chunkRefLevel returns the level of the chunk with reference ref.
+func chunkRefLevel(ref uint32) int {
+	return slotLevel(int(ref) / (CityMapWidth * CityMapHeight))
+}
+
+// LevelOf returns the level containing the absolute tile point p.
+func LevelOf(p util.Point) int {
+	if p.Y < 0 {
+		return 0
+	}
+	return slotLevel(p.Y / levelHeight)
+}
+
+// OnLevel returns the point at the same place as p on level z.
+func OnLevel(p util.Point, z int) util.Point {
+	p.Y += (levelSlot(z) - levelSlot(LevelOf(p))) * levelHeight
+	return p
+}
+
+// LevelBounds returns the bounds of level z in tiles.
+func LevelBounds(z int) util.Rect {
+	return util.NewRectXYWH(0, levelSlot(z)*levelHeight, CityMapWidth*ChunkWidth, levelHeight)
+}
+
+// LevelName returns the descriptive name of level z.
+func LevelName(z int) string {
+	switch {
+	case z == 0:
+		return "Ground"
+	case z == -1:
+		return "Basement"
+	case z < 0:
+		return fmt.Sprintf("Basement %d", -z)
+	default:
+		return fmt.Sprintf("Floor %d", z+1)
+	}
+}
+
+// ValidateLevelTiles returns an error if the tiles filling levels without a
+// map are not defined. This must be called after tile loading is complete.
+func ValidateLevelTiles() error {
+	for _, id := range []string{levelAirTile, levelEarthTile} {
+		if _, found := TileRefs[id]; !found {
+			return fmt.Errorf("required tile %s not defined", id)
+		}
+	}
+	return nil
+}
+
+// ValidateStairs returns an error if the item leads to levels that can not
+// exist. This must be called on all items after item loading is complete.
+func (i *Item) ValidateStairs() error {
+	if i.Stairs == 0 {
+		return nil
+	}
+	if !i.Fixed {
+		return fmt.Errorf("item %s leads to another level but is not fixed", i.TemplateID)
+	}
+	if i.Stairs < MinLevel-MaxLevel || i.Stairs > MaxLevel-MinLevel {
+		return fmt.Errorf("item %s leads %d levels which is more than there are", i.TemplateID, i.Stairs)
+	}
+	return nil
+}
+
+// FillEmptyLevel fills the chunk with open air if it is above ground or earth
+// if it is below ground. Chunk generators call this for levels they have no
+// map for.
+func (c *Chunk) FillEmptyLevel() {
+	id := levelAirTile
+	if c.Level < 0 {
+		id = levelEarthTile
+	}
+	t := TileDefs[TileRefs[id]]
+	for i := range c.Tiles {
+		c.Tiles[i] = t
+	}
+}
+
+// chunkByRef returns the chunk with the given reference, allocating it if it
+// is on a level other than the ground level and has never been used.
+func (m *CityMap) chunkByRef(ref uint32) *Chunk {
+	n := uint32(len(m.Chunks))
+	if ref < n {
+		return m.Chunks[ref]
+	}
+	c := m.levelChunks[ref-n]
+	if c == nil {
+		g := m.Chunks[ref%n]
+		c = NewChunk(int(ref)%CityMapWidth, int(ref)/CityMapWidth, ref)
+		c.Generator = g.Generator
+		c.ChunkGenOffset = g.ChunkGenOffset
+		c.Facing = g.Facing
+		c.Flags = g.Flags
+		c.Generator.AssignStaticInfo(c)
+		m.levelChunks[ref-n] = c
+	}
+	return c
+}
+
+// StairsAt returns the stairs or ladder at p leading up if up is true or down
+// otherwise, or nil if there is none.
+func (m *CityMap) StairsAt(p util.Point, up bool) *Item {
+	for _, i := range m.ItemsAt(p) {
+		if (up && i.Stairs > 0) || (!up && i.Stairs < 0) {
+			return i
+		}
+	}
+	return nil
+}
+
+// stairsDestination returns the position the stairs or ladder i under actor a
+// leads to, loading the level if needed. The second return value is false if
+// there is no such level or the way is blocked.
+func (m *CityMap) stairsDestination(i *Item, a *Actor) (util.Point, bool) {
+	z := LevelOf(a.Position) + i.Stairs
+	if z < MinLevel || z > MaxLevel {
+		return util.Point{}, false
+	}
+	np := OnLevel(a.Position, z)
+	if err := m.EnsureLoadedAround(np); err != nil {
+		Log.Log(termui.ColorRed, "Error loading chunks: %v", err)
+		return util.Point{}, false
+	}
+	ws, _ := m.GetChunk(np).CanStep(a, np, m)
+	return np, ws
+}
+
+// PlayerTakeStairs moves the player along the stairs or ladder at their feet
+// returning true on success. Climbing between levels takes as long as
+// climbing over an obstacle.
+func (m *CityMap) PlayerTakeStairs(up bool) bool {
+	p := m.Player
+	i := m.StairsAt(p.Position, up)
+	if i == nil {
+		if up {
+			Log.Log(termui.ColorYellow, "There is no way up here.")
+		} else {
+			Log.Log(termui.ColorYellow, "There is no way down here.")
+		}
+		return false
+	}
+	if p.InControl {
+		Log.Log(termui.ColorYellow, "You can not do that while driving.")
+		return false
+	}
+	np, ok := m.stairsDestination(i, &p.Actor)
+	if !ok {
+		Log.Log(termui.ColorYellow, "Something blocks the way.")
+		return false
+	}
+	p.Position = np
+	if up {
+		Log.Log(termui.ColorLime, "You climb up the %s.", i.Name)
+	} else {
+		Log.Log(termui.ColorLime, "You climb down the %s.", i.Name)
+	}
+	m.PlayerTookTurn(time.Duration(float64(time.Second)*p.WalkSpeed())*4, nil)
+	return true
+}
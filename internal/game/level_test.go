@@ -0,0 +1,71 @@
+package game_test
+
+import (
+	"testing"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
+)
+
+func TestLevelCoordinates(t *testing.T) {
+	p := util.NewPoint(100, 200)
+	for z := game.MinLevel; z <= game.MaxLevel; z++ {
+		lp := game.OnLevel(p, z)
+		if got := game.LevelOf(lp); got != z {
+			t.Errorf("%v is on level %d, expected %d", lp, got, z)
+		}
+		if !game.LevelBounds(z).Contains(lp) {
+			t.Errorf("%v is outside of the bounds of level %d", lp, z)
+		}
+		if g := game.OnLevel(lp, 0); g != p {
+			t.Errorf("%v on level %d is %v on the ground", p, z, g)
+		}
+	}
+}
+
+func TestTakeStairs(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	log := &lastLog{}
+	game.Log = log
+	m := newQuietCity(t, 1)
+	start := m.Player.Position
+	place := func(tid string) {
+		i := game.NewItem(tid, m.Now, false)
+		i.Position = m.Player.Position
+		m.PlaceItem(i, true)
+	}
+	if m.PlayerTakeStairs(true) {
+		t.Fatal("climbed without stairs")
+	}
+	// The combat test room has nothing but earth below it
+	place("LadderDown")
+	if m.PlayerTakeStairs(false) {
+		t.Fatal("climbed down into solid earth")
+	}
+	if log.line != "Something blocks the way." {
+		t.Fatalf("climbing into solid earth logged %q", log.line)
+	}
+	// Lay a floor above the room to climb up onto
+	up := game.OnLevel(start, 1)
+	if err := m.EnsureLoadedAround(up); err != nil {
+		t.Fatal(err)
+	}
+	c := m.GetChunk(up)
+	rp := up.Sub(c.Bounds.TL)
+	c.Tiles[rp.Y*game.ChunkWidth+rp.X] = game.TileDefs[game.TileRefs["Floor"]]
+	place("LadderUp")
+	if !m.PlayerTakeStairs(true) {
+		t.Fatalf("failed to climb the ladder: %s", log.line)
+	}
+	if m.Player.Position != up || game.LevelOf(m.Player.Position) != 1 {
+		t.Fatalf("climbing the ladder led to %v", m.Player.Position)
+	}
+	// There is no way back down without a ladder on this level
+	if m.PlayerTakeStairs(false) {
+		t.Fatal("climbed down without stairs")
+	}
+	place("LadderDown")
+	if !m.PlayerTakeStairs(false) || m.Player.Position != start {
+		t.Fatalf("climbing down the ladder led to %v", m.Player.Position)
+	}
+}
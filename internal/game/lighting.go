@@ -0,0 +1,176 @@
+package game
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/qbradq/after/lib/util"
+)
+
+// Lighting parameters.
+const (
+	lightMaxRadius int     = 12  // Largest radius in tiles of light an item may cast
+	lightNight     float64 = 0.1 // Ambient light on a clear night
+	lightFull      float64 = 0.5 // Ambient light at which actors see to the full extent of their sight
+	lightCloud     float64 = 0.4 // Fraction of ambient light blocked by full cloud cover
+	lightDayLength float64 = 12  // Mean hours from sunrise to sunset over the year
+	lightDaySwing  float64 = 3   // Difference in hours between the mean and the longest and shortest days
+	lightTwilight  float64 = 1   // Hours of twilight around sunrise and sunset
+	lightDarkSight int     = 1   // Distance actors see in total darkness
+)
+
+// ValidateLight returns an error if the item casts light beyond the largest
+// radius supported. This must be called on all items after item loading is
+// complete.
+func (i *Item) ValidateLight() error {
+	if i.Light < 0 || i.Light > lightMaxRadius {
+		return fmt.Errorf("item %s has light radius %d outside of [0-%d]", i.TemplateID, i.Light, lightMaxRadius)
+	}
+	return nil
+}
+
+// AmbientLight returns the current light from the sun and sky from zero (pitch
+// black) to one (noon under clear skies). Days are longer in summer than in
+// winter and cloud cover dims the sky.
+func (m *CityMap) AmbientLight() float64 {
+	doy := float64(m.Now.YearDay())
+	h := float64(m.Now.Hour()) + float64(m.Now.Minute())/60
+	// The longest day of the year falls in late June
+	dl := lightDayLength + lightDaySwing*math.Cos(2*math.Pi*(doy-172)/365)
+	// Hours since sunrise or until sunset, whichever is nearer
+	sun := dl/2 - math.Abs(h-12)
+	l := lightNight + (1-lightNight)*min(max(sun/lightTwilight+0.5, 0), 1)
+	return l * (1 - m.Weather.Cloud*lightCloud)
+}
+
+// ambientLightAt returns the ambient light at p. No daylight reaches the
+// levels below ground.
+func (m *CityMap) ambientLightAt(p util.Point) float64 {
+	if LevelOf(p) < 0 {
+		return 0
+	}
+	return m.AmbientLight()
+}
+
+// lightSource is a position casting light.
+type lightSource struct {
+	p util.Point // Position of the light
+	r int        // Radius of the light in tiles
+}
+
+// lightSources returns all light sources that could light any point within
+// b. Fixed items like fires and items wielded or worn cast light, as do the
+// lights of the vehicle the player is driving. Loose items on the ground are
+// assumed to be switched off. This does not re-use the ItemsWithin or
+// ActorsWithin slices so it is safe to call while ranging over them.
+func (m *CityMap) lightSources(b util.Rect) []lightSource {
+	var ret []lightSource
+	sb := util.NewRect(b.TL.Sub(util.NewPoint(lightMaxRadius, lightMaxRadius)),
+		b.BR.Add(util.NewPoint(lightMaxRadius, lightMaxRadius))).Overlap(m.stackTileBounds)
+	cb := util.NewRect(sb.TL.Divide(ChunkWidth), sb.BR.Divide(ChunkWidth))
+	for cy := cb.TL.Y; cy <= cb.BR.Y; cy++ {
+		for cx := cb.TL.X; cx <= cb.BR.X; cx++ {
+			c := m.chunkByRef(uint32(cy*CityMapWidth + cx))
+			for _, i := range c.Items {
+				if i.Light > 0 && i.Fixed && sb.Contains(i.Position) {
+					ret = append(ret, lightSource{p: i.Position, r: i.Light})
+				}
+			}
+			for _, a := range c.Actors {
+				if r := a.carriedLight(); r > 0 && sb.Contains(a.Position) {
+					ret = append(ret, lightSource{p: a.Position, r: r})
+				}
+			}
+		}
+	}
+	if r := m.Player.carriedLight(); r > 0 && sb.Contains(m.Player.Position) {
+		ret = append(ret, lightSource{p: m.Player.Position, r: r})
+	}
+	if m.Player.InControl {
+		if v := m.VehicleAt(m.Player.Position); v != nil {
+			var p util.Point
+			for p.Y = v.Bounds.TL.Y; p.Y <= v.Bounds.BR.Y; p.Y++ {
+				for p.X = v.Bounds.TL.X; p.X <= v.Bounds.BR.X; p.X++ {
+					if l := v.GetLocationAbsolute(p); l != nil && l.Light > 0 {
+						ret = append(ret, lightSource{p: p, r: l.Light})
+					}
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// carriedLight returns the radius of the brightest light the actor is wielding
+// or wearing.
+func (a *Actor) carriedLight() int {
+	r := 0
+	if a.Weapon != nil {
+		r = a.Weapon.Light
+	}
+	for _, i := range a.WornItems {
+		if i != nil {
+			r = max(r, i.Light)
+		}
+	}
+	return r
+}
+
+// makeLightSet sets the positions of the Light bitmap within the bitmap bounds
+// b that are lit by daylight or by a light source. Daylight is that of the
+// player's level.
+func (m *CityMap) makeLightSet(b util.Rect) {
+	m.Light.Clear()
+	var p util.Point
+	if m.ambientLightAt(m.Player.Position) >= lightFull {
+		for p.Y = b.TL.Y; p.Y <= b.BR.Y; p.Y++ {
+			for p.X = b.TL.X; p.X <= b.BR.X; p.X++ {
+				m.Light.Set(uint32((p.Y-b.TL.Y)*b.Width() + (p.X - b.TL.X)))
+			}
+		}
+		return
+	}
+	// Light falls on everything visible from its source, so walls are lit on
+	// the side facing the light
+	for _, s := range m.lightSources(b) {
+		fov := util.FOV{
+			Blocks: m.visBlocker(LevelBounds(LevelOf(s.p))),
+			Visible: func(p util.Point) {
+				if b.Contains(p) {
+					m.Light.Set(uint32((p.Y-b.TL.Y)*b.Width() + (p.X - b.TL.X)))
+				}
+			},
+		}
+		fov.Execute(s.p, s.r)
+	}
+}
+
+// Lit returns true if p is lit by daylight or by a light source.
+func (m *CityMap) Lit(p util.Point) bool {
+	if m.ambientLightAt(p) >= lightFull {
+		return true
+	}
+	for _, s := range m.lightSources(util.NewRectFromRadius(p, 0)) {
+		if s.p.Distance(p) <= s.r && m.HasLineOfSight(s.p, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// darkSightRange returns the distance an actor with sight range r sees into
+// unlit areas at p given the ambient light.
+func (m *CityMap) darkSightRange(r int, p util.Point) int {
+	return max(int(float64(r)*min(m.ambientLightAt(p)/lightFull, 1)), lightDarkSight)
+}
+
+// SightRange returns the distance at which actor a could see something at p
+// accounting for status effects and darkness. Lit positions are seen to the
+// full extent of the actor's sight.
+func (m *CityMap) SightRange(a *Actor, p util.Point) int {
+	r := a.CurrentSightRange()
+	if m.Lit(p) {
+		return r
+	}
+	return m.darkSightRange(r, p)
+}
@@ -0,0 +1,133 @@
+package game_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
+)
+
+func TestAmbientLight(t *testing.T) {
+	m := &game.CityMap{}
+	for _, c := range []struct {
+		now   time.Time
+		cloud float64
+		want  float64
+	}{
+		{time.Date(2028, time.June, 21, 12, 0, 0, 0, time.UTC), 0, 1},
+		{time.Date(2028, time.June, 21, 12, 0, 0, 0, time.UTC), 1, 0.6},
+		{time.Date(2028, time.June, 21, 0, 0, 0, 0, time.UTC), 0, 0.1},
+		// Summer evenings are light and winter evenings dark
+		{time.Date(2028, time.June, 21, 17, 30, 0, 0, time.UTC), 0, 1},
+		{time.Date(2028, time.December, 21, 17, 30, 0, 0, time.UTC), 0, 0.1},
+	} {
+		m.Now = c.now
+		m.Weather.Cloud = c.cloud
+		if l := m.AmbientLight(); math.Abs(l-c.want) > 0.01 {
+			t.Errorf("ambient light at %v under %f cloud is %f, expected %f", c.now, c.cloud, l, c.want)
+		}
+	}
+}
+
+func TestLightSources(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	m := newQuietCity(t, 1)
+	p := m.Player
+	p.Weapon = nil
+	for i := range p.WornItems {
+		p.WornItems[i] = nil
+	}
+	o := m.GetChunk(p.Position).Bounds.TL
+	p.Position = o.Add(util.NewPoint(12, 12))
+	// Midnight
+	m.Now = m.Now.Truncate(time.Hour * 24).Add(time.Hour * 24)
+	if m.Lit(o.Add(util.NewPoint(4, 4))) {
+		t.Fatal("room is lit at midnight")
+	}
+	// Fires light their surroundings but not through walls
+	b := game.NewItem("BurnBarrel", m.Now, false)
+	b.Position = o.Add(util.NewPoint(4, 2))
+	m.PlaceItem(b, true)
+	for _, c := range []struct {
+		p    util.Point
+		want bool
+	}{
+		{util.NewPoint(4, 1), true},
+		{util.NewPoint(7, 4), true},
+		{util.NewPoint(9, 2), false},
+		{util.NewPoint(4, -1), false},
+	} {
+		if got := m.Lit(o.Add(c.p)); got != c.want {
+			t.Errorf("%v lit %v by a burn barrel", c.p, got)
+		}
+	}
+	// Unlit positions are only seen from close by
+	dark := o.Add(util.NewPoint(12, 7))
+	if r := m.SightRange(&p.Actor, dark); r >= p.CurrentSightRange() {
+		t.Fatalf("player sees %d tiles into the dark", r)
+	}
+	if r := m.SightRange(&p.Actor, b.Position); r != p.CurrentSightRange() {
+		t.Fatalf("player sees %d tiles to a lit position", r)
+	}
+	// Carried lights light the way
+	f := game.NewItem("Flashlight", m.Now, false)
+	p.WieldItem(f)
+	if !m.Lit(dark) {
+		t.Fatal("flashlight did not light the way")
+	}
+}
+
+func TestLightDiagonalGaps(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	m := newQuietCity(t, 1)
+	p := m.Player
+	p.Weapon = nil
+	for i := range p.WornItems {
+		p.WornItems[i] = nil
+	}
+	o := m.GetChunk(p.Position).Bounds.TL
+	p.Position = o.Add(util.NewPoint(12, 12))
+	m.Now = m.Now.Truncate(time.Hour * 24).Add(time.Hour * 24)
+	place := func(tid string, at util.Point) {
+		i := game.NewItem(tid, m.Now, false)
+		i.Position = o.Add(at)
+		m.PlaceItem(i, true)
+	}
+	// Two closed doors meeting at their corners leave a diagonal gap
+	place("BurnBarrel", util.NewPoint(4, 4))
+	place("Door", util.NewPoint(5, 4))
+	place("Door", util.NewPoint(4, 5))
+	b := util.NewRectFromRadius(p.Position, 16)
+	m.MakeVisibilitySets(b)
+	for _, c := range []struct {
+		p    util.Point
+		want bool
+	}{
+		{util.NewPoint(3, 3), true},
+		{util.NewPoint(5, 4), true},
+		{util.NewPoint(5, 5), false},
+		{util.NewPoint(6, 6), false},
+	} {
+		lp := o.Add(c.p)
+		if got := m.Lit(lp); got != c.want {
+			t.Errorf("%v lit %v by a burn barrel", c.p, got)
+		}
+		idx := uint32((lp.Y-b.TL.Y)*b.Width() + (lp.X - b.TL.X))
+		if got := m.Light.Contains(idx); got != c.want {
+			t.Errorf("%v in the light set %v", c.p, got)
+		}
+	}
+	// Line of sight agrees with the player's field of view
+	var lp util.Point
+	for lp.Y = p.Position.Y - 10; lp.Y <= p.Position.Y+10; lp.Y++ {
+		for lp.X = p.Position.X - 10; lp.X <= p.Position.X+10; lp.X++ {
+			if m.HasLineOfSight(p.Position, lp) != m.CanSeePlayerFrom(lp) {
+				t.Fatalf("line of sight to %v disagrees with the player's field of view", lp)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package game
+
+import (
+	"github.com/qbradq/after/lib/util"
+)
+
+// Loudness of common noises, in tiles the noise carries through open air.
+const (
+	NoiseFootsteps int = 6  // Running footsteps
+	NoiseDoor      int = 8  // Opening or closing a door
+	NoiseCombat    int = 12 // Melee combat
+	NoiseSmash     int = 20 // Smashing through an obstacle
+	NoiseEngine    int = 16 // A running vehicle engine at idle
+	NoiseGunshot   int = 48 // A gunshot
+)
+
+// Attenuation of noise passing through obstacles, in addition to the normal
+// attenuation of one per tile.
+const (
+	noiseWallCost    int = 6 // Positions that block both walking and visibility
+	noiseOpeningCost int = 2 // Positions that block only walking or visibility, like windows and closed doors
+)
+
+// noiseStep is a position in the noise propagation queue.
+type noiseStep struct {
+	p util.Point // Position
+	v int        // Volume remaining at the position
+}
+
+// MakeNoise emits a noise of the given loudness at p. The noise spreads
+// outward losing one volume per tile and more through walls, doors and
+// windows. The AI model of every actor that hears the noise is notified.
+func (m *CityMap) MakeNoise(p util.Point, loudness int) {
+	if loudness < 1 || !m.stackTileBounds.Contains(p) {
+		return
+	}
+	b := util.NewRectFromRadius(p, loudness).Overlap(LevelBounds(LevelOf(p)))
+	w := b.Width()
+	vol := make([]int, w*b.Height())
+	idx := func(p util.Point) int { return (p.Y-b.TL.Y)*w + (p.X - b.TL.X) }
+	// Bucket queue indexed by remaining volume, processed loudest first
+	buckets := make([][]noiseStep, loudness+1)
+	buckets[loudness] = append(buckets[loudness], noiseStep{p: p, v: loudness})
+	vol[idx(p)] = loudness
+	for v := loudness; v > 0; v-- {
+		for i := 0; i < len(buckets[v]); i++ {
+			s := buckets[v][i]
+			if vol[idx(s.p)] > s.v {
+				continue // Already reached louder by another route
+			}
+			for _, d := range util.DirectionOffsets {
+				np := s.p.Add(d)
+				if !b.Contains(np) {
+					continue
+				}
+				nv := s.v - 1
+				c := m.GetChunk(np)
+				if c.bitmapsDirty {
+					c.RebuildBitmaps(m)
+				}
+				bw := c.BlocksWalk.Contains(c.relOfs(np))
+				bv := c.BlocksVis.Contains(c.relOfs(np))
+				if bw && bv {
+					nv -= noiseWallCost
+				} else if bw || bv {
+					nv -= noiseOpeningCost
+				}
+				if nv < 1 || vol[idx(np)] >= nv {
+					continue
+				}
+				vol[idx(np)] = nv
+				buckets[nv] = append(buckets[nv], noiseStep{p: np, v: nv})
+			}
+		}
+	}
+	m.hordesHear(p, loudness)
+	for _, a := range m.ActorsWithin(b) {
+		if a.IsPlayer || a.Dead {
+			continue
+		}
+		if v := vol[idx(a.Position)]; v > 0 {
+			a.AIModel.Heard(a, m, p, v)
+		}
+	}
+}
+
+// PlayerAttack has the player attack the target, making noise if the attack
+// was made. Attacking a member of a faction angers the faction. Returns true if
+// the attack was made.
+func (m *CityMap) PlayerAttack(t *Actor) bool {
+	if !m.Player.Attack(t, m.Now) {
+		return false
+	}
+	if t.Faction != "" {
+		m.AdjustReputation(t.Faction, -reputationAttack)
+	}
+	m.MakeNoise(t.Position, NoiseCombat)
+	return true
+}
@@ -0,0 +1,83 @@
+package game_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/qbradq/after/internal/ai"
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
+)
+
+// earModel is an AI model that only records the noises it hears.
+type earModel struct {
+	volume int // Volume of the last noise heard, zero if none
+}
+
+func (e *earModel) Act(*game.Actor, *game.CityMap) time.Duration { return time.Hour }
+
+func (e *earModel) PeriodicUpdate(*game.Actor, *game.CityMap, time.Duration) {}
+
+func (e *earModel) Heard(a *game.Actor, m *game.CityMap, p util.Point, v int) { e.volume = v }
+
+func (e *earModel) Write(io.Writer) {}
+
+func TestMakeNoise(t *testing.T) {
+	m := newQuietCity(t, 1)
+	// The combat test room is walled with an open interior
+	o := m.GetChunk(m.Player.Position).Bounds.TL
+	y := 4
+	if m.Player.Position.Y-o.Y == y {
+		y = 10
+	}
+	listen := func(p util.Point) *earModel {
+		e := &earModel{}
+		a := game.NewActor("Zombie", m.Now, false)
+		a.AIModel = e
+		a.Position = p
+		m.PlaceActor(a, false)
+		return e
+	}
+	src := o.Add(util.NewPoint(5, y))
+	near := listen(o.Add(util.NewPoint(11, y)))
+	far := listen(src.Add(util.NewPoint(-40, 0)))
+	m.MakeNoise(src, 20)
+	// Volume falls by one per tile, and the listener standing in the way
+	// muffles it like an opening
+	open := near.volume
+	if open != 20-6-2 {
+		t.Fatalf("noise six tiles away through open air heard at volume %d", open)
+	}
+	if far.volume != 0 {
+		t.Fatalf("noise heard beyond its loudness at volume %d", far.volume)
+	}
+	// Partition the room with doors, which cost as much as walls
+	for dy := 1; dy < game.ChunkHeight-1; dy++ {
+		d := game.NewItem("Door", m.Now, false)
+		d.Position = o.Add(util.NewPoint(8, dy))
+		m.PlaceItem(d, true)
+	}
+	near.volume = 0
+	m.MakeNoise(src, 20)
+	if near.volume != open-6 {
+		t.Fatalf("noise through a closed door heard at volume %d", near.volume)
+	}
+	near.volume = 0
+	m.MakeNoise(src, 12)
+	if near.volume != 0 {
+		t.Fatalf("quiet noise through a closed door heard at volume %d", near.volume)
+	}
+}
+
+func TestZombieInvestigatesNoise(t *testing.T) {
+	m := newQuietCity(t, 1)
+	z := game.NewActor("Zombie", m.Now, false)
+	z.Position = m.Player.Position.Add(util.NewPoint(0, 20))
+	m.PlaceActor(z, false)
+	src := z.Position.Add(util.NewPoint(5, 0))
+	m.MakeNoise(src, game.NoiseGunshot)
+	if poi := z.AIModel.(*ai.AIModel).POI; poi != src {
+		t.Fatalf("zombie is interested in %v rather than the noise at %v", poi, src)
+	}
+}
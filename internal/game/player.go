@@ -11,14 +11,24 @@ import (
 // Player implements the player's special actor.
 type Player struct {
 	Actor
-	Stamina   float64 // Stamina value from zero (exhausted) to one (well rested)
-	Hunger    float64 // Hunger value from zero (starving) to one (stuffed)
-	Thirst    float64 // Thirst value from zero (dehydrated to death) to one (slaked)
-	Joy       float64 // Happiness value from zero (suicidal) to one (manic), 0.5 is normal
-	Mind      float64 // Sanity value from zero (insane) to one (well adjusted), 0.5 is normal
-	Sleep     float64 // Sleepiness value from zero (falling asleep standing up) to one (unable to go back to sleep)
-	Running   bool    // If true the player is running and consuming stamina
-	InControl bool    // If true the player is controlling the vehicle at their current location
+	Stamina   float64   // Stamina value from zero (exhausted) to one (well rested)
+	Hunger    float64   // Hunger value from zero (starving) to one (stuffed)
+	Thirst    float64   // Thirst value from zero (dehydrated to death) to one (slaked)
+	Joy       float64   // Happiness value from zero (suicidal) to one (manic), 0.5 is normal
+	Mind      float64   // Sanity value from zero (insane) to one (well adjusted), 0.5 is normal
+	Sleep     float64   // Sleepiness value from zero (falling asleep standing up) to one (unable to go back to sleep)
+	Running   bool      // If true the player is running and consuming stamina
+	InControl bool      // If true the player is controlling the vehicle at their current location
+	Activity  *Activity // The activity the player is performing or was interrupted from, if any, persisted with the city's dynamic data
+	BodyTemp  float64   // Core body temperature in degrees Fahrenheit, persisted with the city's dynamic data
+	Wetness   float64   // Wetness from zero (dry) to one (soaked), persisted with the city's dynamic data
+
+	//
+	// Transient values
+	//
+
+	Sleeping     bool    // If true the player is asleep
+	SleepQuality float64 // Quality of the current sleep from zero to one
 }
 
 // NewPlayer creates and returns a new Player struct.
@@ -26,21 +36,25 @@ func NewPlayer(now time.Time) *Player {
 	a := NewActor("Player", now, true)
 	a.IsPlayer = true
 	p := &Player{
-		Actor:   *a,
-		Stamina: 1.0,
-		Hunger:  0.5,
-		Thirst:  0.5,
-		Joy:     0.5,
-		Mind:    0.5,
-		Sleep:   1.0,
+		Actor:    *a,
+		Stamina:  1.0,
+		Hunger:   0.5,
+		Thirst:   0.5,
+		Joy:      0.5,
+		Mind:     0.5,
+		Sleep:    1.0,
+		BodyTemp: BodyTempNormal,
 	}
 	return p
 }
 
 // NewPlayerFromReader reads the player information from r and returns a new
 // player with this information.
-func NewPlayerFromReader(r io.Reader) *Player {
-	a := NewActorFromReader(r)
+func NewPlayerFromReader(r io.Reader) (*Player, error) {
+	a, err := NewActorFromReader(r)
+	if err != nil {
+		return nil, err
+	}
 	a.IsPlayer = true
 	a.Name = util.GetString(r)
 	p := &Player{
@@ -53,8 +67,9 @@ func NewPlayerFromReader(r io.Reader) *Player {
 		Sleep:     util.GetFloat(r),
 		Running:   util.GetBool(r),
 		InControl: util.GetBool(r),
+		BodyTemp:  BodyTempNormal,
 	}
-	return p
+	return p, nil
 }
 
 // Write writes the player to the writer.
@@ -81,7 +96,8 @@ func (a *Player) Attack(t *Actor, now time.Time) bool {
 		Log.Log(termui.ColorRed, "You are too fatigued.")
 		return false
 	}
-	t.Damage(a.minDamage, a.maxDamage, now, &a.Actor)
+	t.Damage(a.AttackDamageType(), a.minDamage, a.maxDamage, now, &a.Actor)
+	a.WearWeapon()
 	a.Stamina -= sc
 	return true
 }
@@ -116,11 +132,32 @@ func (a *Player) TookTurn(now time.Time, d time.Duration) {
 	if a.Mind < 0 {
 		a.Mind = 0
 	}
-	// Sleep decay
-	a.Sleep -= float64(d) / float64(time.Hour*36) // Thirty six hours until dead tired
-	if a.Mind < 0 {
-		a.Mind = 0
+	// Sleep decay or recovery
+	if a.Sleeping {
+		a.Sleep += a.SleepQuality * float64(d) / float64(time.Hour*8) // Eight hours of good sleep to be fully rested
+	} else {
+		a.Sleep -= float64(d) / float64(time.Hour*36) // Thirty six hours until dead tired
+	}
+	a.Sleep = min(max(a.Sleep, 0), 1)
+	// Sleep deprivation, optional for mods to define
+	switch {
+	case a.Sleep <= 0:
+		a.SetEffect("SleepDeprivation", 3, now)
+	case a.Sleep < 0.1:
+		a.SetEffect("SleepDeprivation", 2, now)
+	case a.Sleep < 0.25:
+		a.SetEffect("SleepDeprivation", 1, now)
+	default:
+		a.SetEffect("SleepDeprivation", 0, now)
+	}
+	// Status effects
+	for _, e := range a.Effects {
+		h := e.hours(now, d) * float64(e.Stacks)
+		a.Stamina = min(max(a.Stamina+e.Stamina*h, 0), 1)
+		a.Hunger = min(max(a.Hunger+e.Hunger*h, 0), 1)
+		a.Thirst = min(max(a.Thirst+e.Thirst*h, 0), 1)
 	}
+	a.UpdateEffects(now, d)
 	// Process broken part timers
 	days := float64(d) / float64(time.Hour*24)
 	for i, p := range a.BodyParts {
@@ -0,0 +1,287 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+func init() {
+	// Reloading is short enough to finish in the middle of a fight
+	RegisterActivity("Reload", &ActivityKind{
+		Start: startReload,
+		Stop:  stopReload,
+	})
+}
+
+// Ranged combat parameters.
+const (
+	rangedReach      int     = 2           // Multiple of a weapon's range its shots carry at all
+	rangedBrokenAim  float64 = 0.5         // Accuracy multiplier with broken arms or hands
+	rangedThrowNoise int     = NoiseCombat // Loudness of a thrown weapon landing
+)
+
+// Reach returns the farthest distance in tiles shots from the ranged weapon
+// carry.
+func (i *Item) Reach() int {
+	return i.Range * rangedReach
+}
+
+// magazine returns the magazine loaded into the weapon, or nil if there is
+// none.
+func (i *Item) magazine() *Item {
+	for _, c := range i.Inventory {
+		if c.TemplateID == i.MagazineType {
+			return c
+		}
+	}
+	return nil
+}
+
+// ammoContainer returns the item that directly holds the rounds of the weapon
+// or magazine, or nil if there is none.
+func (i *Item) ammoContainer() *Item {
+	if i.MagazineType != "" {
+		return i.magazine()
+	}
+	if i.AmmoType != "" {
+		return i
+	}
+	return nil
+}
+
+// Rounds returns the number of rounds loaded into the weapon or magazine and
+// the number of rounds it holds.
+func (i *Item) Rounds() (int, int) {
+	c := i.ammoContainer()
+	if c == nil {
+		if m, found := ItemDefs[i.MagazineType]; found {
+			return 0, m.Capacity
+		}
+		return 0, i.Capacity
+	}
+	n := 0
+	for _, r := range c.Inventory {
+		n += r.StackAmount()
+	}
+	return n, c.Capacity
+}
+
+// spendRound removes one round from the weapon, returning false if it is
+// empty.
+func (i *Item) spendRound() bool {
+	c := i.ammoContainer()
+	if c == nil || len(c.Inventory) == 0 {
+		return false
+	}
+	r := c.Inventory[0]
+	if r.StackAmount() > 1 {
+		r.Amount--
+	} else {
+		c.RemoveItem(r)
+	}
+	return true
+}
+
+// PlayerFire has the player fire or throw the wielded ranged weapon at the
+// target position. Returns true if the shot was made.
+func (m *CityMap) PlayerFire(target util.Point) bool {
+	p := m.Player
+	w := p.Weapon
+	if w == nil || !w.Ranged {
+		Log.Log(termui.ColorYellow, "You are not wielding a ranged weapon.")
+		return false
+	}
+	if target == p.Position {
+		return false
+	}
+	if w.Broken() {
+		Log.Log(termui.ColorYellow, "The %s is broken.", w.Name)
+		return false
+	}
+	if p.Stamina < w.WeaponSwingStam {
+		Log.Log(termui.ColorRed, "You are too fatigued.")
+		return false
+	}
+	if w.Thrown {
+		t := w.Split(1)
+		if t == w {
+			p.UnWieldItem(w)
+		}
+		p.Stamina -= w.WeaponSwingStam
+		t.Position = m.traceShot(target, t)
+		m.PlaceItem(t, true)
+		m.MakeNoise(t.Position, rangedThrowNoise)
+		return true
+	}
+	if !w.spendRound() {
+		Log.Log(termui.ColorYellow, "Click! The %s is empty.", w.Name)
+		return false
+	}
+	p.Stamina -= w.WeaponSwingStam
+	m.MakeNoise(p.Position, NoiseGunshot)
+	m.traceShot(target, w)
+	p.WearWeapon()
+	return true
+}
+
+// traceShot traces a projectile from weapon w fired by the player toward the
+// target. Shots carry on past the target until they hit an actor, a vehicle or
+// a solid obstacle or run out of range. Thrown weapons come down at the target.
+// Accuracy and damage fall off beyond the weapon's range. Returns the last open
+// position the projectile reached.
+func (m *CityMap) traceShot(target util.Point, w *Item) util.Point {
+	from := m.Player.Position
+	reach := w.Reach()
+	end := target
+	if !w.Thrown {
+		dist := from.Distance(target)
+		end = from.Add(target.Sub(from).Multiply((reach + dist - 1) / dist))
+	}
+	acc := w.Accuracy * w.Effectiveness()
+	if m.Player.BodyParts[BodyPartArms].Broken || m.Player.BodyParts[BodyPartHand].Broken {
+		acc *= rangedBrokenAim
+	}
+	last := from
+	for _, p := range slices.Clone(util.Ray(from, end))[1:] {
+		dist := from.Distance(p)
+		if dist > reach || !LevelBounds(LevelOf(from)).Contains(p) {
+			break
+		}
+		if v := m.VehicleAt(p); v != nil {
+			if l := v.GetLocationAbsolute(p); l != nil && l.Solid {
+				Log.Log(termui.ColorWhite, "The %s hits the %s.", w.Name, v.Name)
+				return last
+			}
+		}
+		c := m.GetChunk(p)
+		if c.bitmapsDirty {
+			c.RebuildBitmaps(m)
+		}
+		if c.BlocksWalk.Contains(c.relOfs(p)) && c.BlocksVis.Contains(c.relOfs(p)) {
+			return last
+		}
+		last = p
+		if a := m.ActorAt(p); a != nil && !a.Dead {
+			f := 1.0
+			if dist > w.Range {
+				f = 1 - float64(dist-w.Range)/float64(reach-w.Range+1)
+			}
+			if util.RandomF(0, 1) < acc*f {
+				a.Damage(w.WeaponDamage, w.WeaponMinDamage*f, w.WeaponMaxDamage*f, m.Now, &m.Player.Actor)
+				if a.Faction != "" {
+					m.AdjustReputation(a.Faction, -reputationAttack)
+				}
+				return p
+			}
+			if p == target {
+				Log.Log(termui.ColorWhite, "You miss the %s.", a.Name)
+			}
+		}
+		if w.Thrown && p == end {
+			break
+		}
+	}
+	return last
+}
+
+// Reload has the player reload the wielded weapon as an activity, calling
+// update after every step. Returns true on success.
+func (m *CityMap) Reload(update func()) bool {
+	w := m.Player.Weapon
+	if w == nil || (w.AmmoType == "" && w.MagazineType == "") {
+		Log.Log(termui.ColorYellow, "You are not wielding anything that needs reloading.")
+		return false
+	}
+	a := NewActivity("Reload", "reloading the "+w.Name, time.Duration(w.ReloadTime))
+	return m.StartActivity(a, update)
+}
+
+// reloadSource returns the item from the player's inventory that would best
+// reload the weapon, either a magazine with more rounds than the loaded one or
+// loose rounds. Returns nil if there is nothing to reload with.
+func (m *CityMap) reloadSource(w *Item) *Item {
+	n, c := w.Rounds()
+	if w.MagazineType != "" {
+		var best *Item
+		bn := n
+		for _, i := range m.Player.Inventory {
+			if i.TemplateID != w.MagazineType {
+				continue
+			}
+			if in, _ := i.Rounds(); in > bn {
+				best = i
+				bn = in
+			}
+		}
+		if best != nil || w.magazine() == nil {
+			return best
+		}
+	}
+	if n >= c {
+		return nil
+	}
+	at := w.AmmoType
+	if mag := w.magazine(); mag != nil {
+		at = mag.AmmoType
+	}
+	for _, i := range m.Player.Inventory {
+		if i.Ammo == at {
+			return i
+		}
+	}
+	return nil
+}
+
+// startReload checks that the player has something to reload the wielded
+// weapon with.
+func startReload(m *CityMap, a *Activity) string {
+	w := m.Player.Weapon
+	if w == nil || (w.AmmoType == "" && w.MagazineType == "") {
+		return "You are not wielding anything that needs reloading."
+	}
+	if m.reloadSource(w) == nil {
+		return fmt.Sprintf("You have nothing to reload the %s with.", w.Name)
+	}
+	return ""
+}
+
+// stopReload loads the wielded weapon if the activity was completed.
+// Magazines are swapped for fuller ones, otherwise loose rounds are loaded.
+func stopReload(m *CityMap, a *Activity, completed bool) bool {
+	if !completed {
+		return false
+	}
+	p := m.Player
+	w := p.Weapon
+	var src *Item
+	if w != nil {
+		src = m.reloadSource(w)
+	}
+	if src == nil {
+		Log.Log(termui.ColorYellow, "You were unable to finish reloading.")
+		return false
+	}
+	if src.Ammo == "" {
+		// Magazine swap
+		p.RemoveItemFromInventory(src)
+		if old := w.magazine(); old != nil {
+			w.RemoveItem(old)
+			p.AddItemToInventory(old)
+		}
+		w.AddItem(src)
+	} else {
+		// Loose rounds
+		n, c := w.Rounds()
+		r := src.Split(min(c-n, src.StackAmount()))
+		if r == src {
+			p.RemoveItemFromInventory(src)
+		}
+		w.ammoContainer().AddItem(r)
+	}
+	Log.Log(termui.ColorAqua, "You reload the %s.", w.Name)
+	return true
+}
@@ -0,0 +1,145 @@
+package game_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
+)
+
+// newRange returns a quiet city with the player standing in the combat test
+// room wielding a shotgun that carries four tiles, along with the room's
+// origin.
+func newRange(t *testing.T) (*game.CityMap, *game.Item, util.Point) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	m := newQuietCity(t, 1)
+	o := m.GetChunk(m.Player.Position).Bounds.TL
+	m.Player.Position = o.Add(util.NewPoint(3, 8))
+	w := game.NewItem("Shotgun", m.Now, false)
+	w.Range = 2
+	m.Player.Weapon = nil
+	m.Player.WieldItem(w)
+	return m, w, o
+}
+
+// countHits fires n single-shell shots from a like-new weapon at a zombie
+// standing at p and returns the number that hit.
+func countHits(m *game.CityMap, w *game.Item, p util.Point, n int) int {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	z := game.NewActor("Zombie", m.Now, false)
+	z.Position = p
+	m.PlaceActor(z, false)
+	defer m.RemoveActor(z)
+	ret := 0
+	for i := 0; i < n; i++ {
+		for bp := range z.BodyParts {
+			z.BodyParts[bp].Health = 1
+			z.BodyParts[bp].Broken = false
+		}
+		z.Dead = false
+		m.Player.Stamina = 1
+		w.Wear = 0
+		w.AddItem(game.NewItem("ShotgunShell", m.Now, false))
+		m.PlayerFire(p)
+		for _, bp := range z.BodyParts {
+			if bp.Health < 1 {
+				ret++
+				break
+			}
+		}
+	}
+	return ret
+}
+
+func TestRangedAccuracy(t *testing.T) {
+	m, w, _ := newRange(t)
+	from := m.Player.Position
+	util.WithSeed(1, func() {
+		// Full accuracy within range, falling off to the weapon's reach
+		if n := countHits(m, w, from.Add(util.NewPoint(2, 0)), 200); n < 160 {
+			t.Errorf("%d of 200 shots hit within range", n)
+		}
+		if n := countHits(m, w, from.Add(util.NewPoint(4, 0)), 200); n < 40 || n > 80 {
+			t.Errorf("%d of 200 shots hit at the weapon's reach", n)
+		}
+		if n := countHits(m, w, from.Add(util.NewPoint(5, 0)), 50); n != 0 {
+			t.Errorf("%d of 50 shots hit beyond the weapon's reach", n)
+		}
+		// Broken arms halve accuracy
+		m.Player.BodyParts[game.BodyPartArms].Broken = true
+		if n := countHits(m, w, from.Add(util.NewPoint(2, 0)), 200); n < 70 || n > 110 {
+			t.Errorf("%d of 200 shots hit with broken arms", n)
+		}
+	})
+}
+
+func TestShotsStop(t *testing.T) {
+	m, w, o := newRange(t)
+	from := m.Player.Position
+	util.WithSeed(1, func() {
+		// Closed doors stop shots like walls
+		d := game.NewItem("Door", m.Now, false)
+		d.Position = from.Add(util.NewPoint(1, 0))
+		m.PlaceItem(d, true)
+		if n := countHits(m, w, from.Add(util.NewPoint(2, 0)), 50); n != 0 {
+			t.Errorf("%d of 50 shots hit through a closed door", n)
+		}
+		m.RemoveItem(d)
+		// So do vehicles
+		w.Range = 8
+		v := game.GenerateVehicle("Street", m.Now)
+		v.Bounds = v.Bounds.Move(o.Add(util.NewPoint(6, 6)))
+		if !m.PlaceVehicle(v) {
+			t.Fatal("failed to place the vehicle")
+		}
+		y := -1
+		for p := v.Bounds.TL; y < 0 && p.Y <= v.Bounds.BR.Y; p.Y++ {
+			for p.X = v.Bounds.TL.X; p.X <= v.Bounds.BR.X; p.X++ {
+				if l := v.GetLocationAbsolute(p); l != nil && l.Solid {
+					y = p.Y
+					break
+				}
+			}
+		}
+		if y < 0 {
+			t.Fatal("vehicle has no solid locations")
+		}
+		m.Player.Position = util.NewPoint(o.X+3, y)
+		if n := countHits(m, w, util.NewPoint(o.X+13, y), 50); n != 0 {
+			t.Errorf("%d of 50 shots hit through a vehicle", n)
+		}
+	})
+}
+
+func TestThrownLanding(t *testing.T) {
+	m, _, _ := newRange(t)
+	from := m.Player.Position
+	throw := func(target util.Point) *game.Item {
+		b := game.NewItem("Brick", m.Now, false)
+		m.Player.Weapon = nil
+		m.Player.WieldItem(b)
+		m.Player.Stamina = 1
+		if !m.PlayerFire(target) {
+			t.Fatal("failed to throw the brick")
+		}
+		if m.Player.Weapon != nil {
+			t.Fatal("thrown brick is still wielded")
+		}
+		return b
+	}
+	// Thrown weapons come down at the target rather than carrying on
+	target := from.Add(util.NewPoint(4, 0))
+	if b := throw(target); b.Position != target || !slices.Contains(m.ItemsAt(target), b) {
+		t.Fatalf("brick thrown at %v landed at %v", target, b.Position)
+	}
+	// Unless something gets in the way
+	d := game.NewItem("Door", m.Now, false)
+	d.Position = from.Add(util.NewPoint(2, 0))
+	m.PlaceItem(d, true)
+	if b := throw(target); b.Position != from.Add(util.NewPoint(1, 0)) {
+		t.Fatalf("brick thrown at a closed door landed at %v", b.Position)
+	}
+}
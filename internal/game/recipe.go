@@ -0,0 +1,273 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+func init() {
+	RegisterActivity("Craft", &ActivityKind{
+		Interrupts: InterruptAll,
+		Start:      startCraft,
+		Stop:       stopCraft,
+	})
+}
+
+// RecipeDefs is the map of all recipe definitions.
+var RecipeDefs = map[string]*Recipe{}
+
+// Recipe describes how to craft one or more items from others.
+type Recipe struct {
+	ID       string         // Unique ID
+	Name     string         // Descriptive name
+	Inputs   map[string]int // Item templates and amounts consumed
+	Tools    []string       // Item templates that must be at hand but are not consumed
+	Fixtures []string       // Fixed item templates that must be within reach, like an oven
+	Time     util.Duration  // Time it takes to craft the recipe
+	Hunger   float64        // Amount of hunger the work costs
+	Thirst   float64        // Amount of thirst the work costs
+	Outputs  map[string]int // Item templates and amounts produced
+}
+
+// Validate returns an error if the recipe references items that do not exist or
+// is otherwise malformed. This must be called on all recipes after item loading
+// is complete.
+func (r *Recipe) Validate() error {
+	if len(r.Outputs) < 1 {
+		return fmt.Errorf("recipe %s has no outputs", r.ID)
+	}
+	if r.Time < 0 {
+		return fmt.Errorf("recipe %s has a negative time", r.ID)
+	}
+	check := func(what string, items map[string]int) error {
+		for tid, n := range items {
+			if _, found := ItemDefs[tid]; !found {
+				return fmt.Errorf("recipe %s %s references non-existent item %s", r.ID, what, tid)
+			}
+			if n < 1 {
+				return fmt.Errorf("recipe %s %s %s amount must be at least one", r.ID, what, tid)
+			}
+		}
+		return nil
+	}
+	if err := check("input", r.Inputs); err != nil {
+		return err
+	}
+	if err := check("output", r.Outputs); err != nil {
+		return err
+	}
+	for _, tid := range r.Tools {
+		if _, found := ItemDefs[tid]; !found {
+			return fmt.Errorf("recipe %s tool references non-existent item %s", r.ID, tid)
+		}
+	}
+	for _, tid := range r.Fixtures {
+		i, found := ItemDefs[tid]
+		if !found {
+			return fmt.Errorf("recipe %s fixture references non-existent item %s", r.ID, tid)
+		}
+		if !i.Fixed {
+			return fmt.Errorf("recipe %s fixture %s is not a fixed item", r.ID, tid)
+		}
+	}
+	return nil
+}
+
+// craftSource is an item available to the player for crafting.
+type craftSource struct {
+	item   *Item       // The item
+	remove func() bool // Removes the item from where it is, nil if the item may not be consumed
+}
+
+// craftSources returns all items available to the player for crafting. These
+// are the player's equipment and inventory, items within reach and the contents
+// of all containers among them.
+func (m *CityMap) craftSources() []craftSource {
+	var ret []craftSource
+	var contents func(*Item)
+	contents = func(c *Item) {
+		for _, i := range c.Inventory {
+			i := i
+			ret = append(ret, craftSource{
+				item:   i,
+				remove: func() bool { return c.RemoveItem(i) },
+			})
+			if i.Container {
+				contents(i)
+			}
+		}
+	}
+	a := &m.Player.Actor
+	// Equipment may be used as tools but is never consumed
+	if a.Weapon != nil {
+		ret = append(ret, craftSource{item: a.Weapon})
+	}
+	for _, i := range a.WornItems {
+		if i == nil {
+			continue
+		}
+		ret = append(ret, craftSource{item: i})
+		if i.Container {
+			contents(i)
+		}
+	}
+	for _, i := range a.Inventory {
+		i := i
+		ret = append(ret, craftSource{
+			item:   i,
+			remove: func() bool { return a.RemoveItemFromInventory(i) },
+		})
+		if i.Container {
+			contents(i)
+		}
+	}
+	// ItemsWithin re-uses its return slice
+	reach := slices.Clone(m.ItemsWithin(util.NewRectFromRadius(a.Position, 1)))
+	for _, i := range reach {
+		i := i
+		s := craftSource{item: i}
+		if !i.Fixed {
+			s.remove = func() bool { return m.RemoveItem(i) }
+		}
+		ret = append(ret, s)
+		if i.Container {
+			contents(i)
+		}
+	}
+	return ret
+}
+
+// sortedKeys returns the keys of the map in sorted order so that iteration is
+// deterministic.
+func sortedKeys(m map[string]int) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// consumeInputs removes the item templates and amounts from the items available
+// to the player for crafting. The caller must first check that enough of each
+// is available.
+func (m *CityMap) consumeInputs(inputs map[string]int) {
+	sources := m.craftSources()
+	for _, tid := range sortedKeys(inputs) {
+		need := inputs[tid]
+		for _, s := range sources {
+			if need < 1 {
+				break
+			}
+			if s.item.TemplateID != tid || s.item.Destroyed || s.remove == nil {
+				continue
+			}
+			n := s.item.StackAmount()
+			if n > need {
+				s.item.Amount = n - need
+				break
+			}
+			s.remove()
+			need -= n
+		}
+	}
+}
+
+// Missing returns a description of each requirement of the recipe the player
+// does not currently meet. An empty slice means the recipe may be crafted.
+func (r *Recipe) Missing(m *CityMap) []string {
+	var ret []string
+	sources := m.craftSources()
+	have := func(tid string, consumable, fixed bool) int {
+		n := 0
+		for _, s := range sources {
+			if s.item.TemplateID != tid || s.item.Destroyed ||
+				(consumable && s.remove == nil) || (fixed && !s.item.Fixed) {
+				continue
+			}
+			n += s.item.StackAmount()
+		}
+		return n
+	}
+	for _, tid := range sortedKeys(r.Inputs) {
+		if n := have(tid, true, false); n < r.Inputs[tid] {
+			ret = append(ret, fmt.Sprintf("%d more %s", r.Inputs[tid]-n, ItemDefs[tid].Name))
+		}
+	}
+	for _, tid := range r.Tools {
+		if have(tid, false, false) < 1 {
+			ret = append(ret, "a "+ItemDefs[tid].Name)
+		}
+	}
+	for _, tid := range r.Fixtures {
+		if have(tid, false, true) < 1 {
+			ret = append(ret, "a nearby "+ItemDefs[tid].Name)
+		}
+	}
+	if r.Hunger > 0 && m.Player.Hunger < r.Hunger {
+		ret = append(ret, "a full stomach")
+	}
+	if r.Thirst > 0 && m.Player.Thirst < r.Thirst {
+		ret = append(ret, "something to drink")
+	}
+	return ret
+}
+
+// Craft has the player craft the recipe as an activity, calling update after
+// every step. Inputs are consumed and outputs placed into the player's
+// inventory only if the recipe can still be crafted once the time has passed.
+// Interrupted crafting may be resumed with ResumeActivity. Returns true on
+// success.
+func (m *CityMap) Craft(r *Recipe, update func()) bool {
+	a := NewActivity("Craft", "making "+r.Name, time.Duration(r.Time))
+	a.SArg = r.ID
+	return m.StartActivity(a, update)
+}
+
+// startCraft checks that the player has everything needed to craft the recipe
+// of the activity.
+func startCraft(m *CityMap, a *Activity) string {
+	r, found := RecipeDefs[a.SArg]
+	if !found {
+		return "You no longer know how to make that."
+	}
+	if missing := r.Missing(m); len(missing) > 0 {
+		return fmt.Sprintf("To make %s you need %s.", r.Name, strings.Join(missing, ", "))
+	}
+	return ""
+}
+
+// stopCraft finishes crafting the recipe of the activity if it was completed.
+func stopCraft(m *CityMap, a *Activity, completed bool) bool {
+	if !completed {
+		return false
+	}
+	r := RecipeDefs[a.SArg]
+	if len(r.Missing(m)) > 0 {
+		Log.Log(termui.ColorYellow, "You were unable to finish making %s.", r.Name)
+		return false
+	}
+	m.consumeInputs(r.Inputs)
+	m.Player.Hunger -= r.Hunger
+	m.Player.Thirst -= r.Thirst
+	// Produce outputs
+	for _, tid := range sortedKeys(r.Outputs) {
+		n := r.Outputs[tid]
+		if ItemDefs[tid].Stackable {
+			i := NewItem(tid, m.Now, true)
+			i.Amount = n
+			m.Player.AddItemToInventory(i)
+			continue
+		}
+		for ; n > 0; n-- {
+			m.Player.AddItemToInventory(NewItem(tid, m.Now, true))
+		}
+	}
+	Log.Log(termui.ColorLime, "You make %s.", r.Name)
+	return true
+}
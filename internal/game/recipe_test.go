@@ -0,0 +1,118 @@
+package game_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/qbradq/after/internal/citygen"
+	_ "github.com/qbradq/after/internal/events"
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// lastLog keeps the last line logged.
+type lastLog struct {
+	line string
+}
+
+// Log implements game.Logger.
+func (l *lastLog) Log(c termui.Color, s string, args ...any) {
+	l.line = fmt.Sprintf(s, args...)
+}
+
+// newQuietCity generates a combat test city in a scratch save and clears the
+// actors around the player and the skies so nothing interrupts the test.
+func newQuietCity(t *testing.T, seed int64) *game.CityMap {
+	t.Helper()
+	if err := game.NewScratchSave(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(game.CloseSave)
+	m, err := citygen.Generate("Interstate Town", "CombatTest", seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Clear the actors before they get a chance to act
+	if err := m.EnsureLoadedAround(m.Player.Position); err != nil {
+		t.Fatal(err)
+	}
+	b := util.NewRectFromRadius(m.Player.Position, 32)
+	for _, a := range slices.Clone(m.ActorsWithin(b)) {
+		m.RemoveActor(a)
+	}
+	if err := m.Update(m.Player.Position, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	m.Weather = game.Weather{Until: m.Now.Add(time.Hour * 24 * 365)}
+	return m
+}
+
+func TestCraftWaterJar(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	log := &lastLog{}
+	game.Log = log
+	m := newQuietCity(t, 1)
+	r := game.RecipeDefs["WaterJar"]
+	if got := r.Missing(m); !slices.Equal(got, []string{"1 more glass jar", "a nearby sink"}) {
+		t.Fatalf("missing %q", got)
+	}
+	jar := game.NewItem("Jar", m.Now, false)
+	m.Player.AddItemToInventory(jar)
+	if m.Craft(r, nil) {
+		t.Fatal("crafted without a sink")
+	}
+	sink := game.NewItem("Sink", m.Now, false)
+	sink.Position = m.Player.Position.Add(util.NewPoint(1, 0))
+	m.PlaceItem(sink, true)
+	if got := r.Missing(m); len(got) > 0 {
+		t.Fatalf("missing %q with a jar and sink", got)
+	}
+	start := m.Now
+	if !m.Craft(r, nil) {
+		t.Fatalf("craft failed: %s", log.line)
+	}
+	if d := m.Now.Sub(start); d != time.Minute {
+		t.Fatalf("crafting took %v", d)
+	}
+	var water int
+	for _, i := range m.Player.Inventory {
+		if i == jar {
+			t.Fatal("jar was not consumed")
+		}
+		if i.TemplateID == "WaterJar" {
+			water++
+		}
+	}
+	if water != 1 {
+		t.Fatalf("made %d water jars", water)
+	}
+	if sink.Destroyed || len(m.ItemsAt(sink.Position)) == 0 {
+		t.Fatal("sink fixture was consumed")
+	}
+}
+
+func TestCraftConsumesPartOfStack(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	m := newQuietCity(t, 2)
+	r := &game.Recipe{
+		ID:      "Test",
+		Name:    "test",
+		Inputs:  map[string]int{"Jar": 2},
+		Outputs: map[string]int{"WaterJar": 1},
+	}
+	game.RecipeDefs[r.ID] = r
+	defer delete(game.RecipeDefs, r.ID)
+	jars := game.NewItem("Jar", m.Now, false)
+	jars.Amount = 3
+	m.Player.AddItemToInventory(jars)
+	if !m.Craft(r, nil) {
+		t.Fatal("craft failed")
+	}
+	if jars.Amount != 1 {
+		t.Fatalf("expected 1 jar left, have %d", jars.Amount)
+	}
+}
@@ -0,0 +1,370 @@
+package game_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kelindar/bitmap"
+	_ "github.com/qbradq/after/internal/ai"
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/internal/mods"
+	"github.com/qbradq/after/lib/util"
+)
+
+// The fixtures below are written field by field the way each old version of a
+// record was written, independent of the record layouts they test.
+
+func TestMain(m *testing.M) {
+	if err := mods.Discover("../../mods"); err != nil {
+		panic(err)
+	}
+	if err := mods.LoadMods([]string{"Base"}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// fixtureTime is the time stored in all fixtures.
+var fixtureTime = time.Date(2030, time.May, 1, 8, 0, 0, 0, time.UTC)
+
+// putItem writes a version ver item record with one contained item.
+func putItem(w io.Writer, ver uint32, contained bool) {
+	util.PutUint32(w, ver)                // Version
+	util.PutString(w, "Crowbar")          // Template ID
+	util.PutPoint(w, util.NewPoint(3, 4)) // Map position
+	util.PutTime(w, fixtureTime)          // Time of last update
+	util.PutUint32(w, 2)                  // Stack amount
+	util.PutString(w, "sarg")             // Generic string argument
+	util.PutTime(w, fixtureTime)          // Generic time argument
+	if ver >= 1 {
+		util.PutFloat(w, 0.25) // Spoilage
+	}
+	if ver >= 2 {
+		util.PutFloat(w, 0.5) // Wear
+	}
+	if ver >= 3 {
+		util.PutFloat(w, 7) // Bash damage
+	}
+	if !contained {
+		util.PutUint16(w, 0) // Contents
+		return
+	}
+	util.PutUint16(w, 1)
+	putItem(w, ver, false)
+}
+
+// checkItem checks an item decoded from a putItem fixture of version ver.
+func checkItem(t *testing.T, i *game.Item, ver uint32, contained bool) {
+	t.Helper()
+	if i.TemplateID != "Crowbar" || i.Position != util.NewPoint(3, 4) || i.Amount != 2 ||
+		i.SArg != "sarg" || !i.TArg.Equal(fixtureTime) || !i.LastUpdate.Equal(fixtureTime) {
+		t.Fatalf("version %d item base fields decoded wrong: %+v", ver, i)
+	}
+	for _, f := range []struct {
+		name  string
+		since uint32
+		got   float64
+		want  float64
+	}{
+		{"spoilage", 1, i.Spoilage, 0.25},
+		{"wear", 2, i.Wear, 0.5},
+		{"bash damage", 3, i.BashDamage, 7},
+	} {
+		if ver < f.since {
+			f.want = 0
+		}
+		if f.got != f.want {
+			t.Errorf("version %d item %s is %f, expected %f", ver, f.name, f.got, f.want)
+		}
+	}
+	if !contained {
+		if len(i.Inventory) != 0 {
+			t.Fatalf("version %d item has unexpected contents", ver)
+		}
+		return
+	}
+	if len(i.Inventory) != 1 {
+		t.Fatalf("version %d item has %d contained items, expected 1", ver, len(i.Inventory))
+	}
+	checkItem(t, i.Inventory[0], ver, false)
+}
+
+func TestItemVersions(t *testing.T) {
+	for ver := uint32(0); ver <= 3; ver++ {
+		w := bytes.NewBuffer(nil)
+		putItem(w, ver, true)
+		i, err := game.NewItemFromReader(w)
+		if err != nil {
+			t.Fatalf("version %d item: %v", ver, err)
+		}
+		checkItem(t, i, ver, true)
+		if w.Len() != 0 {
+			t.Fatalf("version %d item left %d bytes unread", ver, w.Len())
+		}
+		// Upgraded records round-trip in the current layout
+		i.Write(w)
+		ri, err := game.NewItemFromReader(w)
+		if err != nil {
+			t.Fatalf("version %d item after upgrade: %v", ver, err)
+		}
+		checkItem(t, ri, ver, true)
+	}
+}
+
+// putChunk writes a version ver chunk record of level z made of wall tiles
+// holding one item.
+func putChunk(w io.Writer, ver uint32, z int) {
+	util.PutUint32(w, ver) // Version
+	for i := 0; i < game.ChunkWidth*game.ChunkHeight; i++ {
+		util.PutUint16(w, 0) // Tile map
+	}
+	util.PutUint16(w, 1) // Items
+	item := game.NewItem("Crowbar", fixtureTime, false)
+	item.Write(w)
+	util.PutUint16(w, 0) // Actors
+	util.PutUint16(w, 0) // Vehicles
+	var seen bitmap.Bitmap
+	seen.Set(5)
+	seen.WriteTo(w) // Remembered bitmap
+	if ver >= 1 {   // Scent layer
+		util.PutBool(w, true)
+		util.PutTime(w, fixtureTime)
+		for i := 0; i < game.ChunkWidth*game.ChunkHeight; i++ {
+			util.PutUint32(w, uint32(i))
+		}
+	}
+	if ver >= 2 { // Tile damage
+		util.PutUint16(w, 1)
+		util.PutUint16(w, 9)
+		util.PutFloat(w, 3)
+	}
+	if ver >= 3 { // Level
+		util.PutByte(w, byte(int8(z)))
+	}
+}
+
+// useWallCrossRef makes tile cross reference zero refer to the wall tile.
+func useWallCrossRef() *game.TileDef {
+	wall := game.TileDefs[game.TileRefs["Wall"]]
+	game.TileCrossRefs = []*game.TileDef{wall}
+	game.TileRefMap = map[game.TileCrossRef]string{0: wall.ID}
+	game.TileCrossRefForRef = map[game.TileRef]game.TileCrossRef{wall.BackRef: 0}
+	return wall
+}
+
+// checkChunk checks a chunk decoded from a putChunk fixture of version ver.
+func checkChunk(t *testing.T, c *game.Chunk, ver uint32, wall *game.TileDef) {
+	t.Helper()
+	for idx, tile := range c.Tiles {
+		if tile != wall {
+			t.Fatalf("version %d chunk tile %d decoded wrong", ver, idx)
+		}
+	}
+	if len(c.Items) != 1 || c.Items[0].TemplateID != "Crowbar" {
+		t.Fatalf("version %d chunk items decoded wrong", ver)
+	}
+	if len(c.Actors) != 0 || len(c.Vehicles) != 0 {
+		t.Fatalf("version %d chunk has unexpected actors or vehicles", ver)
+	}
+	if !c.HasSeen.Contains(5) || c.HasSeen.Count() != 1 {
+		t.Fatalf("version %d chunk remembered bitmap decoded wrong", ver)
+	}
+	if ver < 1 {
+		if c.Scent != nil {
+			t.Fatalf("version %d chunk has a scent layer", ver)
+		}
+	} else if c.Scent == nil || c.Scent[17] != 17 || !c.ScentBase.Equal(fixtureTime) {
+		t.Fatalf("version %d chunk scent layer decoded wrong", ver)
+	}
+	if ver < 2 {
+		if c.TileDamage != nil {
+			t.Fatalf("version %d chunk has tile damage", ver)
+		}
+	} else if len(c.TileDamage) != 1 || c.TileDamage[9] != 3 {
+		t.Fatalf("version %d chunk tile damage decoded wrong", ver)
+	}
+}
+
+func TestChunkVersions(t *testing.T) {
+	wall := useWallCrossRef()
+	for ver := uint32(0); ver <= 3; ver++ {
+		w := bytes.NewBuffer(nil)
+		putChunk(w, ver, 0)
+		c := game.NewChunk(0, 0, 0)
+		if err := c.Read(w); err != nil {
+			t.Fatalf("version %d chunk: %v", ver, err)
+		}
+		checkChunk(t, c, ver, wall)
+		if w.Len() != 0 {
+			t.Fatalf("version %d chunk left %d bytes unread", ver, w.Len())
+		}
+		c.Write(w)
+		rc := game.NewChunk(0, 0, 0)
+		if err := rc.Read(w); err != nil {
+			t.Fatalf("version %d chunk after upgrade: %v", ver, err)
+		}
+		checkChunk(t, rc, ver, wall)
+	}
+}
+
+func TestChunkLevels(t *testing.T) {
+	useWallCrossRef()
+	p := game.OnLevel(util.NewPoint(0, 0), -1)
+	basement := uint32((p.Y/game.ChunkHeight)*game.CityMapWidth + p.X/game.ChunkWidth)
+	for _, tc := range []struct {
+		ver uint32 // Version of the record
+		z   int    // Level stored in the record
+		ref uint32 // Reference of the chunk read
+		ok  bool   // If true the record matches the chunk
+	}{
+		{2, 0, 0, true},
+		{2, 0, basement, false},
+		{3, -1, basement, true},
+		{3, -1, 0, false},
+		{3, 0, basement, false},
+	} {
+		w := bytes.NewBuffer(nil)
+		putChunk(w, tc.ver, tc.z)
+		c := game.NewChunk(0, int(tc.ref)/game.CityMapWidth, tc.ref)
+		err := c.Read(w)
+		if tc.ok && err != nil {
+			t.Errorf("version %d level %d chunk %d: %v", tc.ver, tc.z, tc.ref, err)
+		} else if !tc.ok && err == nil {
+			t.Errorf("version %d level %d chunk %d read without error", tc.ver, tc.z, tc.ref)
+		} else if tc.ok && c.Level != tc.z {
+			t.Errorf("version %d chunk %d is on level %d, expected %d", tc.ver, tc.ref, c.Level, tc.z)
+		}
+	}
+}
+
+// putDynamicData writes a version ver dynamic data record.
+func putDynamicData(w io.Writer, ver uint32) {
+	util.PutUint32(w, ver) // Version
+	p := game.NewPlayer(fixtureTime)
+	p.Write(w)                   // Player
+	util.PutTime(w, fixtureTime) // Current time
+	if ver >= 1 {                // Player activity
+		util.PutBool(w, true)
+		util.PutString(w, "Rest")
+		util.PutString(w, "resting")
+		util.PutString(w, "")
+		util.PutFloat(w, 0)
+		util.PutUint64(w, uint64(time.Hour))
+		util.PutUint64(w, uint64(time.Minute))
+		util.PutByte(w, 0)
+	}
+	if ver >= 2 && ver < 5 { // Hordes of member template IDs
+		util.PutUint32(w, 1)
+		util.PutPoint(w, util.NewPoint(1, 2))
+		util.PutPoint(w, util.NewPoint(30, 40))
+		util.PutTime(w, fixtureTime)
+		util.PutUint16(w, 2)
+		util.PutString(w, "Zombie")
+		util.PutString(w, "Zombie")
+	}
+	if ver >= 3 { // Faction reputations
+		util.PutUint16(w, 1)
+		util.PutString(w, "Raiders")
+		util.PutFloat(w, -0.9)
+	}
+	if ver >= 4 { // Weather, player body temperature and wetness
+		util.PutFloat(w, 0.5)
+		util.PutFloat(w, 0.75)
+		util.PutFloat(w, 12)
+		util.PutFloat(w, -5)
+		util.PutTime(w, fixtureTime)
+		util.PutFloat(w, 97.5)
+		util.PutFloat(w, 0.1)
+	}
+	if ver >= 5 { // Hordes of members
+		util.PutUint32(w, 1)
+		util.PutPoint(w, util.NewPoint(1, 2))
+		util.PutPoint(w, util.NewPoint(30, 40))
+		util.PutTime(w, fixtureTime)
+		util.PutUint16(w, 2)
+		for i := 0; i < 2; i++ {
+			a := game.NewActor("Zombie", fixtureTime, false)
+			a.BodyParts[game.BodyPartHead].Health = 0.5
+			a.Write(w)
+		}
+	}
+}
+
+// sameWeather returns true if the weather fronts are the same.
+func sameWeather(a, b game.Weather) bool {
+	ua, ub := a.Until, b.Until
+	a.Until, b.Until = time.Time{}, time.Time{}
+	return a == b && ua.Equal(ub)
+}
+
+func TestDynamicDataVersions(t *testing.T) {
+	if err := game.NewScratchSave(); err != nil {
+		t.Fatal(err)
+	}
+	defer game.CloseSave()
+	for ver := uint32(0); ver <= 5; ver++ {
+		w := bytes.NewBuffer(nil)
+		putDynamicData(w, ver)
+		if err := game.SaveValue("CityMap.DynamicData", w.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		m := &game.CityMap{}
+		if err := m.LoadDynamicData(); err != nil {
+			t.Fatalf("version %d dynamic data: %v", ver, err)
+		}
+		if m.Player == nil || !m.Now.Equal(fixtureTime) {
+			t.Fatalf("version %d dynamic data base fields decoded wrong", ver)
+		}
+		if a := m.Player.Activity; (ver >= 1) != (a != nil) ||
+			(a != nil && (a.Kind != "Rest" || a.Progress != time.Minute)) {
+			t.Errorf("version %d player activity decoded wrong: %+v", ver, a)
+		}
+		if ver >= 2 {
+			if len(m.Hordes) != 1 || len(m.Hordes[0].Members) != 2 ||
+				m.Hordes[0].Target != util.NewPoint(30, 40) ||
+				m.Hordes[0].Members[0].TemplateID != "Zombie" {
+				t.Fatalf("version %d hordes decoded wrong", ver)
+			}
+			// Members keep their state from version 5 on
+			want := 1.0
+			if ver >= 5 {
+				want = 0.5
+			}
+			if h := m.Hordes[0].Members[1].BodyParts[game.BodyPartHead].Health; h != want {
+				t.Errorf("version %d horde member head health %f, expected %f", ver, h, want)
+			}
+		} else if m.Hordes != nil {
+			t.Errorf("version %d dynamic data has hordes", ver)
+		}
+		if ver >= 3 {
+			if len(m.Reputations) != 1 || m.Reputations["Raiders"] != -0.9 {
+				t.Errorf("version %d reputations decoded wrong: %v", ver, m.Reputations)
+			}
+		} else if m.Reputations == nil || len(m.Reputations) != 0 {
+			t.Errorf("version %d reputations not reset: %v", ver, m.Reputations)
+		}
+		if ver >= 4 {
+			if m.Weather.Wind != 12 || m.Weather.TempOffset != -5 ||
+				m.Player.BodyTemp != 97.5 || m.Player.Wetness != 0.1 {
+				t.Errorf("version %d weather decoded wrong", ver)
+			}
+		} else if !sameWeather(m.Weather, game.Weather{}) {
+			t.Errorf("version %d dynamic data has weather", ver)
+		}
+		// Upgraded records round-trip in the current layout
+		if err := m.SaveDynamicData(); err != nil {
+			t.Fatal(err)
+		}
+		rm := &game.CityMap{}
+		if err := rm.LoadDynamicData(); err != nil {
+			t.Fatalf("version %d dynamic data after upgrade: %v", ver, err)
+		}
+		if len(rm.Hordes) != len(m.Hordes) || len(rm.Reputations) != len(m.Reputations) ||
+			!sameWeather(rm.Weather, m.Weather) || rm.Player.BodyTemp != m.Player.BodyTemp {
+			t.Errorf("version %d dynamic data changed after upgrade", ver)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// Repair is true when the current save was opened in repair mode. In repair
+// mode records that reference unknown item or actor templates are loaded as
+// placeholders instead of failing. Placeholders keep the original template ID
+// so the records are restored if the template becomes available again.
+var Repair bool
+
+// Repairs is the list of all substitutions made in repair mode since the save
+// was opened.
+var Repairs []string
+
+// LogRepair records a substitution made in repair mode.
+func LogRepair(f string, args ...any) {
+	s := fmt.Sprintf(f, args...)
+	Repairs = append(Repairs, s)
+	util.Log("repair: %s", s)
+	if Log != nil {
+		Log.Log(termui.ColorYellow, "Repair: %s", s)
+	}
+}
+
+// placeholderItem returns a new placeholder item for the unknown template.
+func placeholderItem(template string) *Item {
+	LogRepair("replaced unknown item %s with a placeholder", template)
+	return &Item{
+		TemplateID: template,
+		Name:       "unknown item",
+		Rune:       "?",
+		Fg:         termui.ColorFuchsia,
+		Bg:         termui.ColorBlack,
+		Container:  true,
+	}
+}
+
+// placeholderActor returns a new placeholder actor for the unknown template.
+func placeholderActor(template string) *Actor {
+	LogRepair("replaced unknown actor %s with a placeholder", template)
+	a := &Actor{
+		TemplateID: template,
+		AITemplate: "Nil",
+		Name:       "unknown creature",
+		Rune:       "?",
+		Fg:         termui.ColorFuchsia,
+		Bg:         termui.ColorBlack,
+		Speed:      1,
+	}
+	for i := range a.BodyParts {
+		a.BodyParts[i].Which = BodyPartCode(i)
+		a.BodyParts[i].Health = 1
+	}
+	return a
+}
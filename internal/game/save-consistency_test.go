@@ -0,0 +1,130 @@
+package game_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/qbradq/after/internal/citygen"
+	_ "github.com/qbradq/after/internal/events"
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
+)
+
+// savedState describes what the save database holds for the chunks within a
+// region of the ground level.
+type savedState struct {
+	chunks     int // Number of chunk records
+	population int // Number of actors in the chunk records and hordes
+}
+
+// readSavedState reads the saved state of the chunks within r, given in chunks,
+// from the open save.
+func readSavedState(t *testing.T, r util.Rect) savedState {
+	t.Helper()
+	var ret savedState
+	var p util.Point
+	for p.Y = r.TL.Y; p.Y <= r.BR.Y; p.Y++ {
+		for p.X = r.TL.X; p.X <= r.BR.X; p.X++ {
+			ref := uint32(p.Y*game.CityMapWidth + p.X)
+			buf, err := game.LoadValue(fmt.Sprintf("Chunk-%d", ref))
+			if errors.Is(err, game.ErrValueNotFound) {
+				continue
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := game.NewChunk(p.X, p.Y, ref)
+			if err := c.Read(buf); err != nil {
+				t.Fatal(err)
+			}
+			ret.chunks++
+			ret.population += len(c.Actors)
+		}
+	}
+	m := game.NewCityMap()
+	if err := m.LoadDynamicData(); err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range m.Hordes {
+		ret.population += len(h.Members)
+	}
+	return ret
+}
+
+func TestCrashConsistency(t *testing.T) {
+	defer func(r string) { game.SaveRoot = r }(game.SaveRoot)
+	game.SaveRoot = t.TempDir()
+	if err := game.NewSave("crash", []string{"Base"}, 3); err != nil {
+		t.Fatal(err)
+	}
+	defer game.CloseSave()
+	var id string
+	for _, si := range game.Saves {
+		if si.Name == "crash" {
+			id = si.ID
+		}
+	}
+	m, err := citygen.Generate("Interstate Town", "CombatTest", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SaveCityPlan(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Update(m.Player.Position, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.FullSave(); err != nil {
+		t.Fatal(err)
+	}
+	// Walk far enough toward the center of the city to generate new chunks
+	// and purge old ones from memory
+	start := util.NewPoint(m.Player.Position.X/game.ChunkWidth, m.Player.Position.Y/game.ChunkHeight)
+	dx := 11
+	if start.X > game.CityMapWidth/2 {
+		dx = -11
+	}
+	end := start
+	end.X += dx * 12
+	region := util.NewRect(start, end).Grow(5)
+	saved := readSavedState(t, region)
+	walk(t, m, start, dx)
+	// Die without saving and check nothing changed since the last full save
+	game.CloseSave()
+	if err := game.LoadSave(id, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := readSavedState(t, region); got != saved {
+		t.Fatalf("save changed outside of a full save, saved %+v, found %+v", saved, got)
+	}
+	// Once the save is loaded again a full save stores everything the walk
+	// generates
+	m = game.NewCityMap()
+	if err := m.LoadCityPlan(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.LoadDynamicData(); err != nil {
+		t.Fatal(err)
+	}
+	walk(t, m, start, dx)
+	if err := m.FullSave(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readSavedState(t, region); got.chunks <= saved.chunks {
+		t.Fatalf("expected more than %d chunks after a full save, found %d", saved.chunks, got.chunks)
+	}
+}
+
+// walk moves the player twelve steps of dx chunks from the start chunk,
+// updating the city at every step.
+func walk(t *testing.T, m *game.CityMap, start util.Point, dx int) {
+	t.Helper()
+	for i := 1; i <= 12; i++ {
+		cp := util.NewPoint(start.X+dx*i, start.Y)
+		m.Player.Position = util.NewPoint(cp.X*game.ChunkWidth+game.ChunkWidth/2, cp.Y*game.ChunkHeight+game.ChunkHeight/2)
+		if err := m.Update(m.Player.Position, 0, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
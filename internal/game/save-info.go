@@ -4,8 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 // Saves is the map of all save information.
@@ -13,23 +14,61 @@ var Saves = map[string]*SaveInfo{}
 
 // SaveInfo holds metadata about a save.
 type SaveInfo struct {
-	ID   string   // Save ID, save path is saves/[ID]/
-	Name string   // Human-readable
-	Mods []string // List of mods used when creating the save
+	ID       string        // Save ID, save path is [SaveRoot]/[ID]
+	Name     string        // Human-readable
+	Mods     []string      // List of mods used when creating the save
+	Seed     int64         // World generation seed
+	PlayTime time.Duration // Total time the save has been open
+
+	EngineVersion string // Version of the engine that last opened the save
+	SaveVersion   uint32 // Version of the save format, see SaveVersion
+}
+
+// Save information of the currently open save, if any
+var saveInfo *SaveInfo
+
+// Time at which play time was last accumulated into saveInfo
+var playStart time.Time
+
+// writeSaveInfo writes the save information file for the save.
+func writeSaveInfo(si *SaveInfo) error {
+	if err := ensureSaveRoot(); err != nil {
+		return err
+	}
+	d, err := json.Marshal(si)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(saveInfoPath(si.ID), d, 0644)
+}
+
+// updatePlayTime adds the time since the last update to the play time of the
+// open save and writes the save information file.
+func updatePlayTime() error {
+	if saveInfo == nil {
+		return nil
+	}
+	now := time.Now()
+	saveInfo.PlayTime += now.Sub(playStart)
+	playStart = now
+	return writeSaveInfo(saveInfo)
 }
 
 // LoadSaveInfo refreshes all saves data.
 func LoadSaveInfo() error {
 	Saves = map[string]*SaveInfo{}
-	files, err := os.ReadDir("saves")
+	if err := ensureSaveRoot(); err != nil {
+		return err
+	}
+	files, err := os.ReadDir(SaveRoot)
 	if err != nil {
 		return err
 	}
 	for _, file := range files {
-		if file.IsDir() || path.Ext(file.Name()) != ".json" {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
 			continue
 		}
-		p := path.Join("saves", file.Name())
+		p := filepath.Join(SaveRoot, file.Name())
 		d, err := os.ReadFile(p)
 		if err != nil {
 			return err
@@ -38,7 +77,7 @@ func LoadSaveInfo() error {
 		if err := json.Unmarshal(d, &si); err != nil {
 			return err
 		}
-		si.ID = strings.TrimRight(file.Name(), ".json")
+		si.ID = strings.TrimSuffix(file.Name(), ".json")
 		if _, found := Saves[si.ID]; found {
 			return fmt.Errorf("duplicate save ID %s", si.ID)
 		}
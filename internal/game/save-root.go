@@ -0,0 +1,48 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SaveRoot is the directory all saves are stored in. It defaults to the after
+// directory within the XDG data directory and is created as needed.
+var SaveRoot = DefaultSaveRoot()
+
+// legacySaveRoot is the save root used before saves moved to the XDG data
+// directory, relative to the working directory.
+const legacySaveRoot = "saves"
+
+// DefaultSaveRoot returns the default save root. This is $XDG_DATA_HOME/after/saves,
+// falling back to ~/.local/share/after/saves if XDG_DATA_HOME is not set and
+// to saves in the working directory if the home directory is unknown. If the
+// saves directory already exists in the working directory it is used instead
+// so games saved before the move stay where they are.
+func DefaultSaveRoot() string {
+	if fi, err := os.Stat(legacySaveRoot); err == nil && fi.IsDir() {
+		return legacySaveRoot
+	}
+	if d := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(d) {
+		return filepath.Join(d, "after", "saves")
+	}
+	if h, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(h, ".local", "share", "after", "saves")
+	}
+	return legacySaveRoot
+}
+
+// ensureSaveRoot creates the save root if it does not already exist.
+func ensureSaveRoot() error {
+	return os.MkdirAll(SaveRoot, 0755)
+}
+
+// savePath returns the path of the save database for the given save ID.
+func savePath(id string) string {
+	return filepath.Join(SaveRoot, id)
+}
+
+// saveInfoPath returns the path of the save information file for the given
+// save ID.
+func saveInfoPath(id string) string {
+	return filepath.Join(SaveRoot, id+".json")
+}
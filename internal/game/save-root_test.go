@@ -0,0 +1,31 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultSaveRoot(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	xdg := filepath.Join(dir, "data")
+	t.Setenv("XDG_DATA_HOME", xdg)
+	if got, want := DefaultSaveRoot(), filepath.Join(xdg, "after", "saves"); got != want {
+		t.Fatalf("expected save root %s, got %s", want, got)
+	}
+	// Saves from before the move to the data directory stay in use
+	if err := os.Mkdir(legacySaveRoot, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := DefaultSaveRoot(); got != legacySaveRoot {
+		t.Fatalf("expected existing save root %s, got %s", legacySaveRoot, got)
+	}
+}
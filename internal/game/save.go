@@ -2,25 +2,39 @@ package game
 
 import (
 	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path"
+	"path/filepath"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/qbradq/after/lib/util"
 	"go.etcd.io/bbolt"
 )
 
 // Global save database handle
 var save *bbolt.DB
 
-// NewSave creates a new save with the given name.
-func NewSave(name string, mods []string) error {
+// Write transaction in progress, see SaveTransaction
+var saveTx *bbolt.Tx
+
+// Temporary directory holding the current scratch save, if any
+var scratchDir string
+
+// ErrValueNotFound is returned by LoadValue when the key is not present in the
+// save database.
+var ErrValueNotFound = errors.New("value not found in save")
+
+// ErrNoSave is returned by SaveValue and LoadValue when no save is open.
+var ErrNoSave = errors.New("no save open")
+
+// NewSave creates a new save with the given name and world seed.
+func NewSave(name string, mods []string, seed int64) error {
 	s := uuid.NewString()
 	CloseSave()
-	p := path.Join("saves", s)
+	p := savePath(s)
 	_, err := os.Stat(p)
 	if !os.IsNotExist(err) {
 		if err == nil {
@@ -29,37 +43,74 @@ func NewSave(name string, mods []string) error {
 		return err
 	}
 	si := &SaveInfo{
-		ID:   s,
-		Name: name,
-		Mods: mods,
+		ID:            s,
+		Name:          name,
+		Mods:          mods,
+		Seed:          seed,
+		EngineVersion: EngineVersion,
+		SaveVersion:   SaveVersion,
 	}
-	d, err := json.Marshal(si)
-	if err != nil {
+	if err := writeSaveInfo(si); err != nil {
 		return err
 	}
-	os.WriteFile(p+".json", d, 0664)
 	Saves[si.ID] = si
 	return openSave(si)
 }
 
-// LoadSave loads the named save file.
-func LoadSave(id string) error {
+// LoadSave loads the named save file. If repair is true the save is opened in
+// repair mode, see Repair.
+func LoadSave(id string, repair bool) error {
 	CloseSave()
+	Repair = repair
 	save, found := Saves[id]
 	if !found {
 		return fmt.Errorf("save file %s not found", id)
 	}
-	p := path.Join("saves", id)
-	if _, err := os.Stat(p); err != nil {
+	if save.SaveVersion > SaveVersion {
+		return fmt.Errorf("save %s is version %d but only versions up to %d are supported",
+			save.Name, save.SaveVersion, SaveVersion)
+	}
+	if _, err := os.Stat(savePath(id)); err != nil {
+		return err
+	}
+	if err := openSave(save); err != nil {
+		return err
+	}
+	// Older records are upgraded as they are loaded and saved, so record the
+	// current versions
+	if save.SaveVersion < SaveVersion || save.EngineVersion != EngineVersion {
+		save.SaveVersion = SaveVersion
+		save.EngineVersion = EngineVersion
+		return writeSaveInfo(save)
+	}
+	return nil
+}
+
+// NewScratchSave creates a new, unnamed save in a temporary directory. The save
+// is removed when it is closed. This is used by headless simulations.
+func NewScratchSave() error {
+	CloseSave()
+	d, err := os.MkdirTemp("", "after-")
+	if err != nil {
 		return err
 	}
-	return openSave(save)
+	scratchDir = d
+	return openDB(filepath.Join(d, "save"))
 }
 
 // openSave blindly opens the named save.
 func openSave(si *SaveInfo) error {
-	p := path.Join("saves", si.ID)
-	db, err := bbolt.Open(p, 0664, &bbolt.Options{
+	if err := openDB(savePath(si.ID)); err != nil {
+		return err
+	}
+	saveInfo = si
+	playStart = time.Now()
+	return nil
+}
+
+// openDB blindly opens the save database at the given path.
+func openDB(p string) error {
+	db, err := bbolt.Open(p, 0644, &bbolt.Options{
 		Timeout: 1 * time.Second,
 	})
 	if err != nil {
@@ -71,44 +122,156 @@ func openSave(si *SaveInfo) error {
 		}
 		return nil
 	}); err != nil {
-		panic(err)
+		db.Close()
+		return err
 	}
 	save = db
-	LoadTileRefs()
+	if err := LoadTileRefs(); err != nil {
+		CloseSave()
+		return err
+	}
 	return nil
 }
 
 // CloseSave closes the save file and should be called!
 func CloseSave() {
+	if err := updatePlayTime(); err != nil {
+		util.Log("error updating play time: %v", err)
+	}
+	saveInfo = nil
 	if save != nil {
 		save.Close()
 		save = nil
 	}
+	if scratchDir != "" {
+		os.RemoveAll(scratchDir)
+		scratchDir = ""
+	}
+	Repair = false
+	Repairs = nil
+}
+
+// SaveTransaction calls fn with all calls to SaveValue batched into a single
+// database transaction. Either all of the values are saved or, if fn returns an
+// error, none of them are. Nested calls join the outer transaction.
+func SaveTransaction(fn func() error) error {
+	if save == nil {
+		return ErrNoSave
+	}
+	if saveTx != nil {
+		return fn()
+	}
+	return save.Update(func(tx *bbolt.Tx) error {
+		saveTx = tx
+		defer func() { saveTx = nil }()
+		return fn()
+	})
 }
 
-// SaveValue saves the given data to the save database or panics.
-func SaveValue(key string, value []byte) {
-	if err := save.Update(func(tx *bbolt.Tx) error {
+// SaveValue saves the given data to the save database. Within SaveTransaction
+// value must not be modified until the transaction completes.
+func SaveValue(key string, value []byte) error {
+	if save == nil {
+		return ErrNoSave
+	}
+	put := func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte("After"))
-		err := b.Put([]byte(key), value)
-		return err
-	}); err != nil {
-		panic(err)
+		return b.Put([]byte(key), value)
+	}
+	var err error
+	if saveTx != nil {
+		err = put(saveTx)
+	} else {
+		err = save.Update(put)
+	}
+	if err != nil {
+		return fmt.Errorf("saving %s: %w", key, err)
 	}
+	return nil
 }
 
-// LoadValue returns a reader with the requested data or panics.
-func LoadValue(key string) io.Reader {
+// LoadValue returns a reader with the requested data. ErrValueNotFound is
+// returned if the key does not exist.
+func LoadValue(key string) (io.Reader, error) {
+	if save == nil {
+		return nil, ErrNoSave
+	}
 	var buf []byte
-	save.View(func(tx *bbolt.Tx) error {
+	get := func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte("After"))
 		d := b.Get([]byte(key))
 		buf = make([]byte, len(d))
 		copy(buf, d)
 		return nil
-	})
+	}
+	var err error
+	if saveTx != nil {
+		err = get(saveTx)
+	} else {
+		err = save.View(get)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", key, err)
+	}
 	if len(buf) == 0 {
-		return nil
+		return nil, fmt.Errorf("loading %s: %w", key, ErrValueNotFound)
+	}
+	return bytes.NewReader(buf), nil
+}
+
+// ExportSave writes a consistent copy of the save database of the given save to
+// w. The save must not be open.
+func ExportSave(id string, w io.Writer) error {
+	if saveInfo != nil && saveInfo.ID == id {
+		return fmt.Errorf("save %s is open", id)
+	}
+	if _, found := Saves[id]; !found {
+		return fmt.Errorf("save file %s not found", id)
+	}
+	db, err := bbolt.Open(savePath(id), 0644, &bbolt.Options{
+		Timeout:  1 * time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return err
 	}
-	return bytes.NewReader(buf)
+	defer db.Close()
+	return db.View(func(tx *bbolt.Tx) error {
+		_, err := tx.WriteTo(w)
+		return err
+	})
+}
+
+// ImportSave creates a new save from the save information and the save database
+// read from r as written by ExportSave. The save is given a new ID which is
+// set in si.
+func ImportSave(si *SaveInfo, r io.Reader) error {
+	if si.SaveVersion > SaveVersion {
+		return fmt.Errorf("save %s is version %d but only versions up to %d are supported",
+			si.Name, si.SaveVersion, SaveVersion)
+	}
+	if err := ensureSaveRoot(); err != nil {
+		return err
+	}
+	si.ID = uuid.NewString()
+	p := savePath(si.ID)
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(p)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(p)
+		return err
+	}
+	if err := writeSaveInfo(si); err != nil {
+		os.Remove(p)
+		return err
+	}
+	Saves[si.ID] = si
+	return nil
 }
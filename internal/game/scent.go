@@ -0,0 +1,109 @@
+package game
+
+import (
+	"io"
+	"time"
+
+	"github.com/qbradq/after/lib/util"
+)
+
+// ScentDuration is how long the player's scent lingers where it was left.
+var ScentDuration = time.Hour
+
+// readScent reads the scent layer of the chunk from r.
+func (c *Chunk) readScent(r io.Reader) {
+	if !util.GetBool(r) {
+		c.Scent = nil
+		return
+	}
+	c.ScentBase = util.GetTime(r)
+	c.Scent = make([]uint32, ChunkWidth*ChunkHeight)
+	for i := range c.Scent {
+		c.Scent[i] = util.GetUint32(r)
+	}
+}
+
+// writeScent writes the scent layer of the chunk to w.
+func (c *Chunk) writeScent(w io.Writer) {
+	if c.Scent == nil {
+		util.PutBool(w, false)
+		return
+	}
+	util.PutBool(w, true)
+	util.PutTime(w, c.ScentBase)
+	for _, s := range c.Scent {
+		util.PutUint32(w, s)
+	}
+}
+
+// LeaveScent marks p as freshly scented by the player. Water does not hold
+// scent.
+func (m *CityMap) LeaveScent(p util.Point) {
+	c := m.GetChunk(p)
+	if c == nil || c.Tiles == nil || c.Tiles[c.relOfs(p)].Water {
+		return
+	}
+	if c.Scent == nil {
+		c.Scent = make([]uint32, ChunkWidth*ChunkHeight)
+		c.ScentBase = m.Now.Add(-time.Second) // Zero means no scent
+	}
+	c.Scent[c.relOfs(p)] = uint32(m.Now.Sub(c.ScentBase) / time.Second)
+}
+
+// Scent returns the strength of the player's scent at p from zero (none) to
+// one (fresh).
+func (m *CityMap) Scent(p util.Point) float64 {
+	c := m.GetChunk(p)
+	if c == nil || c.Scent == nil {
+		return 0
+	}
+	s := c.Scent[c.relOfs(p)]
+	if s == 0 {
+		return 0
+	}
+	age := m.Now.Sub(c.ScentBase.Add(time.Duration(s) * time.Second))
+	if age >= ScentDuration {
+		return 0
+	}
+	return 1 - float64(age)/float64(ScentDuration)
+}
+
+// WashScent ages all scent within b by d, as rain does.
+func (m *CityMap) WashScent(b util.Rect, d time.Duration) {
+	ds := uint32(d / time.Second)
+	var p util.Point
+	for _, c := range m.ChunksWithin(b) {
+		if c.Scent == nil {
+			continue
+		}
+		cb := c.Bounds.Overlap(b)
+		for p.Y = cb.TL.Y; p.Y <= cb.BR.Y; p.Y++ {
+			for p.X = cb.TL.X; p.X <= cb.BR.X; p.X++ {
+				i := c.relOfs(p)
+				if c.Scent[i] > ds {
+					c.Scent[i] -= ds
+				} else {
+					c.Scent[i] = 0
+				}
+			}
+		}
+	}
+}
+
+// ScentDirection returns the direction from p toward fresher scent, or
+// util.DirectionInvalid if there is no fresher scent next to p.
+func (m *CityMap) ScentDirection(p util.Point) util.Direction {
+	dm := NewDMap(util.NewRectFromRadius(p, 1).Overlap(LevelBounds(LevelOf(p))))
+	var sp util.Point
+	for sp.Y = dm.Bounds.TL.Y; sp.Y <= dm.Bounds.BR.Y; sp.Y++ {
+		for sp.X = dm.Bounds.TL.X; sp.X <= dm.Bounds.BR.X; sp.X++ {
+			r := DMapRankMax
+			if s := m.Scent(sp); s > 0 {
+				r = DMapRank((1 - s) * float64(DMapRankMax-1))
+			}
+			dm.Map[(sp.Y-dm.Bounds.TL.Y)*dm.Bounds.Width()+(sp.X-dm.Bounds.TL.X)] = r
+		}
+	}
+	_, d := dm.RollDown(p)
+	return d
+}
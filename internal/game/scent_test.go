@@ -0,0 +1,53 @@
+package game_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
+)
+
+func TestScentFades(t *testing.T) {
+	m := newQuietCity(t, 1)
+	p := m.Player.Position
+	m.PlayerTookTurn(time.Second, nil)
+	if s := m.Scent(p); s <= 0.99 {
+		t.Fatalf("fresh scent has strength %f", s)
+	}
+	m.Now = m.Now.Add(game.ScentDuration / 2)
+	if s := m.Scent(p); s < 0.49 || s > 0.51 {
+		t.Fatalf("half faded scent has strength %f", s)
+	}
+	m.WashScent(util.NewRectFromRadius(p, 1), game.ScentDuration)
+	if s := m.Scent(p); s != 0 {
+		t.Fatalf("washed scent has strength %f", s)
+	}
+}
+
+func TestScentDirection(t *testing.T) {
+	m := newQuietCity(t, 1)
+	// Lay a trail heading east through the room
+	o := m.GetChunk(m.Player.Position).Bounds.TL.Add(util.NewPoint(3, 3))
+	for x := 0; x < 5; x++ {
+		m.LeaveScent(o.Add(util.NewPoint(x, 0)))
+		m.Now = m.Now.Add(time.Minute)
+	}
+	if d := m.ScentDirection(o); d != util.DirectionEast {
+		t.Fatalf("scent direction at the start of the trail is %v", d)
+	}
+	if d := m.ScentDirection(o.Add(util.NewPoint(2, 1))); d != util.DirectionNorthEast {
+		t.Fatalf("scent direction beside the trail is %v", d)
+	}
+	if d := m.ScentDirection(o.Add(util.NewPoint(4, 0))); d != util.DirectionInvalid {
+		t.Fatalf("scent direction at the freshest scent is %v", d)
+	}
+	if d := m.ScentDirection(o.Add(util.NewPoint(0, 5))); d != util.DirectionInvalid {
+		t.Fatalf("scent direction away from the trail is %v", d)
+	}
+	// Stale trails lead nowhere
+	m.Now = m.Now.Add(game.ScentDuration)
+	if d := m.ScentDirection(o); d != util.DirectionInvalid {
+		t.Fatalf("scent direction on a stale trail is %v", d)
+	}
+}
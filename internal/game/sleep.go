@@ -0,0 +1,131 @@
+package game
+
+import (
+	"time"
+
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// sleepNoiseRadius is the distance within which creatures disturb sleep.
+const sleepNoiseRadius int = 16
+
+// sleepComfort returns the comfort of the best place to lie down at p. The
+// player can not sleep at p if the comfort is zero or less.
+func (m *CityMap) sleepComfort(p util.Point) float64 {
+	c := m.GetTile(p).Comfort
+	for _, i := range m.ItemsAt(p) {
+		if i.Comfort > c {
+			c = i.Comfort
+		}
+	}
+	return c
+}
+
+// SleepQuality rolls the quality of sleep the player gets at their current
+// position from just above zero to one. The comfort of the bed or floor slept
+// on and nearby furniture improves sleep while noise from nearby creatures
+// worsens it.
+func (m *CityMap) SleepQuality() float64 {
+	p := m.Player.Position
+	q := 0.2 + m.sleepComfort(p)
+	// Nearby furniture
+	f := 0.0
+	for _, i := range m.ItemsWithin(util.NewRectFromRadius(p, 1).Overlap(LevelBounds(LevelOf(p)))) {
+		if i.Position != p && i.Fixed && i.Comfort > 0 {
+			f += i.Comfort * 0.25
+		}
+	}
+	q += min(f, 0.25)
+	// Noise
+	for _, a := range m.ActorsWithin(util.NewRectFromRadius(p, sleepNoiseRadius).Overlap(LevelBounds(LevelOf(p)))) {
+		if !a.IsPlayer && !a.Dead {
+			q -= 0.1
+		}
+	}
+	q += util.RandomF(-0.1, 0.1)
+	return min(max(q, 0.1), 1)
+}
+
+// VisibleHostile returns a hostile actor the player can see, or nil if there
+// are none.
+func (m *CityMap) VisibleHostile() *Actor {
+	p := m.Player.Position
+	r := m.Player.CurrentSightRange()
+	for _, a := range m.ActorsWithin(util.NewRectFromRadius(p, r).Overlap(LevelBounds(LevelOf(p)))) {
+		if a.IsPlayer || a.Dead || a.Position.Distance(p) > r || !m.Hostile(a, &m.Player.Actor) ||
+			a.Position.Distance(p) > m.SightRange(&m.Player.Actor, a.Position) {
+			continue
+		}
+		if m.CanSeePlayerFrom(a.Position) {
+			return a
+		}
+	}
+	return nil
+}
+
+func init() {
+	RegisterActivity("Rest", &ActivityKind{
+		Interrupts: InterruptAll,
+	})
+	RegisterActivity("Sleep", &ActivityKind{
+		Interrupts: InterruptHostile | InterruptDamage,
+		Start:      startSleep,
+		Step: func(m *CityMap, a *Activity) string {
+			if m.Player.Sleep >= 1 {
+				return "You wake up fully rested."
+			}
+			return ""
+		},
+		Stop: stopSleep,
+	})
+}
+
+// PlayerRest has the player rest in place for up to d.
+func (m *CityMap) PlayerRest(d time.Duration, update func()) {
+	m.StartActivity(NewActivity("Rest", "resting", d), update)
+}
+
+// PlayerSleep has the player sleep at their current position for up to d. The
+// player wakes early when fully rested.
+func (m *CityMap) PlayerSleep(d time.Duration, update func()) {
+	m.StartActivity(NewActivity("Sleep", "sleeping", d), update)
+}
+
+// startSleep puts the player to sleep if they are able to.
+func startSleep(m *CityMap, a *Activity) string {
+	p := m.Player
+	if p.InControl {
+		return "You can not sleep while driving."
+	}
+	if m.sleepComfort(p.Position) <= 0 {
+		return "There is no place to lie down here."
+	}
+	if p.Sleep >= 0.9 {
+		return "You are not tired enough to sleep."
+	}
+	p.SleepQuality = m.SleepQuality()
+	p.Sleeping = true
+	p.Running = false
+	Log.Log(termui.ColorPurple, "You lie down and fall asleep.")
+	return ""
+}
+
+// stopSleep wakes the player.
+func stopSleep(m *CityMap, a *Activity, completed bool) bool {
+	p := m.Player
+	p.Sleeping = false
+	if p.Dead {
+		return false
+	}
+	p.RemoveEffect("Exhaustion")
+	switch {
+	case p.SleepQuality >= 0.75:
+		Log.Log(termui.ColorLime, "You slept soundly.")
+	case p.SleepQuality >= 0.4:
+		Log.Log(termui.ColorWhite, "You slept fitfully.")
+	default:
+		Log.Log(termui.ColorOlive, "You barely slept at all.")
+	}
+	return completed
+}
@@ -0,0 +1,87 @@
+package game_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
+)
+
+func TestSleepInBed(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	m := newQuietCity(t, 1)
+	bed := game.NewItem("Bed", m.Now, false)
+	bed.Position = m.Player.Position
+	m.PlaceItem(bed, true)
+	m.Player.Sleep = 0.3
+	start := m.Now
+	m.PlayerSleep(time.Hour*12, nil)
+	if m.Player.Sleeping {
+		t.Fatal("player is still asleep")
+	}
+	if m.Player.Sleep < 1 {
+		t.Fatalf("sleeping in a bed left sleep at %f", m.Player.Sleep)
+	}
+	if d := m.Now.Sub(start); d >= time.Hour*12 {
+		t.Fatalf("player slept %v without waking when fully rested", d)
+	}
+}
+
+func TestSleepRefusals(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	log := &lastLog{}
+	game.Log = log
+	m := newQuietCity(t, 1)
+	refuse := func(reason string) {
+		t.Helper()
+		start := m.Now
+		m.PlayerSleep(time.Hour*8, nil)
+		if !m.Now.Equal(start) {
+			t.Fatalf("player slept when they should have refused with %q", reason)
+		}
+		if !strings.Contains(log.line, reason) {
+			t.Fatalf("sleep refusal logged %q, expected %q", log.line, reason)
+		}
+	}
+	m.Player.Sleep = 0.95
+	refuse("not tired enough")
+	m.Player.Sleep = 0.3
+	z := game.NewActor("Zombie", m.Now, false)
+	z.Position = m.Player.Position.Add(util.NewPoint(-2, 0))
+	m.PlaceActor(z, false)
+	refuse("can not do that with a")
+	start := m.Now
+	m.PlayerRest(time.Hour, nil)
+	if !m.Now.Equal(start) || !strings.Contains(log.line, "can not do that with a") {
+		t.Fatalf("rest with a hostile in sight logged %q", log.line)
+	}
+}
+
+func TestSleepDeprivation(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	m := newQuietCity(t, 1)
+	for _, c := range []struct {
+		sleep  float64
+		stacks int
+	}{
+		{0.5, 0},
+		{0.2, 1},
+		{0.05, 2},
+		{0, 3},
+		{0.5, 0},
+	} {
+		m.Player.Sleep = c.sleep
+		m.PlayerTookTurn(time.Second, nil)
+		e := m.Player.Effect("SleepDeprivation")
+		switch {
+		case c.stacks == 0 && e != nil:
+			t.Fatalf("sleep %f applied sleep deprivation", c.sleep)
+		case c.stacks > 0 && (e == nil || e.Stacks != c.stacks):
+			t.Fatalf("sleep %f applied %+v, expected %d stacks", c.sleep, e, c.stacks)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package game
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// StatusEffectDefs is the map of all status effect definitions.
+var StatusEffectDefs = map[string]*StatusEffectDef{}
+
+// StatusEffectDef defines a timed effect that may be applied to an actor. All
+// modifiers are per stack of the effect.
+type StatusEffectDef struct {
+	ID          string        // Unique ID
+	Name        string        // Descriptive name
+	Fg          termui.Color  // Display color
+	Duration    util.Duration // Duration of one application of the effect
+	MaxStacks   int           // Maximum number of stacks, zero means one
+	WalkSpeed   float64       // Walk time modifier, 0.25 means walking takes 25% longer
+	ActSpeed    float64       // Action time modifier, -0.25 means actions take 25% less time
+	Damage      float64       // Damage modifier, 0.5 means 50% more damage dealt
+	SightRange  int           // Change in sight range
+	Health      float64       // Change in health of the body per hour
+	Stamina     float64       // Change in player stamina per hour
+	Hunger      float64       // Change in player hunger per hour
+	Thirst      float64       // Change in player thirst per hour
+	Expires     string        // Effect that may be applied when this effect expires
+	ExpireCause float64       // Chance of applying the Expires effect
+	ApplyMsg    string        // Message shown when the player gains the effect
+	ExpireMsg   string        // Message shown when the effect wears off the player
+}
+
+// Validate returns an error if the status effect definition is malformed. This
+// must be called on all status effects after loading is complete.
+func (d *StatusEffectDef) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("status effect %s has no name", d.ID)
+	}
+	if d.Duration <= 0 {
+		return fmt.Errorf("status effect %s must have a positive duration", d.ID)
+	}
+	if d.MaxStacks < 0 {
+		return fmt.Errorf("status effect %s has a negative maximum stack count", d.ID)
+	}
+	if d.Expires != "" {
+		if _, found := StatusEffectDefs[d.Expires]; !found {
+			return fmt.Errorf("status effect %s expires into non-existent effect %s", d.ID, d.Expires)
+		}
+	}
+	return nil
+}
+
+// StatusEffect is an instance of a status effect applied to an actor.
+type StatusEffect struct {
+	*StatusEffectDef           // Definition
+	Stacks           int       // Number of stacks
+	Until            time.Time // Time the effect expires
+}
+
+// hours returns the number of hours within the interval starting at t of
+// duration d that the effect is active.
+func (e *StatusEffect) hours(t time.Time, d time.Duration) float64 {
+	if !e.Until.After(t) {
+		return 0
+	}
+	if r := e.Until.Sub(t); r < d {
+		d = r
+	}
+	return float64(d) / float64(time.Hour)
+}
+
+// readStatusEffects reads the list of status effects from r.
+func readStatusEffects(r io.Reader) ([]*StatusEffect, error) {
+	var ret []*StatusEffect
+	n := int(util.GetUint16(r))
+	for i := 0; i < n; i++ {
+		id := util.GetString(r)        // Definition ID
+		stacks := int(util.GetByte(r)) // Stack count
+		until := util.GetTime(r)       // Expiration time
+		def, found := StatusEffectDefs[id]
+		if !found {
+			if !Repair {
+				return nil, fmt.Errorf("reference to non-existent status effect %s", id)
+			}
+			LogRepair("dropped unknown status effect %s", id)
+			continue
+		}
+		ret = append(ret, &StatusEffect{
+			StatusEffectDef: def,
+			Stacks:          stacks,
+			Until:           until,
+		})
+	}
+	return ret, nil
+}
+
+// writeStatusEffects writes the list of status effects to w.
+func writeStatusEffects(w io.Writer, effects []*StatusEffect) {
+	util.PutUint16(w, uint16(len(effects)))
+	for _, e := range effects {
+		util.PutString(w, e.ID)         // Definition ID
+		util.PutByte(w, byte(e.Stacks)) // Stack count
+		util.PutTime(w, e.Until)        // Expiration time
+	}
+}
+
+// Effect returns the named status effect applied to the actor, or nil if the
+// actor is not under that effect.
+func (a *Actor) Effect(id string) *StatusEffect {
+	for _, e := range a.Effects {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+// ApplyEffect applies one stack of the named status effect to the actor. If
+// the effect is already applied its stack count is increased up to the maximum
+// and its duration is refreshed.
+func (a *Actor) ApplyEffect(id string, now time.Time) error {
+	def, found := StatusEffectDefs[id]
+	if !found {
+		return fmt.Errorf("reference to non-existent status effect %s", id)
+	}
+	until := now.Add(time.Duration(def.Duration))
+	if e := a.Effect(id); e != nil {
+		if e.Stacks < max(def.MaxStacks, 1) {
+			e.Stacks++
+		}
+		if until.After(e.Until) {
+			e.Until = until
+		}
+	} else {
+		a.Effects = append(a.Effects, &StatusEffect{
+			StatusEffectDef: def,
+			Stacks:          1,
+			Until:           until,
+		})
+		if a.IsPlayer && def.ApplyMsg != "" {
+			Log.Log(def.Fg, "%s", def.ApplyMsg)
+		}
+	}
+	a.recalculateDamage()
+	return nil
+}
+
+// SetEffect sets the number of stacks of the named status effect applied to the
+// actor and refreshes its duration. Zero stacks removes the effect.
+func (a *Actor) SetEffect(id string, stacks int, now time.Time) error {
+	def, found := StatusEffectDefs[id]
+	if !found {
+		return fmt.Errorf("reference to non-existent status effect %s", id)
+	}
+	if stacks < 1 {
+		a.RemoveEffect(id)
+		return nil
+	}
+	e := a.Effect(id)
+	if e == nil {
+		if err := a.ApplyEffect(id, now); err != nil {
+			return err
+		}
+		e = a.Effect(id)
+	}
+	e.Stacks = min(stacks, max(def.MaxStacks, 1))
+	e.Until = now.Add(time.Duration(def.Duration))
+	a.recalculateDamage()
+	return nil
+}
+
+// RemoveEffect removes the named status effect from the actor. Returns true if
+// the effect was applied.
+func (a *Actor) RemoveEffect(id string) bool {
+	for i, e := range a.Effects {
+		if e.ID != id {
+			continue
+		}
+		a.Effects = append(a.Effects[:i], a.Effects[i+1:]...)
+		a.recalculateDamage()
+		return true
+	}
+	return false
+}
+
+// UpdateEffects applies the effects of all status effects over the interval
+// starting at now of duration d and removes expired effects. This executes in
+// linear time no matter the value of d.
+func (a *Actor) UpdateEffects(now time.Time, d time.Duration) {
+	if len(a.Effects) < 1 {
+		return
+	}
+	end := now.Add(d)
+	var expired []*StatusEffect
+	effects := a.Effects[:0]
+	for _, e := range a.Effects {
+		if e.Health != 0 && !a.Dead {
+			p := a.BodyParts[BodyPartBody]
+			p.Health = min(p.Health+e.Health*float64(e.Stacks)*e.hours(now, d), 1)
+			if p.Health <= 0 {
+				p.Health = 0
+				a.Dead = true
+				if a.IsPlayer {
+					Log.Log(termui.ColorRed, "You have succumbed to %s.", e.Name)
+				}
+			}
+			a.BodyParts[BodyPartBody] = p
+		}
+		if e.Until.After(end) {
+			effects = append(effects, e)
+		} else {
+			expired = append(expired, e)
+		}
+	}
+	a.Effects = effects
+	for _, e := range expired {
+		if a.IsPlayer && e.ExpireMsg != "" {
+			Log.Log(termui.ColorSilver, "%s", e.ExpireMsg)
+		}
+		if e.Expires != "" && !a.Dead && util.RandomF(0, 1) < e.ExpireCause {
+			a.ApplyEffect(e.Expires, e.Until)
+		}
+	}
+	a.recalculateDamage()
+}
+
+// effectModifier returns the sum of the modifier selected by fn over all
+// status effects applied to the actor.
+func (a *Actor) effectModifier(fn func(*StatusEffect) float64) float64 {
+	ret := 0.0
+	for _, e := range a.Effects {
+		ret += fn(e) * float64(e.Stacks)
+	}
+	return ret
+}
+
+// CurrentSightRange returns the distance the actor can currently see
+// accounting for all status effects.
+func (a *Actor) CurrentSightRange() int {
+	r := a.SightRange
+	for _, e := range a.Effects {
+		r += e.SightRange * e.Stacks
+	}
+	return max(r, 1)
+}
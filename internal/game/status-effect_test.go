@@ -0,0 +1,96 @@
+package game_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/qbradq/after/internal/events"
+	"github.com/qbradq/after/internal/game"
+)
+
+func TestApplyEffectStacks(t *testing.T) {
+	a := game.NewActor("Zombie", fixtureTime, false)
+	for i := 0; i < 5; i++ {
+		if err := a.ApplyEffect("Pain", fixtureTime.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := a.Effect("Pain")
+	if e == nil || len(a.Effects) != 1 {
+		t.Fatalf("expected one pain effect, have %d", len(a.Effects))
+	}
+	if e.Stacks != e.MaxStacks {
+		t.Fatalf("pain has %d stacks, expected the maximum of %d", e.Stacks, e.MaxStacks)
+	}
+	if want := fixtureTime.Add(4*time.Hour + time.Duration(e.Duration)); !e.Until.Equal(want) {
+		t.Fatalf("pain expires at %v, expected the refreshed time %v", e.Until, want)
+	}
+	if err := a.ApplyEffect("NoSuchEffect", fixtureTime); err == nil {
+		t.Fatal("applied a non-existent effect")
+	}
+}
+
+func TestEffectModifiers(t *testing.T) {
+	a := game.NewActor("Zombie", fixtureTime, false)
+	walk, act, sight := a.WalkSpeed(), a.ActSpeed(), a.CurrentSightRange()
+	a.ApplyEffect("Drunk", fixtureTime)
+	a.ApplyEffect("Drunk", fixtureTime)
+	if got := a.CurrentSightRange(); got != sight-8 {
+		t.Fatalf("two stacks of drunkenness left sight range %d of %d", got, sight)
+	}
+	if got := a.WalkSpeed(); got != walk*1.2 {
+		t.Fatalf("two stacks of drunkenness left walk speed %f of %f", got, walk)
+	}
+	a.RemoveEffect("Drunk")
+	a.ApplyEffect("Adrenaline", fixtureTime)
+	if got := a.ActSpeed(); got != act*0.8 {
+		t.Fatalf("adrenaline left act speed %f of %f", got, act)
+	}
+}
+
+func TestUpdateEffectsExpire(t *testing.T) {
+	a := game.NewActor("Zombie", fixtureTime, false)
+	a.ApplyEffect("Bleeding", fixtureTime)
+	// Bleeding lasts half an hour so a long update only applies that much
+	a.UpdateEffects(fixtureTime, time.Hour*10)
+	if a.Effect("Bleeding") != nil {
+		t.Fatal("bleeding did not expire")
+	}
+	if h := a.BodyParts[game.BodyPartBody].Health; h != 0.9 {
+		t.Fatalf("half an hour of bleeding left body health at %f", h)
+	}
+}
+
+func TestEffectsRoundTrip(t *testing.T) {
+	a := game.NewActor("Zombie", fixtureTime, false)
+	a.ApplyEffect("Pain", fixtureTime)
+	a.ApplyEffect("Pain", fixtureTime)
+	w := bytes.NewBuffer(nil)
+	a.Write(w)
+	ra, err := game.NewActorFromReader(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := ra.Effect("Pain")
+	if e == nil || e.Stacks != 2 || !e.Until.Equal(a.Effect("Pain").Until) {
+		t.Fatalf("pain effect did not round-trip: %+v", e)
+	}
+}
+
+func TestMedicateCures(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	m := newQuietCity(t, 1)
+	m.Player.ApplyEffect("Bleeding", m.Now)
+	i := game.NewItem("Bandage", m.Now, false)
+	if err, ok := events.ExecuteItemUseEvent("Use", i, &m.Player.Actor, m); err != nil || !ok {
+		t.Fatalf("using a bandage: %v %v", err, ok)
+	}
+	if m.Player.Effect("Bleeding") != nil {
+		t.Fatal("bandage did not stop the bleeding")
+	}
+	if !i.Destroyed {
+		t.Fatal("bandage was not used up")
+	}
+}
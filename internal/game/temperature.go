@@ -0,0 +1,132 @@
+package game
+
+import (
+	"time"
+
+	"github.com/qbradq/after/lib/util"
+)
+
+// BodyTempNormal is the healthy core body temperature in degrees Fahrenheit.
+const BodyTempNormal float64 = 98.6
+
+// Body temperature parameters, temperatures are in degrees Fahrenheit.
+const (
+	bodyTempMin       float64 = 80  // Lowest body temperature
+	bodyTempMax       float64 = 110 // Highest body temperature
+	bodyTempColdest   float64 = 60  // Lowest felt temperature the body keeps itself warm in
+	bodyTempHottest   float64 = 85  // Highest felt temperature the body keeps itself cool in
+	bodyTempRecovery  float64 = 2   // Degrees per hour the body returns to normal when comfortable
+	bodyTempColdRate  float64 = 20  // Degrees of cold beyond comfort that chill the body one degree per hour
+	bodyTempHeatRate  float64 = 10  // Degrees of heat beyond comfort that warm the body one degree per hour
+	indoorTemp        float64 = 65  // Temperature buildings tend toward
+	indoorShelter     float64 = 0.5 // Fraction of the difference to indoorTemp buildings make up
+	windChill         float64 = 0.5 // Degrees of chill per mile per hour of wind out in the cold
+	wetChill          float64 = 15  // Degrees of chill when soaked
+	wetInsulation     float64 = 0.6 // Fraction of the warmth of clothing lost when soaked
+	wetRate           float64 = 2   // Wetness gained per hour out in a downpour
+	wetSnowRate       float64 = 0.5 // Multiplier of wetRate in falling snow
+	dryRate           float64 = 0.5 // Wetness lost per hour out of the rain
+	dryFireRate       float64 = 3   // Multiplier of dryRate next to a fire
+	fireRadius        int     = 3   // Distance in tiles at which fires warm
+	hypothermiaTemp   float64 = 95  // Body temperature below which hypothermia sets in
+	heatstrokeTemp    float64 = 103 // Body temperature above which heatstroke sets in
+	bodyTempStackStep float64 = 3   // Degrees beyond the onset temperature for each further stack
+)
+
+// Indoors returns true if p is sheltered from the weather.
+func (m *CityMap) Indoors(p util.Point) bool {
+	c := m.GetChunk(p)
+	if c == nil {
+		return false
+	}
+	c.RebuildBitmaps(m)
+	return c.Indoors.Contains(c.relOfs(p))
+}
+
+// Insulation returns the warmth in degrees Fahrenheit of all clothing worn by
+// the actor accounting for wear.
+func (a *Actor) Insulation() float64 {
+	ret := 0.0
+	for _, i := range a.WornItems {
+		if i != nil {
+			ret += i.Warmth * i.Effectiveness()
+		}
+	}
+	return ret
+}
+
+// fireHeat returns the warmth in degrees Fahrenheit given off at p by the
+// hottest fire nearby.
+func (m *CityMap) fireHeat(p util.Point) float64 {
+	ret := 0.0
+	for _, i := range m.ItemsWithin(util.NewRectFromRadius(p, fireRadius)) {
+		if i.Heat <= 0 {
+			continue
+		}
+		h := i.Heat * (1 - float64(p.Distance(i.Position))/float64(fireRadius+1))
+		ret = max(ret, h)
+	}
+	return ret
+}
+
+// FeltTemperature returns the temperature in degrees Fahrenheit the player
+// feels accounting for shelter, wind, worn clothing, being wet and nearby
+// fires.
+func (m *CityMap) FeltTemperature() float64 {
+	p := m.Player
+	t := m.OutdoorTemperature()
+	if m.Indoors(p.Position) {
+		t += (indoorTemp - t) * indoorShelter
+	} else if t < bodyTempColdest {
+		t -= m.Weather.Wind * windChill
+	}
+	t += p.Insulation() * (1 - p.Wetness*wetInsulation)
+	t -= p.Wetness * wetChill
+	t += m.fireHeat(p.Position)
+	return t
+}
+
+// updateBodyTemperature updates the player's wetness and body temperature for
+// duration d and applies hypothermia or heatstroke. Both effects are optional
+// for mods to define.
+func (m *CityMap) updateBodyTemperature(d time.Duration) {
+	p := m.Player
+	h := d.Hours()
+	// Wetness
+	if m.Weather.Precipitation > 0 && !m.Indoors(p.Position) {
+		r := wetRate
+		if m.Snowing() {
+			r *= wetSnowRate
+		}
+		p.Wetness += m.Weather.Precipitation * r * h
+	} else {
+		r := dryRate
+		if m.fireHeat(p.Position) > 0 {
+			r *= dryFireRate
+		}
+		p.Wetness -= r * h
+	}
+	p.Wetness = min(max(p.Wetness, 0), 1)
+	// Body temperature
+	felt := m.FeltTemperature()
+	switch {
+	case felt < bodyTempColdest:
+		p.BodyTemp -= (bodyTempColdest - felt) / bodyTempColdRate * h
+	case felt > bodyTempHottest:
+		p.BodyTemp += (felt - bodyTempHottest) / bodyTempHeatRate * h
+	case p.BodyTemp < BodyTempNormal:
+		p.BodyTemp = min(p.BodyTemp+bodyTempRecovery*h, BodyTempNormal)
+	default:
+		p.BodyTemp = max(p.BodyTemp-bodyTempRecovery*h, BodyTempNormal)
+	}
+	p.BodyTemp = min(max(p.BodyTemp, bodyTempMin), bodyTempMax)
+	// Effects
+	stacks := func(beyond float64) int {
+		if beyond <= 0 {
+			return 0
+		}
+		return int(beyond/bodyTempStackStep) + 1
+	}
+	p.SetEffect("Hypothermia", stacks(hypothermiaTemp-p.BodyTemp), m.Now)
+	p.SetEffect("Heatstroke", stacks(p.BodyTemp-heatstrokeTemp), m.Now)
+}
@@ -0,0 +1,75 @@
+package game_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
+)
+
+func TestBodyTemperature(t *testing.T) {
+	defer func(l game.Logger) { game.Log = l }(game.Log)
+	game.Log = &lastLog{}
+	m := newQuietCity(t, 1)
+	p := m.Player
+	for i := range p.WornItems {
+		p.WornItems[i] = nil
+	}
+	// The combat test room is indoors and the street north of it is not
+	o := m.GetChunk(p.Position).Bounds.TL
+	p.Position = o.Add(util.NewPoint(5, -1))
+	if m.Indoors(p.Position) {
+		t.Fatal("street is indoors")
+	}
+	weather := func(wx game.Weather) {
+		wx.Until = m.Now.Add(time.Hour * 24)
+		m.Weather = wx
+	}
+	wait := func(d time.Duration) {
+		for end := m.Now.Add(d); m.Now.Before(end); {
+			m.PlayerTookTurn(time.Minute*10, nil)
+		}
+	}
+	// A freezing day outdoors chills the body into hypothermia
+	weather(game.Weather{TempOffset: -60})
+	if f := m.FeltTemperature(); f >= 30 {
+		t.Fatalf("freezing day feels like %f", f)
+	}
+	wait(time.Hour * 2)
+	if p.BodyTemp >= 95 || p.Effect("Hypothermia") == nil {
+		t.Fatalf("two freezing hours left body temperature at %f", p.BodyTemp)
+	}
+	// A mild day lets the body recover at a steady rate
+	weather(game.Weather{TempOffset: 15})
+	if f := m.FeltTemperature(); f < 60 || f > 85 {
+		t.Fatalf("mild day feels like %f", f)
+	}
+	bt := p.BodyTemp
+	wait(time.Hour)
+	if d := p.BodyTemp - bt; math.Abs(d-2) > 1e-6 {
+		t.Fatalf("body temperature recovered %f degrees in an hour", d)
+	}
+	wait(time.Hour * 4)
+	if p.BodyTemp != game.BodyTempNormal || p.Effect("Hypothermia") != nil {
+		t.Fatalf("body temperature recovered to %f", p.BodyTemp)
+	}
+	// A downpour soaks the player outdoors, which chills them
+	dry := m.FeltTemperature()
+	weather(game.Weather{Precipitation: 1, Cloud: 1, TempOffset: 15})
+	wait(time.Minute * 30)
+	if p.Wetness != 1 {
+		t.Fatalf("half an hour in a downpour left wetness at %f", p.Wetness)
+	}
+	weather(game.Weather{TempOffset: 15})
+	if f := m.FeltTemperature(); f >= dry-10 {
+		t.Fatalf("soaked player feels %f rather than %f", f, dry)
+	}
+	// Indoors the player dries off
+	p.Position = o.Add(util.NewPoint(5, 5))
+	wait(time.Hour)
+	if math.Abs(p.Wetness-0.5) > 1e-6 {
+		t.Fatalf("an hour indoors left wetness at %f", p.Wetness)
+	}
+}
@@ -3,6 +3,7 @@ package game
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"github.com/qbradq/after/lib/util"
 )
@@ -16,7 +17,14 @@ var TileGens = map[string]TileGen{}
 func (g *TileGen) UnmarshalJSON(in []byte) error {
 	var src = map[string]int{}
 	json.Unmarshal(in, &src)
-	for k, n := range src {
+	// Sort the keys so the generator is deterministic for a given seed
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		n := src[k]
 		r, found := TileRefs[k]
 		if !found {
 			panic(fmt.Errorf("TileGen referenced non-existent tile %s", k))
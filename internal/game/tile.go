@@ -2,6 +2,7 @@ package game
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 
 	"github.com/qbradq/after/lib/termui"
@@ -23,6 +24,10 @@ type TileDef struct {
 	BlocksWalk  bool         // If true this tile blocks walking
 	BlocksStack bool         // If true this tile blocks any other items being placed on that spot
 	Climbable   bool         // If true this tile may be (c)limbed over even if it blocks walk
+	Comfort     float64      // Comfort of sleeping on this tile, zero or less if it can not be slept on
+	Water       bool         // If true this tile is water which does not hold scent
+	Indoors     bool         // If true this tile is roofed over and sheltered from the weather
+	Bash        *Bash        // How the tile stands up to being smashed, nil if it can not be smashed
 }
 
 // TileRefs is the global string-to-TileRef reference.
@@ -66,30 +71,42 @@ func getTileCrossRef(r TileRef) TileCrossRef {
 }
 
 // SaveTileRefs saves tileRefMap.
-func SaveTileRefs() {
+func SaveTileRefs() error {
 	// Write out the map
 	w := bytes.NewBuffer(nil)
-	util.PutUint32(w, 0) // Version
+	util.PutUint32(w, tileRefsVersion) // Version
 	util.PutUint16(w, uint16(len(TileRefMap)))
 	for k, v := range TileRefMap {
 		util.PutUint16(w, uint16(k))
 		util.PutString(w, v)
 	}
-	SaveValue("TileRefs", w.Bytes())
+	if err := SaveValue("TileRefs", w.Bytes()); err != nil {
+		return err
+	}
 	// Flag as no longer dirty
 	crossReferencesDirty = false
+	return nil
 }
 
-// LoadTileRefs loads tileRefMap and rebuilds tileCrossRefs.
-func LoadTileRefs() {
+// LoadTileRefs loads tileRefMap and rebuilds tileCrossRefs. Cross references
+// of any previously open save are discarded.
+func LoadTileRefs() error {
 	TileRefMap = make(map[TileCrossRef]string)
+	TileCrossRefs = nil
+	TileCrossRefForRef = map[TileRef]TileCrossRef{}
+	crossReferencesDirty = false
 	// Read from database
-	r := LoadValue("TileRefs")
-	if r == nil {
+	r, err := LoadValue("TileRefs")
+	if errors.Is(err, ErrValueNotFound) {
 		// TileRefs have not yet been written - probably a new save
-		return
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := ReadVersion(r, "tile references", tileRefsVersion); err != nil { // Version
+		return err
 	}
-	_ = util.GetUint32(r) // Version
 	n := int(util.GetUint16(r))
 	for i := 0; i < n; i++ {
 		TileRefMap[TileCrossRef(util.GetUint16(r))] = util.GetString(r)
@@ -98,12 +115,16 @@ func LoadTileRefs() {
 	TileCrossRefs = make([]*TileDef, n)
 	TileCrossRefForRef = map[TileRef]TileCrossRef{}
 	for k, v := range TileRefMap {
+		if int(k) >= n {
+			return fmt.Errorf("tile cross-reference %d out of range", k)
+		}
 		r, found := TileRefs[v]
 		if !found {
-			panic(fmt.Errorf("tile cross-reference referenced non-loaded tile %s", v))
+			return fmt.Errorf("tile cross-reference referenced non-loaded tile %s", v)
 		}
 		t := TileDefs[r]
 		TileCrossRefs[k] = t
 		TileCrossRefForRef[r] = k
 	}
+	return nil
 }
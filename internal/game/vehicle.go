@@ -12,19 +12,30 @@ import (
 // VehicleLocation encapsulates all of the parts and functionality of one area
 // of a vehicle.
 type VehicleLocation struct {
-	Parts []*Item      // Items at the location, from bottom to top
-	Glyph termui.Glyph // Visual representation, if any
-	Solid bool         // If true at least one part at this location is solid
+	Parts     []*Item      // Items at the location, from bottom to top
+	Glyph     termui.Glyph // Visual representation, if any
+	Solid     bool         // If true at least one part at this location is solid
+	Sheltered bool         // If true at least one part at this location shelters it from the weather
+	Light     int          // Radius in tiles of the brightest light at this location
 }
 
 // UpdateFlags updates the location's flags given the current contents of the
 // parts list.
 func (l *VehicleLocation) UpdateFlags() {
 	l.Solid = false
+	l.Sheltered = false
+	l.Light = 0
 	for _, p := range l.Parts {
+		if p.Broken() {
+			continue
+		}
 		if p.VehicleSolid {
 			l.Solid = true
 		}
+		if p.VehicleShelter {
+			l.Sheltered = true
+		}
+		l.Light = max(l.Light, p.Light)
 	}
 }
 
@@ -66,6 +77,10 @@ func (l *VehicleLocation) Remove(i *Item) bool {
 	return true
 }
 
+// vehicleCrashDamage is the damage dealt to each part along the leading edge of
+// a vehicle per mile per hour of speed when it crashes.
+const vehicleCrashDamage float64 = 0.1
+
 // AccelerationState represents the state of the vehicle's acceleration.
 type AccelerationState uint8
 
@@ -126,9 +141,11 @@ func GenerateVehicle(gn string, now time.Time) *Vehicle {
 }
 
 // NewVehicleFromReader reads a vehicle from a reader.
-func NewVehicleFromReader(r io.Reader) *Vehicle {
+func NewVehicleFromReader(r io.Reader) (*Vehicle, error) {
 	// Top-level information
-	util.GetUint32(r)                       // Version
+	if _, err := ReadVersion(r, "vehicle", vehicleVersion); err != nil { // Version
+		return nil, err
+	}
 	p := util.GetPoint(r)                   // Position
 	s := util.GetPoint(r)                   // Size
 	v := newVehicle(s)                      // Create base vehicle
@@ -151,24 +168,28 @@ func NewVehicleFromReader(r io.Reader) *Vehicle {
 	for idx := 0; idx < v.Size.X*v.Size.Y; idx++ {
 		nParts := int(util.GetByte(r))            // Number of parts
 		for iPart := 0; iPart < nParts; iPart++ { // Parts
-			v.Locations[idx].Add(NewItemFromReader(r))
+			i, err := NewItemFromReader(r)
+			if err != nil {
+				return nil, err
+			}
+			v.Locations[idx].Add(i)
 		}
 	}
-	return v
+	return v, nil
 }
 
 // Write writes the vehicle to the writer.
 func (v *Vehicle) Write(w io.Writer) {
-	util.PutUint32(w, 0)             // Version
-	util.PutPoint(w, v.Bounds.TL)    // Position
-	util.PutPoint(w, v.Size)         // North-facing dimensions
-	util.PutString(w, v.Name)        // Name
-	util.PutByte(w, byte(v.Facing))  // Facing
-	util.PutFloat(w, v.Speed)        // Forward speed
-	util.PutFloat(w, v.TopSpeed)     // Top forward speed
-	util.PutFloat(w, v.Acceleration) // Acceleration
-	util.PutByte(w, byte(v.Heading)) // Movement heading
-	util.PutFloat(w, v.stp)          // Sub-tile position
+	util.PutUint32(w, vehicleVersion) // Version
+	util.PutPoint(w, v.Bounds.TL)     // Position
+	util.PutPoint(w, v.Size)          // North-facing dimensions
+	util.PutString(w, v.Name)         // Name
+	util.PutByte(w, byte(v.Facing))   // Facing
+	util.PutFloat(w, v.Speed)         // Forward speed
+	util.PutFloat(w, v.TopSpeed)      // Top forward speed
+	util.PutFloat(w, v.Acceleration)  // Acceleration
+	util.PutByte(w, byte(v.Heading))  // Movement heading
+	util.PutFloat(w, v.stp)           // Sub-tile position
 	for _, l := range v.Locations {
 		util.PutByte(w, byte(len(l.Parts))) // Number of parts at this location
 		for _, p := range l.Parts {         // Parts
@@ -269,20 +290,22 @@ func (v *Vehicle) doTurn(left bool, cm *CityMap) {
 // Update handles short term updates for vehicles.
 func (v *Vehicle) Update(d time.Duration, cm *CityMap) {
 	// Handle acceleration and deceleration
+	// Worn engines lose power
+	ep := v.EnginePower()
 	switch v.AccelerationState {
 	case AccelerationStateAccelerating:
-		v.Speed += (float64(d) / float64(time.Second)) * v.Acceleration
-		if v.Speed > v.TopSpeed {
-			v.Speed = v.TopSpeed
+		v.Speed += (float64(d) / float64(time.Second)) * v.Acceleration * ep
+		if v.Speed > v.TopSpeed*ep {
+			v.Speed = max(v.TopSpeed*ep, 0)
 		}
 	case AccelerationStateDecelerating:
 		m := v.Acceleration * 2
 		if v.Speed <= 0 {
-			m = v.Acceleration / 4
+			m = v.Acceleration / 4 * ep
 		}
 		v.Speed -= (float64(d) / float64(time.Second)) * m
-		if v.Speed < -v.TopSpeed/4 {
-			v.Speed = -v.TopSpeed / 4
+		if v.Speed < -v.TopSpeed*ep/4 {
+			v.Speed = min(-v.TopSpeed*ep/4, 0)
 		}
 	case AccelerationStateIdle:
 		if v.Speed > 0 {
@@ -297,6 +320,10 @@ func (v *Vehicle) Update(d time.Duration, cm *CityMap) {
 			}
 		}
 	}
+	// Engine noise grows louder with speed
+	if v.Speed != 0 || v.AccelerationState == AccelerationStateAccelerating {
+		cm.MakeNoise(v.Bounds.Center(), NoiseEngine+int(math.Abs(v.Speed)/4))
+	}
 	mt := math.Abs(v.Speed) * (float64(d) / float64(time.Hour)) // Miles traveled
 	v.stp += mt * 1760 / 4                                      // Tiles traveled
 	// Handle turning
@@ -315,9 +342,63 @@ func (v *Vehicle) Update(d time.Duration, cm *CityMap) {
 	}
 	for ; v.stp >= 1; v.stp -= 1 {
 		if !cm.MoveVehicle(v, ofs) {
+			v.crash(ofs, cm)
 			v.stp = 0
 			v.Speed = 0
 			return
 		}
 	}
 }
+
+// EnginePower returns the fraction of full power the vehicle's engines produce
+// given their wear, zero if all of its engines are broken. Vehicles without
+// engine parts always have full power.
+func (v *Vehicle) EnginePower() float64 {
+	ret := 0.0
+	n := 0
+	for _, l := range v.Locations {
+		for _, p := range l.Parts {
+			if p.VehicleEngine {
+				ret += p.Effectiveness()
+				n++
+			}
+		}
+	}
+	if n == 0 {
+		return 1
+	}
+	return ret / float64(n)
+}
+
+// crash damages the top-most part at each location along the leading edge of
+// the vehicle after it runs into something while moving by ofs.
+func (v *Vehicle) crash(ofs util.Point, cm *CityMap) {
+	d := math.Abs(v.Speed) * vehicleCrashDamage
+	if d <= 0 {
+		return
+	}
+	if v.Bounds.Contains(cm.Player.Position) {
+		Log.Log(termui.ColorRed, "The %s crashes!", v.Name)
+	}
+	cm.MakeNoise(v.Bounds.Center(), NoiseSmash)
+	for p := v.Bounds.TL; p.Y <= v.Bounds.BR.Y; p.Y++ {
+		for p.X = v.Bounds.TL.X; p.X <= v.Bounds.BR.X; p.X++ {
+			if v.Bounds.Contains(p.Add(ofs)) {
+				continue
+			}
+			l := v.GetLocationAbsolute(p)
+			for i := len(l.Parts) - 1; i >= 0; i-- {
+				part := l.Parts[i]
+				if part.Durability <= 0 || part.Broken() {
+					continue
+				}
+				if part.Degrade(d) && v.Bounds.Contains(cm.Player.Position) {
+					Log.Log(termui.ColorYellow, "The %s's %s is wrecked.", v.Name, part.Name)
+				}
+				break
+			}
+			l.UpdateFlags()
+		}
+	}
+	cm.FlagBitmapsForVehicle(v, v.Bounds)
+}
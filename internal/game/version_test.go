@@ -0,0 +1,20 @@
+package game
+
+import "testing"
+
+func TestLayoutVersions(t *testing.T) {
+	for _, tc := range []struct {
+		record string
+		layout uint32
+		want   uint32
+	}{
+		{"item", itemLayout.version(), itemVersion},
+		{"actor", actorLayout.version(), actorVersion},
+		{"chunk", chunkLayout.version(), chunkVersion},
+		{"dynamic data", dynamicDataLayout.version(), dynamicDataVersion},
+	} {
+		if tc.layout != tc.want {
+			t.Errorf("%s layout describes version %d, record version is %d", tc.record, tc.layout, tc.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package game
+
+import (
+	"io"
+	"math"
+	"time"
+
+	"github.com/qbradq/after/lib/util"
+)
+
+// Season is a code that indicates the season of the year.
+type Season uint8
+
+const (
+	SeasonSpring Season = 0
+	SeasonSummer Season = 1
+	SeasonAutumn Season = 2
+	SeasonWinter Season = 3
+)
+
+// seasonInfo describes the weather of each season.
+var seasonInfo = []struct {
+	Name       string  // Descriptive name
+	PrecipOdds float64 // Chance of each weather front bringing rain or snow
+	MaxWind    float64 // Highest wind speed of a weather front in miles per hour
+}{
+	{"spring", 0.4, 25},
+	{"summer", 0.25, 15},
+	{"autumn", 0.35, 25},
+	{"winter", 0.3, 30},
+}
+
+// String implements the fmt.Stringer interface.
+func (s Season) String() string {
+	return seasonInfo[s].Name
+}
+
+// Weather parameters, temperatures are in degrees Fahrenheit.
+const (
+	weatherMeanTemp     float64 = 55  // Mean temperature over the year
+	weatherSeasonSwing  float64 = 25  // Difference between the mean and the warmest and coldest days of the year
+	weatherDailySwing   float64 = 10  // Difference between the daily mean and the warmest and coldest hours under clear skies
+	weatherFrontSwing   float64 = 10  // Largest difference between a front's temperature and the normal temperature
+	weatherFrontMin     int     = 2   // Shortest weather front in hours
+	weatherFrontMax     int     = 12  // Longest weather front in hours
+	weatherSnowTemp     float64 = 34  // Temperature at or below which precipitation falls as snow
+	weatherWindy        float64 = 20  // Wind speed in miles per hour considered windy
+	weatherRainWash     float64 = 6   // Multiple of elapsed time that a downpour ages scent outdoors
+	weatherHeavyPrecip  float64 = 0.6 // Precipitation considered heavy
+	weatherLightPrecip  float64 = 0.3 // Precipitation considered light
+	weatherOvercast     float64 = 0.7 // Cloud cover considered overcast
+	weatherCloudy       float64 = 0.3 // Cloud cover considered cloudy
+	weatherCloudyFront  float64 = 0.7 // Least cloud cover of a front bringing rain or snow
+	weatherCloudDamping float64 = 0.5 // Fraction of the daily temperature swing removed by full cloud cover
+)
+
+// Weather describes the weather front passing over the city.
+type Weather struct {
+	Precipitation float64   // Intensity of rain or snow from zero (none) to one (downpour)
+	Cloud         float64   // Cloud cover from zero (clear) to one (overcast)
+	Wind          float64   // Wind speed in miles per hour
+	TempOffset    float64   // Difference between the front's temperature and the normal temperature
+	Until         time.Time // Time the front passes
+}
+
+// readWeather reads the weather from r.
+func readWeather(r io.Reader) Weather {
+	return Weather{
+		Precipitation: util.GetFloat(r),
+		Cloud:         util.GetFloat(r),
+		Wind:          util.GetFloat(r),
+		TempOffset:    util.GetFloat(r),
+		Until:         util.GetTime(r),
+	}
+}
+
+// writeWeather writes the weather to w.
+func writeWeather(w io.Writer, wx Weather) {
+	util.PutFloat(w, wx.Precipitation) // Precipitation
+	util.PutFloat(w, wx.Cloud)         // Cloud cover
+	util.PutFloat(w, wx.Wind)          // Wind speed
+	util.PutFloat(w, wx.TempOffset)    // Temperature offset
+	util.PutTime(w, wx.Until)          // Front end time
+}
+
+// Season returns the current season.
+func (m *CityMap) Season() Season {
+	switch m.Now.Month() {
+	case time.March, time.April, time.May:
+		return SeasonSpring
+	case time.June, time.July, time.August:
+		return SeasonSummer
+	case time.September, time.October, time.November:
+		return SeasonAutumn
+	default:
+		return SeasonWinter
+	}
+}
+
+// OutdoorTemperature returns the current outdoor temperature in degrees
+// Fahrenheit. The coldest day of the year falls in mid-January and the
+// coldest hour of the day before dawn.
+func (m *CityMap) OutdoorTemperature() float64 {
+	doy := float64(m.Now.YearDay())
+	h := float64(m.Now.Hour()) + float64(m.Now.Minute())/60
+	season := -weatherSeasonSwing * math.Cos(2*math.Pi*(doy-15)/365)
+	day := -weatherDailySwing * (1 - m.Weather.Cloud*weatherCloudDamping) *
+		math.Cos(2*math.Pi*(h-3)/24)
+	return weatherMeanTemp + season + day + m.Weather.TempOffset
+}
+
+// Snowing returns true if the current precipitation is falling as snow.
+func (m *CityMap) Snowing() bool {
+	return m.Weather.Precipitation > 0 && m.OutdoorTemperature() <= weatherSnowTemp
+}
+
+// WeatherDescription returns a short description of the current weather.
+func (m *CityMap) WeatherDescription() string {
+	wx := m.Weather
+	if wx.Precipitation > 0 {
+		switch {
+		case m.Snowing() && wx.Precipitation >= weatherHeavyPrecip:
+			return "Heavy snow"
+		case m.Snowing() && wx.Precipitation < weatherLightPrecip:
+			return "Flurries"
+		case m.Snowing():
+			return "Snow"
+		case wx.Precipitation >= weatherHeavyPrecip:
+			return "Heavy rain"
+		case wx.Precipitation < weatherLightPrecip:
+			return "Drizzle"
+		default:
+			return "Rain"
+		}
+	}
+	switch {
+	case wx.Wind >= weatherWindy:
+		return "Windy"
+	case wx.Cloud >= weatherOvercast:
+		return "Overcast"
+	case wx.Cloud >= weatherCloudy:
+		return "Cloudy"
+	default:
+		return "Clear"
+	}
+}
+
+// updateWeather brings in new weather fronts as old ones pass and lets rain
+// wash away scent outdoors.
+func (m *CityMap) updateWeather(d time.Duration) {
+	for !m.Now.Before(m.Weather.Until) {
+		m.newWeatherFront()
+	}
+	if m.Weather.Precipitation > 0 && !m.Snowing() {
+		m.WashScent(m.updateBounds, time.Duration(float64(d)*m.Weather.Precipitation*weatherRainWash))
+	}
+}
+
+// newWeatherFront replaces the weather with a new front typical of the season
+// following on from the old one.
+func (m *CityMap) newWeatherFront() {
+	si := seasonInfo[m.Season()]
+	start := m.Weather.Until
+	if start.IsZero() {
+		start = m.Now
+	}
+	wx := Weather{
+		Cloud:      util.RandomF(0, 1),
+		Wind:       util.RandomF(0, si.MaxWind),
+		TempOffset: util.RandomF(-weatherFrontSwing, weatherFrontSwing),
+		Until:      start.Add(time.Duration(util.Random(weatherFrontMin, weatherFrontMax+1)) * time.Hour),
+	}
+	if util.RandomF(0, 1) < si.PrecipOdds {
+		wx.Cloud = max(wx.Cloud, weatherCloudyFront)
+		wx.Precipitation = util.RandomF(0.1, 1)
+	}
+	m.Weather = wx
+}
@@ -0,0 +1,518 @@
+// Package headless implements a simulation harness that drives a
+// [game.CityMap] from scripted actions without a terminal.
+package headless
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/qbradq/after/internal/ai"
+	"github.com/qbradq/after/internal/citygen"
+	"github.com/qbradq/after/internal/events"
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/internal/mods"
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// Config describes the world a simulation runs in.
+type Config struct {
+	ModsPath string   // Directory to discover mods in, empty to use the mods discovered at startup
+	Mods     []string // IDs of the mods to load, in load order
+	CityGen  string   // Name of the city generator to use
+	Scenario string   // Name of the scenario to execute
+	Seed     int64    // World generation seed, also seeds each simulation step
+}
+
+// Sim is a running headless simulation.
+type Sim struct {
+	CityMap *game.CityMap // The city being simulated
+	Lines   []string      // All log lines produced so far
+	Steps   int           // Number of actions executed so far
+	seed    int64         // World seed
+}
+
+// New loads the configured mods, generates a new city in a scratch save and
+// returns the simulation ready to accept actions. Close must be called when
+// the simulation is no longer needed.
+func New(cfg Config) (*Sim, error) {
+	if cfg.ModsPath != "" {
+		if err := mods.Discover(cfg.ModsPath); err != nil {
+			return nil, err
+		}
+	}
+	if err := mods.LoadMods(cfg.Mods); err != nil {
+		return nil, err
+	}
+	if _, found := citygen.CityGens[cfg.CityGen]; !found {
+		return nil, fmt.Errorf("city generator %s not found", cfg.CityGen)
+	}
+	if _, found := citygen.Scenarios[cfg.Scenario]; !found {
+		return nil, fmt.Errorf("scenario %s not found", cfg.Scenario)
+	}
+	if err := game.NewScratchSave(); err != nil {
+		return nil, err
+	}
+	s := &Sim{
+		seed: cfg.Seed,
+	}
+	game.Log = s
+	m, err := citygen.Generate(cfg.CityGen, cfg.Scenario, cfg.Seed)
+	if err != nil {
+		game.CloseSave()
+		return nil, err
+	}
+	s.CityMap = m
+	if err := s.CityMap.SaveCityPlan(); err != nil {
+		game.CloseSave()
+		return nil, err
+	}
+	// The first update brings in the first weather front among other things,
+	// so it is seeded like every action
+	util.WithSeed(s.seed, func() {
+		err = s.CityMap.Update(s.CityMap.Player.Position, 0, nil)
+	})
+	if err != nil {
+		game.CloseSave()
+		return nil, err
+	}
+	if err := s.CityMap.FullSave(); err != nil {
+		game.CloseSave()
+		return nil, err
+	}
+	if err := game.SaveTileRefs(); err != nil {
+		game.CloseSave()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close releases all resources associated with the simulation and removes its
+// scratch save.
+func (s *Sim) Close() {
+	game.CloseSave()
+}
+
+// Log implements the game.Logger interface.
+func (s *Sim) Log(c termui.Color, f string, args ...any) {
+	s.Lines = append(s.Lines, fmt.Sprintf(f, args...))
+}
+
+// Run executes each action in order, stopping at the first error.
+func (s *Sim) Run(actions ...string) error {
+	for _, a := range actions {
+		if err := s.Do(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do executes a single scripted action. Each action is a verb followed by an
+// optional argument separated by white space. Directions are given as compass
+// points such as n, ne and sw. Supported actions are:
+//
+//	walk DIR       Walk, bump-attack or use things the same as the game client
+//	climb DIR      Climb over an obstacle
+//	stairs up|down Go up or down the stairs or ladder at the player's feet
+//	attack DIR     Attack the actor in the given direction
+//	smash DIR      Smash the door, wall or fixture in the given direction
+//	use DIR        Use the top-most item in the given direction
+//	fire X,Y       Fire or throw the wielded weapon at the offset from the player
+//	reload         Reload the wielded weapon
+//	wait DURATION  Rest for a Go duration such as 1s or 2h30m
+//	rest DURATION  Rest for a duration, stopping early if interrupted
+//	sleep DURATION Sleep for a duration, stopping early if interrupted
+//	run on|off     Start or stop running
+//	control        Take or release control of the vehicle the player is in
+//	craft RECIPE   Craft the recipe with the given ID
+//	repair ITEM    Repair the most worn item with the given template ID
+//	resume         Resume the interrupted activity
+//	talk DIR N...  Talk to the actor in the given direction, choosing the
+//	               numbered dialogue options in order
+//	trade DIR ITEM[*N]... for ITEM[*N]...
+//	               Trade with the actor or fixture in the given direction,
+//	               offering the inventory items before "for" and asking for
+//	               the stock items after it
+//
+// The random number generator is seeded from the world seed and step number
+// for each action so scripts are reproducible.
+func (s *Sim) Do(action string) error {
+	var err error
+	fields := strings.Fields(action)
+	if len(fields) < 1 {
+		return errors.New("empty action")
+	}
+	if s.CityMap.Player.Dead {
+		return fmt.Errorf("action %q given after player death", action)
+	}
+	util.WithSeed(util.SubSeed(s.seed, uint64(s.Steps)), func() {
+		err = s.do(fields)
+	})
+	s.Steps++
+	if err != nil {
+		return fmt.Errorf("step %d %q: %w", s.Steps, action, err)
+	}
+	return nil
+}
+
+// do executes the action described by the fields.
+func (s *Sim) do(fields []string) error {
+	m := s.CityMap
+	arg := ""
+	if len(fields) > 1 {
+		arg = fields[1]
+	}
+	switch fields[0] {
+	case "walk":
+		d, err := parseDirection(arg)
+		if err != nil {
+			return err
+		}
+		if m.Player.InControl {
+			return s.drive(d)
+		}
+		if !m.StepPlayer(false, d) {
+			return s.bump(d)
+		}
+	case "climb":
+		d, err := parseDirection(arg)
+		if err != nil {
+			return err
+		}
+		if !m.StepPlayer(true, d) {
+			return errors.New("unable to climb")
+		}
+	case "stairs":
+		switch arg {
+		case "up":
+			if !m.PlayerTakeStairs(true) {
+				return errors.New("unable to go up")
+			}
+		case "down":
+			if !m.PlayerTakeStairs(false) {
+				return errors.New("unable to go down")
+			}
+		default:
+			return fmt.Errorf("bad stairs argument %q", arg)
+		}
+	case "attack":
+		d, err := parseDirection(arg)
+		if err != nil {
+			return err
+		}
+		a := m.ActorAt(m.Player.Position.Step(d))
+		if a == nil {
+			return errors.New("nothing to attack")
+		}
+		m.PlayerAttack(a)
+		m.PlayerTookTurn(time.Second, nil)
+	case "smash":
+		d, err := parseDirection(arg)
+		if err != nil {
+			return err
+		}
+		if !m.PlayerSmash(m.Player.Position.Step(d)) {
+			return errors.New("unable to smash")
+		}
+		m.PlayerTookTurn(time.Duration(float64(time.Second)*m.Player.ActSpeed()), nil)
+	case "fire":
+		var o util.Point
+		if _, err := fmt.Sscanf(arg, "%d,%d", &o.X, &o.Y); err != nil {
+			return fmt.Errorf("bad offset %q", arg)
+		}
+		if !m.PlayerFire(m.Player.Position.Add(o)) {
+			return errors.New("unable to fire")
+		}
+		m.PlayerTookTurn(time.Duration(float64(time.Second)*m.Player.ActSpeed()), nil)
+	case "reload":
+		if !m.Reload(nil) {
+			return errors.New("unable to reload")
+		}
+	case "use":
+		d, err := parseDirection(arg)
+		if err != nil {
+			return err
+		}
+		items := m.ItemsAt(m.Player.Position.Step(d))
+		if len(items) < 1 {
+			return errors.New("nothing to use")
+		}
+		return s.use(items[len(items)-1])
+	case "wait":
+		d, err := time.ParseDuration(arg)
+		if err != nil {
+			return err
+		}
+		m.PlayerTookTurn(d, nil)
+	case "rest", "sleep":
+		d, err := time.ParseDuration(arg)
+		if err != nil {
+			return err
+		}
+		if fields[0] == "rest" {
+			m.PlayerRest(d, nil)
+		} else {
+			m.PlayerSleep(d, nil)
+		}
+	case "run":
+		switch arg {
+		case "on":
+			m.Player.Running = true
+		case "off":
+			m.Player.Running = false
+		default:
+			return fmt.Errorf("bad run argument %q", arg)
+		}
+	case "control":
+		if m.Player.InControl {
+			m.Player.InControl = false
+			return nil
+		}
+		v := m.VehicleAt(m.Player.Position)
+		if v == nil {
+			return errors.New("not within a vehicle")
+		}
+		l := v.GetLocationAbsolute(m.Player.Position)
+		if l == nil {
+			return errors.New("not within a vehicle")
+		}
+		for _, p := range l.Parts {
+			if p.TemplateID == "VehicleControls" {
+				m.Player.InControl = true
+				return nil
+			}
+		}
+		return errors.New("no vehicle controls here")
+	case "resume":
+		if m.Player.Activity == nil {
+			return errors.New("no activity to resume")
+		}
+		m.ResumeActivity(nil)
+	case "craft":
+		r, found := game.RecipeDefs[arg]
+		if !found {
+			return fmt.Errorf("unknown recipe %q", arg)
+		}
+		if !m.Craft(r, nil) {
+			return errors.New("unable to craft")
+		}
+	case "repair":
+		for _, i := range m.RepairableItems() {
+			if i.TemplateID == arg {
+				if !m.Repair(i, nil) {
+					return errors.New("unable to repair")
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("nothing to repair matching %q", arg)
+	case "talk":
+		d, err := parseDirection(arg)
+		if err != nil {
+			return err
+		}
+		return s.talk(d, fields[2:])
+	case "trade":
+		d, err := parseDirection(arg)
+		if err != nil {
+			return err
+		}
+		return s.trade(d, fields[2:])
+	default:
+		return fmt.Errorf("unknown action %s", fields[0])
+	}
+	return nil
+}
+
+// bump handles the player bumping into something the same way the game client
+// does, minus climbing which requires confirmation.
+func (s *Sim) bump(d util.Direction) error {
+	m := s.CityMap
+	np := m.Player.Position.Step(d)
+	// Try attacking first
+	if a := m.ActorAt(np); a != nil {
+		m.PlayerAttack(a)
+		m.PlayerTookTurn(time.Duration(float64(time.Second)*m.Player.ActSpeed()), nil)
+		return nil
+	}
+	// Vehicle handling
+	if v := m.VehicleAt(np); v != nil {
+		l := v.GetLocationAbsolute(np)
+		for _, p := range l.Parts {
+			if _, found := p.Events["Use"]; !found {
+				continue
+			}
+			err, used := events.ExecuteVehicleEvent("Use", v, l, p, np, &m.Player.Actor, m)
+			if err != nil {
+				return err
+			}
+			if used {
+				m.PlayerTookTurn(time.Duration(float64(time.Second)*m.Player.ActSpeed()), nil)
+			}
+			return nil
+		}
+		return nil
+	}
+	// Try to use fixed items
+	for _, i := range m.ItemsAt(np) {
+		if !i.Fixed || i.Events == nil {
+			continue
+		}
+		if _, found := i.Events["Use"]; !found {
+			continue
+		}
+		return s.use(i)
+	}
+	return nil
+}
+
+// talk holds a conversation with the actor in direction d, choosing the
+// 1-based dialogue options in order.
+func (s *Sim) talk(d util.Direction, choices []string) error {
+	m := s.CityMap
+	a := m.ActorAt(m.Player.Position.Step(d))
+	if a == nil {
+		return errors.New("nobody to talk to")
+	}
+	n, reason := m.StartDialogue(a)
+	if n == nil {
+		return errors.New(reason)
+	}
+	for _, c := range choices {
+		if n == nil {
+			return errors.New("conversation is over")
+		}
+		s.Log(termui.ColorWhite, "%s", n.Say(a))
+		opts := m.DialogueOptions(n, a)
+		i, err := strconv.Atoi(c)
+		if err != nil || i < 1 || i > len(opts) {
+			return fmt.Errorf("bad dialogue option %q", c)
+		}
+		s.Log(termui.ColorWhite, "%s", opts[i-1].Text)
+		n = m.ChooseDialogueOption(a, opts[i-1])
+	}
+	if n != nil {
+		s.Log(termui.ColorWhite, "%s", n.Say(a))
+	}
+	return nil
+}
+
+// trade barters with the actor or fixture in direction d. Terms are item
+// template IDs optionally followed by *N for the amount. Terms before "for"
+// are offered from the player's inventory, those after are asked for from the
+// stock.
+func (s *Sim) trade(d util.Direction, terms []string) error {
+	m := s.CityMap
+	np := m.Player.Position.Step(d)
+	var t *game.Trade
+	reason := "nothing to trade with"
+	if a := m.ActorAt(np); a != nil {
+		t, reason = m.StartTrade(a)
+	} else {
+		for _, i := range m.ItemsAt(np) {
+			if i.Fixed && i.Stock {
+				t, reason = m.StartFixtureTrade(i)
+				break
+			}
+		}
+	}
+	if t == nil {
+		return errors.New(reason)
+	}
+	items, set := m.Player.Inventory, t.SetOffer
+	for _, term := range terms {
+		if term == "for" {
+			items, set = t.Stock.Inventory, t.SetAsk
+			continue
+		}
+		id, ns, found := strings.Cut(term, "*")
+		n := 0
+		if found {
+			var err error
+			if n, err = strconv.Atoi(ns); err != nil {
+				return fmt.Errorf("bad trade amount %q", term)
+			}
+		}
+		idx := slices.IndexFunc(items, func(i *game.Item) bool { return i.TemplateID == id })
+		if idx < 0 {
+			return fmt.Errorf("no %s to trade", id)
+		}
+		if !found {
+			n = items[idx].StackAmount()
+		}
+		set(items[idx], n)
+	}
+	if r := m.CompleteTrade(t); r != "" {
+		return errors.New(r)
+	}
+	return nil
+}
+
+// use executes the use event of the item.
+func (s *Sim) use(i *game.Item) error {
+	m := s.CityMap
+	err, used := events.ExecuteItemUseEvent("Use", i, &m.Player.Actor, m)
+	if err != nil {
+		return err
+	}
+	if used {
+		m.PlayerTookTurn(time.Duration(float64(time.Second)*m.Player.ActSpeed()), nil)
+	}
+	return nil
+}
+
+// drive operates the controls of the vehicle the player is in.
+func (s *Sim) drive(d util.Direction) error {
+	m := s.CityMap
+	v := m.VehicleAt(m.Player.Position)
+	if v == nil {
+		return errors.New("not within a vehicle")
+	}
+	switch d {
+	case util.DirectionNorth:
+		v.AccelerationState = game.AccelerationStateAccelerating
+		m.PlayerTookTurn(time.Second, nil)
+		v.AccelerationState = game.AccelerationStateIdle
+	case util.DirectionSouth:
+		v.AccelerationState = game.AccelerationStateDecelerating
+		m.PlayerTookTurn(time.Second, nil)
+		v.AccelerationState = game.AccelerationStateIdle
+	case util.DirectionEast:
+		v.TurningState = game.TurningStateRight
+		m.PlayerTookTurn(time.Second, nil)
+		v.TurningState = game.TurningStateNone
+	case util.DirectionWest:
+		v.TurningState = game.TurningStateLeft
+		m.PlayerTookTurn(time.Second, nil)
+		v.TurningState = game.TurningStateNone
+	default:
+		return errors.New("vehicles can not be driven diagonally")
+	}
+	return nil
+}
+
+// directionNames maps compass point names to directions.
+var directionNames = map[string]util.Direction{
+	"n":  util.DirectionNorth,
+	"ne": util.DirectionNorthEast,
+	"e":  util.DirectionEast,
+	"se": util.DirectionSouthEast,
+	"s":  util.DirectionSouth,
+	"sw": util.DirectionSouthWest,
+	"w":  util.DirectionWest,
+	"nw": util.DirectionNorthWest,
+}
+
+// parseDirection returns the direction named by the compass point.
+func parseDirection(s string) (util.Direction, error) {
+	d, found := directionNames[strings.ToLower(s)]
+	if !found {
+		return util.DirectionInvalid, fmt.Errorf("bad direction %q", s)
+	}
+	return d, nil
+}
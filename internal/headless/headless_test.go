@@ -0,0 +1,84 @@
+package headless
+
+import (
+	"bytes"
+	"testing"
+)
+
+// testConfig is the world every regression test runs in.
+var testConfig = Config{
+	ModsPath: "../../mods",
+	Mods:     []string{"Base"},
+	CityGen:  "Interstate Town",
+	Scenario: "CombatTest",
+	Seed:     3,
+}
+
+// testTurns is the number of turns each regression run lasts.
+const testTurns = 10
+
+// runCombatTest runs the combat test scenario for testTurns turns and returns
+// the final snapshot and its JSON encoding.
+func runCombatTest(t *testing.T) (*Snapshot, []byte) {
+	t.Helper()
+	s, err := New(testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for i := 0; i < testTurns && !s.CityMap.Player.Dead; i++ {
+		if err := s.Do("wait 1s"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d, err := s.SnapshotJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.Snapshot(), d
+}
+
+func TestCombatScenario(t *testing.T) {
+	snap, _ := runCombatTest(t)
+	if snap.Player.Dead {
+		t.Fatalf("expected the player to survive %d turns", testTurns)
+	}
+	if snap.Steps != testTurns {
+		t.Fatalf("expected %d steps, got %d", testTurns, snap.Steps)
+	}
+	// The combat test room holds two zombies and two zombie children, more
+	// may wander nearby
+	zombies := 0
+	for _, a := range snap.Actors {
+		if a.TemplateID == "Zombie" || a.TemplateID == "ZombieChild" {
+			zombies++
+		}
+	}
+	if zombies < 4 {
+		t.Fatalf("expected at least 4 zombies near the player, got %d", zombies)
+	}
+	// Zombies shamble up to the player and attack
+	hurt := false
+	for _, h := range snap.Player.Health {
+		if h < 0 || h > 1 {
+			t.Errorf("player body part health %f outside of [0-1]", h)
+		}
+		if h < 1 {
+			hurt = true
+		}
+	}
+	if !hurt {
+		t.Error("expected the player to be hurt by the zombies")
+	}
+	if snap.Player.Weapon != "Crowbar" {
+		t.Errorf("expected the player to wield a crowbar, got %q", snap.Player.Weapon)
+	}
+}
+
+func TestDeterminism(t *testing.T) {
+	_, a := runCombatTest(t)
+	_, b := runCombatTest(t)
+	if !bytes.Equal(a, b) {
+		t.Fatalf("two runs with the same seed diverged:\n%s\n----\n%s", a, b)
+	}
+}
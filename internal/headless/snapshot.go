@@ -0,0 +1,136 @@
+package headless
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/lib/util"
+)
+
+// snapshotRadius is the radius in tiles around the player that is included in
+// snapshots.
+const snapshotRadius int = 32
+
+// Snapshot is a point-in-time description of the simulation state suitable for
+// assertions and JSON encoding.
+type Snapshot struct {
+	Steps    int               // Number of actions executed
+	Now      time.Time         // Current in-game time
+	Weather  string            // Description of the current weather
+	Temp     float64           // Outdoor temperature in degrees Fahrenheit
+	Player   PlayerSnapshot    // Player state
+	Actors   []ActorSnapshot   // All non-player actors near the player
+	Vehicles []VehicleSnapshot // All vehicles near the player
+	Log      []string          // All log lines produced so far
+}
+
+// ActorSnapshot describes a single actor.
+type ActorSnapshot struct {
+	TemplateID string     // Template the actor was created from
+	Position   util.Point // Absolute position
+	Dead       bool       // If true the actor is dead
+	Health     []float64  // Health of each body part
+	Effects    []string   // IDs of all status effects applied
+}
+
+// PlayerSnapshot describes the player.
+type PlayerSnapshot struct {
+	ActorSnapshot
+	Stamina   float64  // Stamina value
+	Hunger    float64  // Hunger value
+	Thirst    float64  // Thirst value
+	Sleep     float64  // Sleepiness value
+	Running   bool     // If true the player is running
+	InControl bool     // If true the player is controlling a vehicle
+	Weapon    string   // Template ID of the wielded weapon if any
+	Inventory []string // Template IDs of all items in the inventory
+	Activity  string   // Name of the interrupted activity if any
+	BodyTemp  float64  // Core body temperature in degrees Fahrenheit
+	Wetness   float64  // Wetness value
+	Level     int      // Level the player is on
+}
+
+// VehicleSnapshot describes a single vehicle.
+type VehicleSnapshot struct {
+	Name   string      // Name of the vehicle
+	Bounds util.Rect   // Current bounds in the city
+	Facing util.Facing // Current facing
+	Speed  float64     // Forward speed in scale miles per hour
+}
+
+// newActorSnapshot returns the snapshot of the actor.
+func newActorSnapshot(a *game.Actor) ActorSnapshot {
+	ret := ActorSnapshot{
+		TemplateID: a.TemplateID,
+		Position:   a.Position,
+		Dead:       a.Dead,
+		Health:     make([]float64, len(a.BodyParts)),
+		Effects:    []string{},
+	}
+	for _, e := range a.Effects {
+		ret.Effects = append(ret.Effects, e.ID)
+	}
+	for i, p := range a.BodyParts {
+		ret.Health[i] = p.Health
+	}
+	return ret
+}
+
+// Snapshot returns a snapshot of the current simulation state.
+func (s *Sim) Snapshot() *Snapshot {
+	m := s.CityMap
+	p := m.Player
+	ret := &Snapshot{
+		Steps:   s.Steps,
+		Now:     m.Now,
+		Weather: m.WeatherDescription(),
+		Temp:    m.OutdoorTemperature(),
+		Player: PlayerSnapshot{
+			ActorSnapshot: newActorSnapshot(&p.Actor),
+			Stamina:       p.Stamina,
+			Hunger:        p.Hunger,
+			Thirst:        p.Thirst,
+			Sleep:         p.Sleep,
+			Running:       p.Running,
+			InControl:     p.InControl,
+			BodyTemp:      p.BodyTemp,
+			Wetness:       p.Wetness,
+			Level:         game.LevelOf(p.Position),
+			Inventory:     []string{},
+		},
+		Actors:   []ActorSnapshot{},
+		Vehicles: []VehicleSnapshot{},
+		Log:      append([]string{}, s.Lines...),
+	}
+	if p.Weapon != nil {
+		ret.Player.Weapon = p.Weapon.TemplateID
+	}
+	if p.Activity != nil {
+		ret.Player.Activity = p.Activity.Name
+	}
+	for _, i := range p.Inventory {
+		ret.Player.Inventory = append(ret.Player.Inventory, i.TemplateID)
+	}
+	b := util.NewRectFromRadius(p.Position, snapshotRadius)
+	for _, a := range m.ActorsWithin(b) {
+		if a.IsPlayer {
+			continue
+		}
+		ret.Actors = append(ret.Actors, newActorSnapshot(a))
+	}
+	for _, v := range m.VehiclesWithin(b) {
+		ret.Vehicles = append(ret.Vehicles, VehicleSnapshot{
+			Name:   v.Name,
+			Bounds: v.Bounds,
+			Facing: v.Facing,
+			Speed:  v.Speed,
+		})
+	}
+	return ret
+}
+
+// SnapshotJSON returns the current simulation state as indented JSON.
+func (s *Sim) SnapshotJSON() ([]byte, error) {
+	return json.MarshalIndent(s.Snapshot(), "", "\t")
+}
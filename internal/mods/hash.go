@@ -0,0 +1,51 @@
+package mods
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Hash returns the hex-encoded SHA-256 hash of the contents of the mod. The
+// hash covers the relative path and contents of every file in the mod so any
+// change to the mod's content changes the hash.
+func Hash(id string) (string, error) {
+	mod, found := mods[id]
+	if !found {
+		return "", fmt.Errorf("mod %s not found", id)
+	}
+	h := sha256.New()
+	// WalkDir visits files in lexical order so the hash is stable
+	err := filepath.WalkDir(mod.Path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rp, err := filepath.Rel(mod.Path, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rp), info.Size())
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
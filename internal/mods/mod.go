@@ -16,31 +16,50 @@ import (
 )
 
 func init() {
-	// Load all mods
-	files, err := os.ReadDir("mods")
-	if err != nil {
+	// Discover all mods in the default location
+	if err := Discover("mods"); err != nil {
 		panic(err)
 	}
+}
+
+// Discover adds all mods found in the given directory to the set of available
+// mods. A missing directory is not an error, and discovering the same directory
+// more than once has no effect.
+func Discover(dir string) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
 	for _, f := range files {
 		if !f.IsDir() {
 			continue
 		}
 		p := &Mod{
-			Path: path.Join("mods", f.Name()),
+			Path: path.Join(dir, f.Name()),
 		}
 		d, err := os.ReadFile(path.Join(p.Path, "mod.json"))
 		if err != nil {
-			panic(err)
+			return err
+		}
+		if err := json.Unmarshal(d, p); err != nil {
+			return err
 		}
-		json.Unmarshal(d, p)
 		if len(p.ID) < 1 {
-			panic(fmt.Errorf("mod with no ID specified %s", p.Path))
+			return fmt.Errorf("mod with no ID specified %s", p.Path)
 		}
-		if _, found := mods[p.ID]; found {
-			panic(fmt.Errorf("duplicate mod ID %s from %s", p.ID, p.Path))
+		if m, found := mods[p.ID]; found {
+			if m.Path == p.Path {
+				// Already discovered
+				continue
+			}
+			return fmt.Errorf("duplicate mod ID %s from %s", p.ID, p.Path)
 		}
 		mods[p.ID] = p
 	}
+	return nil
 }
 
 // Global map of mods
@@ -70,6 +89,11 @@ func UnloadAllMods() {
 	game.ItemDefs = map[string]*game.Item{}
 	game.ActorDefs = map[string]*game.Actor{}
 	game.VehicleGenGroups = map[string]*game.VehicleGenGroup{}
+	game.RecipeDefs = map[string]*game.Recipe{}
+	game.RepairKitDefs = map[string]*game.RepairKit{}
+	game.StatusEffectDefs = map[string]*game.StatusEffectDef{}
+	game.FactionDefs = map[string]*game.FactionDef{}
+	game.DialogueDefs = map[string]*game.Dialogue{}
 }
 
 // LoadMods loads all of the listed mods.
@@ -85,6 +109,18 @@ func LoadMods(ids []string) error {
 			return err
 		}
 	}
+	// Status effects
+	for _, id := range ids {
+		if err := mods[id].loadStatusEffects(); err != nil {
+			return err
+		}
+	}
+	// Validate status effects
+	for _, e := range game.StatusEffectDefs {
+		if err := e.Validate(); err != nil {
+			return err
+		}
+	}
 	// Items
 	for _, id := range ids {
 		if err := mods[id].loadItems(); err != nil {
@@ -109,12 +145,96 @@ func LoadMods(ids []string) error {
 			return err
 		}
 	}
+	// Validate item status effects
+	for _, i := range game.ItemDefs {
+		if err := i.ValidateEffects(); err != nil {
+			return err
+		}
+	}
+	// Validate item weapon properties
+	for _, i := range game.ItemDefs {
+		if err := i.ValidateWeapon(); err != nil {
+			return err
+		}
+	}
+	// Validate item armor properties
+	for _, i := range game.ItemDefs {
+		if err := i.ValidateArmor(); err != nil {
+			return err
+		}
+	}
+	// Validate item light sources
+	for _, i := range game.ItemDefs {
+		if err := i.ValidateLight(); err != nil {
+			return err
+		}
+	}
+	// Validate stairs and ladders
+	for _, i := range game.ItemDefs {
+		if err := i.ValidateStairs(); err != nil {
+			return err
+		}
+	}
+	// Validate smashable items
+	for _, i := range game.ItemDefs {
+		if err := i.ValidateBash(); err != nil {
+			return err
+		}
+	}
+	// Repair kits
+	for _, id := range ids {
+		if err := mods[id].loadRepairKits(); err != nil {
+			return err
+		}
+	}
+	// Validate repair kits
+	for _, k := range game.RepairKitDefs {
+		if err := k.Validate(); err != nil {
+			return err
+		}
+	}
+	// Validate item durability properties
+	for _, i := range game.ItemDefs {
+		if err := i.ValidateDurability(); err != nil {
+			return err
+		}
+	}
+	// Factions
+	for _, id := range ids {
+		if err := mods[id].loadFactions(); err != nil {
+			return err
+		}
+	}
+	// Validate factions
+	for _, f := range game.FactionDefs {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+	}
+	// Dialogue
+	for _, id := range ids {
+		if err := mods[id].loadDialogue(); err != nil {
+			return err
+		}
+	}
+	// Validate dialogue
+	for _, d := range game.DialogueDefs {
+		if err := d.Validate(); err != nil {
+			return err
+		}
+	}
 	// Actors
 	for _, id := range ids {
 		if err := mods[id].loadActors(); err != nil {
 			return err
 		}
 	}
+	// Validate actors
+	for _, a := range game.ActorDefs {
+		if err := a.Validate(); err != nil {
+			return err
+		}
+	}
 	// Compile equipment statements
 	for _, a := range game.ActorDefs {
 		if err := a.CacheEquipmentStatements(); err != nil {
@@ -141,6 +261,16 @@ func LoadMods(ids []string) error {
 			return err
 		}
 	}
+	// Validate tiles filling empty levels
+	if err := game.ValidateLevelTiles(); err != nil {
+		return err
+	}
+	// Validate smashable tiles
+	for _, t := range game.TileDefs {
+		if err := t.ValidateBash(); err != nil {
+			return err
+		}
+	}
 	// TileGens
 	for _, id := range ids {
 		if err := mods[id].loadTileGens(); err != nil {
@@ -159,6 +289,18 @@ func LoadMods(ids []string) error {
 			return err
 		}
 	}
+	// Recipes
+	for _, id := range ids {
+		if err := mods[id].loadRecipes(); err != nil {
+			return err
+		}
+	}
+	// Validate recipes
+	for _, r := range game.RecipeDefs {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -299,14 +441,15 @@ func (m *Mod) loadChunkGens() error {
 			if len(g.Variant) < 1 {
 				return errors.New("chunk generator with no variant given")
 			}
-			if len(g.Map) != g.Height*game.ChunkHeight || len(g.Map[0]) != g.Width*game.ChunkWidth {
-				return fmt.Errorf("chunk generator group %s variant %s has the wrong dimensions", g.Group, g.Variant)
+			if err := validateChunkGenMap(g, g.Map); err != nil {
+				return err
 			}
-			for iRow, row := range g.Map {
-				for iCol, r := range row {
-					if _, found := g.Tiles[string(r)]; !found {
-						return fmt.Errorf("chunk generator %s at %dx%d references tile %s not in tiles list", g.Group, iCol, iRow, string(r))
-					}
+			for z, lm := range g.Levels {
+				if z == 0 || z < game.MinLevel || z > game.MaxLevel {
+					return fmt.Errorf("chunk generator group %s variant %s has a map for invalid level %d", g.Group, g.Variant, z)
+				}
+				if err := validateChunkGenMap(g, lm); err != nil {
+					return err
 				}
 			}
 			if group, found := citygen.ChunkGenGroups[g.Group]; found {
@@ -321,6 +464,22 @@ func (m *Mod) loadChunkGens() error {
 	return nil
 }
 
+// validateChunkGenMap returns an error if the map of one level of the chunk
+// generator has the wrong dimensions or references undefined tiles.
+func validateChunkGenMap(g *citygen.ChunkGen, rows []string) error {
+	if len(rows) != g.Height*game.ChunkHeight || len(rows[0]) != g.Width*game.ChunkWidth {
+		return fmt.Errorf("chunk generator group %s variant %s has the wrong dimensions", g.Group, g.Variant)
+	}
+	for iRow, row := range rows {
+		for iCol, r := range row {
+			if _, found := g.Tiles[string(r)]; !found {
+				return fmt.Errorf("chunk generator %s at %dx%d references tile %s not in tiles list", g.Group, iCol, iRow, string(r))
+			}
+		}
+	}
+	return nil
+}
+
 // loadActors loads the mod's actor definitions.
 func (m *Mod) loadActors() error {
 	files, err := os.ReadDir(path.Join(m.Path, "actors"))
@@ -473,3 +632,153 @@ func (m *Mod) loadVehicles() error {
 	}
 	return nil
 }
+
+// loadRecipes loads the mod's recipe definitions.
+func (m *Mod) loadRecipes() error {
+	files, err := os.ReadDir(path.Join(m.Path, "recipes"))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	for _, f := range files {
+		d, err := os.ReadFile(path.Join(m.Path, "recipes", f.Name()))
+		if err != nil {
+			return err
+		}
+		var recipes map[string]*game.Recipe
+		err = json.Unmarshal(d, &recipes)
+		if err != nil {
+			return err
+		}
+		for k, r := range recipes {
+			if _, found := game.RecipeDefs[k]; found {
+				return fmt.Errorf("duplicate recipe definition %s", k)
+			}
+			r.ID = k
+			game.RecipeDefs[k] = r
+		}
+	}
+	return nil
+}
+
+// loadRepairKits loads the mod's repair kit definitions.
+func (m *Mod) loadRepairKits() error {
+	files, err := os.ReadDir(path.Join(m.Path, "repairkits"))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	for _, f := range files {
+		d, err := os.ReadFile(path.Join(m.Path, "repairkits", f.Name()))
+		if err != nil {
+			return err
+		}
+		var kits map[string]*game.RepairKit
+		err = json.Unmarshal(d, &kits)
+		if err != nil {
+			return err
+		}
+		for k, r := range kits {
+			if _, found := game.RepairKitDefs[k]; found {
+				return fmt.Errorf("duplicate repair kit definition %s", k)
+			}
+			r.ID = k
+			game.RepairKitDefs[k] = r
+		}
+	}
+	return nil
+}
+
+// loadStatusEffects loads the mod's status effect definitions.
+func (m *Mod) loadStatusEffects() error {
+	files, err := os.ReadDir(path.Join(m.Path, "effects"))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	for _, f := range files {
+		d, err := os.ReadFile(path.Join(m.Path, "effects", f.Name()))
+		if err != nil {
+			return err
+		}
+		var effects map[string]*game.StatusEffectDef
+		err = json.Unmarshal(d, &effects)
+		if err != nil {
+			return err
+		}
+		for k, e := range effects {
+			if _, found := game.StatusEffectDefs[k]; found {
+				return fmt.Errorf("duplicate status effect definition %s", k)
+			}
+			e.ID = k
+			game.StatusEffectDefs[k] = e
+		}
+	}
+	return nil
+}
+
+// loadFactions loads the mod's faction definitions.
+func (m *Mod) loadFactions() error {
+	files, err := os.ReadDir(path.Join(m.Path, "factions"))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	for _, f := range files {
+		d, err := os.ReadFile(path.Join(m.Path, "factions", f.Name()))
+		if err != nil {
+			return err
+		}
+		var factions map[string]*game.FactionDef
+		err = json.Unmarshal(d, &factions)
+		if err != nil {
+			return err
+		}
+		for k, f := range factions {
+			if _, found := game.FactionDefs[k]; found {
+				return fmt.Errorf("duplicate faction definition %s", k)
+			}
+			f.ID = k
+			game.FactionDefs[k] = f
+		}
+	}
+	return nil
+}
+
+// loadDialogue loads the mod's dialogue trees.
+func (m *Mod) loadDialogue() error {
+	files, err := os.ReadDir(path.Join(m.Path, "dialogue"))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	for _, f := range files {
+		d, err := os.ReadFile(path.Join(m.Path, "dialogue", f.Name()))
+		if err != nil {
+			return err
+		}
+		var dialogues map[string]*game.Dialogue
+		err = json.Unmarshal(d, &dialogues)
+		if err != nil {
+			return err
+		}
+		for k, d := range dialogues {
+			if _, found := game.DialogueDefs[k]; found {
+				return fmt.Errorf("duplicate dialogue definition %s", k)
+			}
+			d.ID = k
+			game.DialogueDefs[k] = d
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+// Package savearchive packs saves into portable, compressed archives and
+// unpacks them again. An archive is a gzip-compressed tar file containing a
+// manifest describing the save followed by the save database.
+package savearchive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/qbradq/after/internal/game"
+	"github.com/qbradq/after/internal/mods"
+)
+
+// Version is the current version of the archive layout.
+const Version uint32 = 1
+
+// Names of the archive entries, in the order they appear
+const (
+	manifestName = "manifest.json"
+	databaseName = "save.db"
+)
+
+// ErrModMismatch is returned by Import when a mod used by the save is present
+// but its content differs from the content the save was exported with.
+var ErrModMismatch = errors.New("mod content does not match")
+
+// ModInfo identifies a single mod used by an archived save.
+type ModInfo struct {
+	ID   string // Mod ID
+	Hash string // Content hash of the mod, see mods.Hash
+}
+
+// Manifest describes the save contained in an archive.
+type Manifest struct {
+	Version       uint32        // Archive layout version, see Version
+	Name          string        // Human-readable name of the save
+	Seed          int64         // World generation seed
+	Mods          []ModInfo     // Mods used by the save in load order
+	EngineVersion string        // Version of the engine that exported the save
+	SaveVersion   uint32        // Version of the save format
+	PlayTime      time.Duration // Total time the save has been played
+	Exported      time.Time     // Time of export
+	DatabaseHash  string        // SHA-256 hash of the save database
+}
+
+// Export writes the save with the given ID to w as a compressed archive. The
+// save must not be open.
+func Export(id string, w io.Writer) error {
+	si, found := game.Saves[id]
+	if !found {
+		return fmt.Errorf("save file %s not found", id)
+	}
+	m := &Manifest{
+		Version:       Version,
+		Name:          si.Name,
+		Seed:          si.Seed,
+		EngineVersion: si.EngineVersion,
+		SaveVersion:   si.SaveVersion,
+		PlayTime:      si.PlayTime,
+		Exported:      time.Now(),
+	}
+	for _, mid := range si.Mods {
+		h, err := mods.Hash(mid)
+		if err != nil {
+			return err
+		}
+		m.Mods = append(m.Mods, ModInfo{
+			ID:   mid,
+			Hash: h,
+		})
+	}
+	// Spool the database to a temporary file so it can be hashed before the
+	// manifest is written
+	f, err := os.CreateTemp("", "after-export-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	h := sha256.New()
+	if err := game.ExportSave(id, io.MultiWriter(f, h)); err != nil {
+		return err
+	}
+	m.DatabaseHash = hex.EncodeToString(h.Sum(nil))
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	d, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	// Write the archive
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    manifestName,
+		Mode:    0644,
+		Size:    int64(len(d)),
+		ModTime: m.Exported,
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(d); err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    databaseName,
+		Mode:    0644,
+		Size:    size,
+		ModTime: m.Exported,
+	}); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, f); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Validate returns an error if the save described by the manifest can not be
+// loaded with the mods available. Content hash mismatches are reported with an
+// error wrapping ErrModMismatch.
+func (m *Manifest) Validate() error {
+	if m.Version > Version {
+		return fmt.Errorf("archive version %d is newer than supported version %d", m.Version, Version)
+	}
+	if m.SaveVersion > game.SaveVersion {
+		return fmt.Errorf("save version %d is newer than supported version %d", m.SaveVersion, game.SaveVersion)
+	}
+	for _, mi := range m.Mods {
+		h, err := mods.Hash(mi.ID)
+		if err != nil {
+			return err
+		}
+		if h != mi.Hash {
+			return fmt.Errorf("%w: %s", ErrModMismatch, mi.ID)
+		}
+	}
+	return nil
+}
+
+// Import reads an archive written by Export from r, validates it and adds the
+// save it contains to game.Saves under a new ID. If force is true mod content
+// mismatches are ignored. The save information of the new save is returned.
+func Import(r io.Reader, force bool) (*game.SaveInfo, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+	// Manifest
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	if hdr.Name != manifestName {
+		return nil, fmt.Errorf("expected %s but found %s", manifestName, hdr.Name)
+	}
+	m := &Manifest{}
+	if err := json.NewDecoder(tr).Decode(m); err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		if !force || !errors.Is(err, ErrModMismatch) {
+			return nil, err
+		}
+	}
+	// Database, spooled to a temporary file so it can be verified before the
+	// save is created
+	hdr, err = tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("reading save database: %w", err)
+	}
+	if hdr.Name != databaseName {
+		return nil, fmt.Errorf("expected %s but found %s", databaseName, hdr.Name)
+	}
+	f, err := os.CreateTemp("", "after-import-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), tr); err != nil {
+		return nil, fmt.Errorf("reading save database: %w", err)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != m.DatabaseHash {
+		return nil, errors.New("save database is corrupt")
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	si := &game.SaveInfo{
+		Name:          m.Name,
+		Seed:          m.Seed,
+		PlayTime:      m.PlayTime,
+		EngineVersion: m.EngineVersion,
+		SaveVersion:   m.SaveVersion,
+	}
+	for _, mi := range m.Mods {
+		si.Mods = append(si.Mods, mi.ID)
+	}
+	if err := game.ImportSave(si, f); err != nil {
+		return nil, err
+	}
+	return si, nil
+}
@@ -48,6 +48,34 @@ var Palette = []color.Color{
 	color.RGBA{R: 255, G: 255, B: 255, A: 255},
 }
 
+// Names of all colors in code order
+var colorNames = []string{
+	"Black",
+	"Maroon",
+	"Green",
+	"Olive",
+	"Navy",
+	"Purple",
+	"Teal",
+	"Silver",
+	"Gray",
+	"Red",
+	"Lime",
+	"Yellow",
+	"Blue",
+	"Fuchsia",
+	"Aqua",
+	"White",
+}
+
+// String returns the name of the color.
+func (c Color) String() string {
+	if int(c) >= len(colorNames) {
+		return fmt.Sprintf("Color(%d)", c)
+	}
+	return colorNames[c]
+}
+
 func (c Color) MarshalJSON() ([]byte, error) {
 	switch c {
 	case ColorBlack:
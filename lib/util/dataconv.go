@@ -106,8 +106,8 @@ func GetString(r io.Reader) string {
 	var buf = []byte{0}
 	var ret []byte
 	for {
-		r.Read(buf)
-		if buf[0] == 0 {
+		// Truncated input ends the string
+		if n, _ := r.Read(buf); n < 1 || buf[0] == 0 {
 			return string(ret)
 		}
 		ret = append(ret, buf[0])
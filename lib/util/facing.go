@@ -32,18 +32,18 @@ func (f Facing) Bound() Facing {
 func (f Facing) MarshalJSON() ([]byte, error) {
 	switch f.Bound() {
 	case FacingNorth:
-		return []byte("North"), nil
+		return []byte(`"North"`), nil
 	case FacingEast:
-		return []byte("East"), nil
+		return []byte(`"East"`), nil
 	case FacingSouth:
-		return []byte("South"), nil
+		return []byte(`"South"`), nil
 	default:
-		return []byte("West"), nil
+		return []byte(`"West"`), nil
 	}
 }
 
 func (f *Facing) UnmarshalJSON(in []byte) error {
-	switch strings.ToLower(string(in)) {
+	switch strings.ToLower(strings.Trim(string(in), `"`)) {
 	case "north":
 		*f = FacingNorth
 	case "east":
@@ -0,0 +1,165 @@
+package util
+
+// fovQuadrants are the transforms from quadrant-relative row and column to map
+// offsets for the north, east, south and west quadrants.
+var fovQuadrants = [4]struct {
+	row Point // Map offset of one step in depth
+	col Point // Map offset of one step across the row
+}{
+	{Point{0, -1}, Point{1, 0}},
+	{Point{1, 0}, Point{0, 1}},
+	{Point{0, 1}, Point{1, 0}},
+	{Point{-1, 0}, Point{0, 1}},
+}
+
+// FOV implements symmetric shadowcasting field of view. A point is visible
+// from the origin if the line between their centers does not touch a point
+// that blocks, so if a point is visible from the origin then the origin is
+// visible from the point. Walls cast shadows with their whole square, which
+// means diagonal gaps between walls are never seen through. A wall is visible
+// if any part of it is.
+type FOV struct {
+	Blocks  func(Point) bool // Returns true if the point blocks visibility
+	Visible func(Point)      // Called for every visible point, possibly more than once
+}
+
+// fovSlope is the slope n/d of a line from the origin within a quadrant,
+// measured in columns per row of depth. d is always positive.
+type fovSlope struct {
+	n, d int
+	in   bool // If true a range ending at this slope includes it
+}
+
+// less returns true if s is less than o.
+func (s fovSlope) less(o fovSlope) bool {
+	return s.n*o.d < o.n*s.d
+}
+
+// before returns true if the range starting at s and ending at e is not empty.
+func (s fovSlope) before(e fovSlope) bool {
+	return s.less(e) || (!e.less(s) && s.in && e.in)
+}
+
+// Execute calls Visible for every point within radius of the origin that is
+// visible from the origin. Distance is measured in the same way as
+// Point.Distance. Blocks and Visible must be non-nil.
+func (f *FOV) Execute(origin Point, radius int) {
+	if f.Blocks == nil || f.Visible == nil {
+		return
+	}
+	f.Visible(origin)
+	for q := range fovQuadrants {
+		f.scan(origin, q, radius, 1, fovSlope{-1, 1, true}, fovSlope{1, 1, true})
+	}
+}
+
+// LineOfSight returns true if b is visible from a by the same rules FOV uses,
+// so it agrees with the field of view of either point. blocks returns true if
+// the point blocks visibility.
+func LineOfSight(blocks func(Point) bool, a, b Point) bool {
+	if a == b {
+		return true
+	}
+	seen := false
+	f := FOV{
+		Blocks:  blocks,
+		Visible: func(p Point) { seen = seen || p == b },
+	}
+	d := b.Sub(a)
+	for q, t := range fovQuadrants {
+		// Only scan the quadrants b may be seen from, walls next to the
+		// edge of a quadrant may be seen from the quadrant beside it
+		depth := d.X*t.row.X + d.Y*t.row.Y
+		col := d.X*t.col.X + d.Y*t.col.Y
+		if depth < 1 || col < -depth-1 || col > depth+1 {
+			continue
+		}
+		f.scan(a, q, max(depth, col, -col), 1, fovSlope{-1, 1, true}, fovSlope{1, 1, true})
+		if seen {
+			return true
+		}
+	}
+	return false
+}
+
+// fovPoint returns the map point of the column col of the row at depth in
+// quadrant q.
+func fovPoint(origin Point, q, depth, col int) Point {
+	t := fovQuadrants[q]
+	return Point{
+		X: origin.X + t.row.X*depth + t.col.X*col,
+		Y: origin.Y + t.row.Y*depth + t.col.Y*col,
+	}
+}
+
+// fovShadow returns the least and greatest slopes that touch the square of the
+// column col of the row at depth.
+func fovShadow(depth, col int) (lo, hi fovSlope) {
+	lo = fovSlope{2*col - 1, 2*depth - 1, true}
+	if col > 0 {
+		lo.d = 2*depth + 1
+	}
+	hi = fovSlope{2*col + 1, 2*depth - 1, true}
+	if col < 0 {
+		hi.d = 2*depth + 1
+	}
+	return lo, hi
+}
+
+// floorDiv returns a / b rounded toward negative infinity. b must be positive.
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
+
+// scan scans the row at depth of quadrant q between the start and end slopes,
+// recursing into the following rows.
+func (f *FOV) scan(origin Point, q, radius, depth int, start, end fovSlope) {
+	for ; depth <= radius; depth++ {
+		// Every square touching the slopes lies within a column of the
+		// columns the slopes pass through
+		minCol := floorDiv(start.n*depth, start.d) - 1
+		maxCol := -floorDiv(-end.n*depth, end.d) + 1
+		next := start
+		for col := minCol; col <= maxCol; col++ {
+			lo, hi := fovShadow(depth, col)
+			if !start.before(hi) || !lo.before(end) {
+				continue
+			}
+			// No row casts a shadow over the diagonal gap between two walls
+			// next to the origin, so points behind one are hidden here
+			if depth == 1 && (col == 1 || col == -1) &&
+				f.Blocks(fovPoint(origin, q, 0, col)) && f.Blocks(fovPoint(origin, q, 1, 0)) {
+				continue
+			}
+			p := fovPoint(origin, q, depth, col)
+			inRadius := col >= -radius && col <= radius
+			if !f.Blocks(p) {
+				// Floor is only visible if its center lies within the
+				// slopes, which is what makes the algorithm symmetric
+				center := fovSlope{col, depth, true}
+				if inRadius && start.before(center) && center.before(end) {
+					f.Visible(p)
+				}
+				continue
+			}
+			if inRadius {
+				f.Visible(p)
+			}
+			// The shadow of the wall splits the slopes, the slopes before it
+			// are scanned on their own
+			if lo.in = false; next.before(lo) {
+				f.scan(origin, q, radius, depth+1, next, lo)
+			}
+			if !hi.less(next) {
+				next = fovSlope{hi.n, hi.d, false}
+			}
+		}
+		if !next.before(end) {
+			return
+		}
+		start = next
+	}
+}
@@ -0,0 +1,216 @@
+package util
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// fovGrid is a test map for field of view. Points outside of the grid block.
+type fovGrid struct {
+	bounds Rect
+	walls  map[Point]bool
+}
+
+// newFOVGrid returns an open grid of the given size.
+func newFOVGrid(w, h int) *fovGrid {
+	return &fovGrid{
+		bounds: NewRectWH(w, h),
+		walls:  map[Point]bool{},
+	}
+}
+
+// newRandomFOVGrid returns a grid of the given size with walls scattered over
+// the given fraction of it.
+func newRandomFOVGrid(w, h int, walls float64, seed int64) *fovGrid {
+	g := newFOVGrid(w, h)
+	r := rand.New(rand.NewSource(seed))
+	var p Point
+	for p.Y = 0; p.Y < h; p.Y++ {
+		for p.X = 0; p.X < w; p.X++ {
+			if r.Float64() < walls {
+				g.walls[p] = true
+			}
+		}
+	}
+	return g
+}
+
+// blocks implements FOV.Blocks.
+func (g *fovGrid) blocks(p Point) bool {
+	return !g.bounds.Contains(p) || g.walls[p]
+}
+
+// visible returns the set of points visible from origin within radius.
+func (g *fovGrid) visible(origin Point, radius int) map[Point]bool {
+	ret := map[Point]bool{}
+	f := FOV{
+		Blocks:  g.blocks,
+		Visible: func(p Point) { ret[p] = true },
+	}
+	f.Execute(origin, radius)
+	return ret
+}
+
+// raySweep is the visibility algorithm FOV replaced: rays cast from the origin
+// to every point on the border of the square of the radius, stopping at the
+// first point that blocks, with walls next to every visible point revealed.
+func (g *fovGrid) raySweep(origin Point, radius int, visible func(Point)) {
+	b := NewRectFromRadius(origin, radius)
+	fn := func(ps []Point) {
+		for _, p := range ps[1:] {
+			if p.Distance(ps[0]) > radius {
+				break
+			}
+			visible(p)
+			var dp Point
+			for dp.Y = p.Y - 1; dp.Y <= p.Y+1; dp.Y++ {
+				for dp.X = p.X - 1; dp.X <= p.X+1; dp.X++ {
+					if g.blocks(dp) {
+						visible(dp)
+					}
+				}
+			}
+			if g.blocks(p) {
+				break
+			}
+		}
+	}
+	visible(origin)
+	for i := 0; i < b.Width(); i++ {
+		fn(Ray(origin, NewPoint(b.TL.X+i, b.TL.Y)))
+		fn(Ray(origin, NewPoint(b.TL.X+i, b.BR.Y)))
+	}
+	for i := 1; i < b.Height()-1; i++ {
+		fn(Ray(origin, NewPoint(b.TL.X, b.TL.Y+i)))
+		fn(Ray(origin, NewPoint(b.BR.X, b.TL.Y+i)))
+	}
+}
+
+func BenchmarkFOV(b *testing.B) {
+	g := newRandomFOVGrid(201, 121, 0.15, 1)
+	origin := NewPoint(100, 60)
+	delete(g.walls, origin)
+	n := 0
+	visible := func(Point) { n++ }
+	for _, radius := range []int{16, 48} {
+		b.Run(fmt.Sprintf("shadowcast-%d", radius), func(b *testing.B) {
+			f := FOV{Blocks: g.blocks, Visible: visible}
+			for i := 0; i < b.N; i++ {
+				f.Execute(origin, radius)
+			}
+		})
+		b.Run(fmt.Sprintf("ray-sweep-%d", radius), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				g.raySweep(origin, radius, visible)
+			}
+		})
+	}
+}
+
+func TestFOVSymmetry(t *testing.T) {
+	const radius = 16
+	for seed := int64(2); seed < 5; seed++ {
+		g := newRandomFOVGrid(41, 41, 0.2, seed)
+		seen := map[Point]map[Point]bool{}
+		var a Point
+		for a.Y = 0; a.Y < 41; a.Y++ {
+			for a.X = 0; a.X < 41; a.X++ {
+				if !g.blocks(a) {
+					seen[a] = g.visible(a, radius)
+				}
+			}
+		}
+		for a, v := range seen {
+			for b := range v {
+				if !g.blocks(b) && !seen[b][a] {
+					t.Fatalf("seed %d: %v sees %v but %v does not see %v", seed, a, b, b, a)
+				}
+			}
+		}
+	}
+}
+
+func TestFOVWalls(t *testing.T) {
+	// A wall across the grid hides everything behind it
+	g := newFOVGrid(21, 21)
+	for x := 0; x < 21; x++ {
+		g.walls[NewPoint(x, 10)] = true
+	}
+	v := g.visible(NewPoint(10, 15), 20)
+	if !v[NewPoint(10, 10)] {
+		t.Error("expected the wall in front of the origin to be visible")
+	}
+	for p := range v {
+		if p.Y < 10 {
+			t.Fatalf("%v is visible through a wall", p)
+		}
+	}
+	// Diagonal lines of walls have gaps between the corners of their walls
+	// that must not be seen through
+	for _, tc := range []struct {
+		wall   func(x int) Point
+		behind func(p Point) bool
+	}{
+		{func(x int) Point { return NewPoint(x, 20-x) }, func(p Point) bool { return p.X+p.Y > 20 }},
+		{func(x int) Point { return NewPoint(x, x) }, func(p Point) bool { return p.Y > p.X }},
+	} {
+		g = newFOVGrid(21, 21)
+		for x := 0; x <= 20; x++ {
+			g.walls[tc.wall(x)] = true
+		}
+		for _, origin := range []Point{NewPoint(3, 3), NewPoint(8, 2), NewPoint(15, 2), NewPoint(11, 8)} {
+			if g.blocks(origin) || tc.behind(origin) {
+				continue
+			}
+			for p := range g.visible(origin, 20) {
+				if tc.behind(p) {
+					t.Fatalf("%v is visible from %v through a diagonal gap", p, origin)
+				}
+			}
+		}
+	}
+}
+
+func TestFOVRadius(t *testing.T) {
+	g := newFOVGrid(41, 41)
+	origin := NewPoint(20, 20)
+	const radius = 7
+	v := g.visible(origin, radius)
+	var p Point
+	for p.Y = 0; p.Y < 41; p.Y++ {
+		for p.X = 0; p.X < 41; p.X++ {
+			if within := p.Distance(origin) <= radius; within != v[p] {
+				t.Fatalf("%v at distance %d visible %v with radius %d", p, p.Distance(origin), v[p], radius)
+			}
+		}
+	}
+}
+
+func TestLineOfSight(t *testing.T) {
+	const radius = 12
+	g := newRandomFOVGrid(25, 25, 0.2, 5)
+	var a, b Point
+	for a.Y = 0; a.Y < 25; a.Y++ {
+		for a.X = 0; a.X < 25; a.X++ {
+			v := g.visible(a, radius)
+			for b.Y = 0; b.Y < 25; b.Y++ {
+				for b.X = 0; b.X < 25; b.X++ {
+					if b.Distance(a) > radius {
+						continue
+					}
+					if los := LineOfSight(g.blocks, a, b); los != v[b] {
+						t.Fatalf("line of sight from %v to %v is %v but visible is %v", a, b, los, v[b])
+					}
+				}
+			}
+		}
+	}
+	// Diagonal gaps between walls are never seen through
+	g = newFOVGrid(5, 5)
+	g.walls[NewPoint(2, 1)] = true
+	g.walls[NewPoint(1, 2)] = true
+	if LineOfSight(g.blocks, NewPoint(1, 1), NewPoint(3, 3)) {
+		t.Error("line of sight through a diagonal gap")
+	}
+}
@@ -9,6 +9,30 @@ import (
 // the runtime.
 var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
 
+// NewSeed returns a new random seed value suitable for use with WithSeed.
+func NewSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// SubSeed derives a new seed value from the base seed and the given value.
+// The same inputs always result in the same output.
+func SubSeed(seed int64, v uint64) int64 {
+	// SplitMix64 finalizer
+	z := uint64(seed) + (v+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+// WithSeed executes fn with the global random number generator seeded with the
+// given seed, then restores the previous generator. Calls may be nested.
+func WithSeed(seed int64, fn func()) {
+	old := rng
+	rng = rand.New(rand.NewSource(seed))
+	defer func() { rng = old }()
+	fn()
+}
+
 // Random returns a random int within the half-open range [min-max).
 func Random(min, max int) int {
 	if min < 0 || max < 0 {
@@ -0,0 +1,178 @@
+// Package virtualdriver implements an in-memory [termui.TerminalDriver] that
+// consumes scripted input and can dump the screen as text. It is intended for
+// testing user interfaces without a display.
+package virtualdriver
+
+import (
+	"fmt"
+	"strings"
+
+	commondriver "github.com/qbradq/after/lib/common-driver"
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+// Driver is the termui.TerminalDriver implementation over an in-memory glyph
+// grid. Scripted input is held in an unbounded queue that is never flushed, so
+// every key reaches the modes in order no matter how long the script is. When
+// the scripted input runs out PollEvent returns *termui.EventQuit so all
+// running modes exit.
+type Driver struct {
+	commondriver.Driver
+	OnShow func(*Driver)  // If not nil, called after every call to Show or Sync
+	Shows  int            // Number of times Show or Sync has been called
+	w, h   int            // Dimensions of the screen
+	cells  []termui.Glyph // Glyph grid
+	queue  []any          // Scripted events not yet polled
+}
+
+// New returns a new Driver of the given dimensions ready for use.
+func New(w, h int) *Driver {
+	d := &Driver{
+		Driver: *commondriver.New(),
+	}
+	d.resize(w, h)
+	return d
+}
+
+// resize resizes the glyph grid, preserving what content it can.
+func (d *Driver) resize(w, h int) {
+	cells := make([]termui.Glyph, w*h)
+	for i := range cells {
+		cells[i] = termui.Glyph{
+			Rune:  ' ',
+			Style: termui.StyleDefault,
+		}
+	}
+	for y := 0; y < h && y < d.h; y++ {
+		for x := 0; x < w && x < d.w; x++ {
+			cells[y*w+x] = d.cells[y*d.w+x]
+		}
+	}
+	d.w = w
+	d.h = h
+	d.cells = cells
+}
+
+// Init implements the termui.TerminalDriver interface.
+func (d *Driver) Init() error { return nil }
+
+// Fini implements the termui.TerminalDriver interface.
+func (d *Driver) Fini() { d.Quit() }
+
+// Key queues key events for each rune of the string.
+func (d *Driver) Key(keys string) {
+	for _, r := range keys {
+		d.queue = append(d.queue, &termui.EventKey{Key: r})
+	}
+}
+
+// Resize queues a resize event for the given dimensions. The screen is resized
+// when the event is polled.
+func (d *Driver) Resize(w, h int) {
+	d.queue = append(d.queue, &termui.EventResize{
+		Size: util.NewPoint(w, h),
+	})
+}
+
+// PollEvent implements the termui.TerminalDriver interface.
+func (d *Driver) PollEvent() any {
+	if d.Done {
+		return &termui.EventQuit{}
+	}
+	if len(d.queue) == 0 {
+		// Out of scripted input
+		d.Done = true
+		return &termui.EventQuit{}
+	}
+	e := d.queue[0]
+	d.queue[0] = nil
+	d.queue = d.queue[1:]
+	if ev, ok := e.(*termui.EventResize); ok {
+		d.resize(ev.Size.X, ev.Size.Y)
+	}
+	return e
+}
+
+// FlushEvents implements the termui.TerminalDriver interface. Scripted input
+// stands in for keys typed ahead on purpose, so nothing is discarded.
+func (d *Driver) FlushEvents() {}
+
+// Size implements the termui.TerminalDriver interface.
+func (d *Driver) Size() (w, h int) {
+	return d.w, d.h
+}
+
+// SetCell implements the termui.TerminalDriver interface.
+func (d *Driver) SetCell(p util.Point, g termui.Glyph) {
+	if p.X < 0 || p.Y < 0 || p.X >= d.w || p.Y >= d.h {
+		return
+	}
+	d.cells[p.Y*d.w+p.X] = g
+}
+
+// GetCell implements the termui.TerminalDriver interface.
+func (d *Driver) GetCell(p util.Point) termui.Glyph {
+	if p.X < 0 || p.Y < 0 || p.X >= d.w || p.Y >= d.h {
+		return termui.Glyph{}
+	}
+	return d.cells[p.Y*d.w+p.X]
+}
+
+// Sync implements the termui.TerminalDriver interface.
+func (d *Driver) Sync() {
+	d.Show()
+}
+
+// Show implements the termui.TerminalDriver interface.
+func (d *Driver) Show() {
+	d.Shows++
+	if d.OnShow != nil {
+		d.OnShow(d)
+	}
+}
+
+// Text returns the screen contents as plain text, one line per row with
+// trailing white space removed.
+func (d *Driver) Text() string {
+	var sb strings.Builder
+	for y := 0; y < d.h; y++ {
+		var line strings.Builder
+		for _, g := range d.cells[y*d.w : (y+1)*d.w] {
+			if g.Rune == 0 {
+				line.WriteRune(' ')
+			} else {
+				line.WriteRune(g.Rune)
+			}
+		}
+		sb.WriteString(strings.TrimRight(line.String(), " "))
+		sb.WriteRune('\n')
+	}
+	return sb.String()
+}
+
+// StyledText returns the screen contents as text, one line per row, with color
+// annotations. An annotation of the form {Fg,Bg} is emitted at the start of
+// each row and every time the style changes, for example {White,Black}. A
+// literal { is written as {{.
+func (d *Driver) StyledText() string {
+	var sb strings.Builder
+	for y := 0; y < d.h; y++ {
+		for x, g := range d.cells[y*d.w : (y+1)*d.w] {
+			if x == 0 || g.Style != d.cells[y*d.w+x-1].Style {
+				fg, bg := g.Style.Decompose()
+				fmt.Fprintf(&sb, "{%s,%s}", fg, bg)
+			}
+			switch g.Rune {
+			case 0:
+				sb.WriteRune(' ')
+			case '{':
+				sb.WriteString("{{")
+			default:
+				sb.WriteRune(g.Rune)
+			}
+		}
+		sb.WriteRune('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,49 @@
+package virtualdriver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qbradq/after/lib/termui"
+	"github.com/qbradq/after/lib/util"
+)
+
+func TestLongScript(t *testing.T) {
+	d := New(10, 2)
+	keys := strings.Repeat("abc", 1000)
+	d.Key(keys)
+	for i, r := range keys {
+		if i%7 == 0 {
+			d.FlushEvents() // Game mode flushes after every turn
+		}
+		ev, ok := d.PollEvent().(*termui.EventKey)
+		if !ok {
+			t.Fatalf("event %d is not a key", i)
+		}
+		if ev.Key != r {
+			t.Fatalf("event %d is key %q, expected %q", i, ev.Key, r)
+		}
+	}
+	if _, ok := d.PollEvent().(*termui.EventQuit); !ok {
+		t.Fatal("expected quit once the script runs out")
+	}
+	if _, ok := d.PollEvent().(*termui.EventQuit); !ok {
+		t.Fatal("expected quit after the script ran out")
+	}
+}
+
+func TestResize(t *testing.T) {
+	d := New(4, 2)
+	d.SetCell(util.NewPoint(1, 1), termui.Glyph{Rune: 'x'})
+	d.Resize(6, 3)
+	if w, h := d.Size(); w != 4 || h != 2 {
+		t.Fatalf("resized before the event was polled to %dx%d", w, h)
+	}
+	d.PollEvent()
+	if w, h := d.Size(); w != 6 || h != 3 {
+		t.Fatalf("expected 6x3, got %dx%d", w, h)
+	}
+	if got := d.Text(); got != "\n x\n\n" {
+		t.Fatalf("unexpected screen %q", got)
+	}
+}